@@ -12,7 +12,16 @@ import (
 
 func main() {
 	// Define command-line flags
+	options := ui.DefaultOptions()
 	versionFlag := flag.Bool("version", false, "Print version information and exit")
+	projectFlag := flag.String("project", "", "Project directory or package.json to open (same as the path argument)")
+	flag.IntVar(&options.Detect.Depth, "depth", options.Detect.Depth, "Number of parent directories searched for package.json")
+	flag.BoolVar(&options.Detect.StopAtGitRoot, "stop-at-git-root", options.Detect.StopAtGitRoot, "Don't search for package.json above the git repository root")
+	flag.IntVar(&options.ScanDepth, "scan-depth", options.ScanDepth, "Directory levels searched below the working directory for projects to offer")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lazynode [flags] [path]\n\nOpens the project in path, a directory or a package.json, or the one around the working directory.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	// If version flag is set, print version and exit
@@ -21,11 +30,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	// The project comes from the path argument or --project, not both
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	options.Path = *projectFlag
+	if path := flag.Arg(0); path != "" {
+		if options.Path != "" && options.Path != path {
+			fmt.Fprintf(os.Stderr, "Give the project either as an argument or with --project, not both\n")
+			os.Exit(2)
+		}
+		options.Path = path
+	}
+
 	// Initialize the LazyNode application
 	fmt.Printf("Starting LazyNode %s - TUI for Node.js, npm, and npx\n", version.GetVersion())
 
 	// Create our model
-	model := ui.NewModel()
+	model := ui.NewModel(options)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
 	// Run the program
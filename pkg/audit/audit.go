@@ -0,0 +1,440 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how serious an advisory is
+type Severity int
+
+const (
+	Info Severity = iota
+	Low
+	Moderate
+	High
+	Critical
+)
+
+// Severities lists every severity from the most to the least serious
+var Severities = []Severity{Critical, High, Moderate, Low, Info}
+
+// String returns the severity name used by npm
+func (s Severity) String() string {
+	switch s {
+	case Low:
+		return "low"
+	case Moderate:
+		return "moderate"
+	case High:
+		return "high"
+	case Critical:
+		return "critical"
+	}
+	return "info"
+}
+
+// ParseSeverity parses a severity name, case-insensitively
+func ParseSeverity(name string) Severity {
+	switch strings.ToLower(name) {
+	case "low":
+		return Low
+	case "moderate", "medium":
+		return Moderate
+	case "high":
+		return High
+	case "critical":
+		return Critical
+	}
+	return Info
+}
+
+// Advisory is a vulnerability affecting an installed package
+type Advisory struct {
+	ID       string
+	Package  string
+	Title    string
+	URL      string
+	Severity Severity
+	// VulnerableRange is the range of affected versions, e.g. "<1.2.6"
+	VulnerableRange string
+	// PatchedRange is the range of fixed versions, e.g. ">=1.2.6", if known
+	PatchedRange string
+	// Paths lists where the package is installed, e.g. "app > a > minimist"
+	Paths []string
+	// Direct is set when the project depends on the package itself
+	Direct bool
+	// FixAvailable is set when the package manager can fix the advisory
+	FixAvailable bool
+	// FixPackage and FixVersion name the install that fixes it, when known
+	FixPackage string
+	FixVersion string
+	// FixIsMajor is set when the fix needs a semver-major upgrade
+	FixIsMajor bool
+}
+
+// Report is the result of an audit
+type Report struct {
+	Advisories []Advisory
+}
+
+// Counts returns the number of advisories per severity
+func (r *Report) Counts() map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, advisory := range r.Advisories {
+		counts[advisory.Severity]++
+	}
+	return counts
+}
+
+// Summary describes the counts, e.g. "2 critical, 1 high"
+func (r *Report) Summary() string {
+	counts := r.Counts()
+
+	var parts []string
+	for _, severity := range Severities {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Parse reads the JSON output of npm audit (v7+ and the older advisories
+// format also used by pnpm), yarn audit (one JSON object per line) or
+// yarn npm audit, and returns the advisories sorted by severity.
+func Parse(output []byte) (*Report, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, fmt.Errorf("empty audit output")
+	}
+
+	var probe map[string]json.RawMessage
+	var advisories []Advisory
+	var err error
+
+	switch {
+	case json.Unmarshal(output, &probe) != nil, probe["type"] != nil, probe["children"] != nil:
+		// One JSON object per line
+		advisories, err = parseLines(output)
+	case probe["vulnerabilities"] != nil && probe["auditReportVersion"] != nil:
+		advisories, err = parseNpm(output)
+	case probe["advisories"] != nil:
+		advisories, err = parseAdvisories(probe["advisories"])
+	case probe["error"] != nil:
+		return nil, fmt.Errorf("audit failed: %s", auditError(probe["error"]))
+	default:
+		advisories, err = parseBulk(probe)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sortAdvisories(advisories)
+	return &Report{Advisories: advisories}, nil
+}
+
+// sortAdvisories orders advisories by severity, then by package
+func sortAdvisories(advisories []Advisory) {
+	sort.SliceStable(advisories, func(i, j int) bool {
+		if advisories[i].Severity != advisories[j].Severity {
+			return advisories[i].Severity > advisories[j].Severity
+		}
+		if advisories[i].Package != advisories[j].Package {
+			return advisories[i].Package < advisories[j].Package
+		}
+		return advisories[i].ID < advisories[j].ID
+	})
+}
+
+// auditError extracts the message of an npm error object
+func auditError(data json.RawMessage) string {
+	var e struct {
+		Summary string `json:"summary"`
+		Detail  string `json:"detail"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Summary != "" {
+		return e.Summary
+	}
+	return string(data)
+}
+
+// npmVulnerability is an entry of the npm v7+ vulnerabilities map
+type npmVulnerability struct {
+	Name         string            `json:"name"`
+	Severity     string            `json:"severity"`
+	IsDirect     bool              `json:"isDirect"`
+	Via          []json.RawMessage `json:"via"`
+	Range        string            `json:"range"`
+	Nodes        []string          `json:"nodes"`
+	FixAvailable json.RawMessage   `json:"fixAvailable"`
+}
+
+// npmVia is an advisory that makes a package vulnerable
+type npmVia struct {
+	Source   json.Number `json:"source"`
+	Name     string      `json:"name"`
+	Title    string      `json:"title"`
+	URL      string      `json:"url"`
+	Severity string      `json:"severity"`
+	Range    string      `json:"range"`
+}
+
+// parseNpm parses the report of npm audit --json from npm 7 on. Packages
+// that are only vulnerable through another package are left out, their
+// advisory is listed under the package it comes from.
+func parseNpm(output []byte) ([]Advisory, error) {
+	var report struct {
+		Vulnerabilities map[string]npmVulnerability `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+
+	var advisories []Advisory
+	for name, vuln := range report.Vulnerabilities {
+		// fixAvailable is a bool, or the install that fixes it
+		var fix struct {
+			Name          string `json:"name"`
+			Version       string `json:"version"`
+			IsSemVerMajor bool   `json:"isSemVerMajor"`
+		}
+		var fixAvailable bool
+		if json.Unmarshal(vuln.FixAvailable, &fixAvailable) != nil {
+			fixAvailable = json.Unmarshal(vuln.FixAvailable, &fix) == nil && fix.Name != ""
+		}
+
+		// Install paths such as node_modules/a/node_modules/minimist
+		var paths []string
+		for _, node := range vuln.Nodes {
+			paths = append(paths, strings.ReplaceAll(strings.TrimPrefix(node, "node_modules/"), "/node_modules/", " > "))
+		}
+
+		for _, raw := range vuln.Via {
+			var via npmVia
+			if json.Unmarshal(raw, &via) != nil {
+				continue // The name of another vulnerable package
+			}
+
+			advisories = append(advisories, Advisory{
+				ID:              via.Source.String(),
+				Package:         name,
+				Title:           via.Title,
+				URL:             via.URL,
+				Severity:        ParseSeverity(via.Severity),
+				VulnerableRange: via.Range,
+				PatchedRange:    PatchedRange(via.Range),
+				Paths:           paths,
+				Direct:          vuln.IsDirect,
+				FixAvailable:    fixAvailable,
+				FixPackage:      fix.Name,
+				FixVersion:      fix.Version,
+				FixIsMajor:      fix.IsSemVerMajor,
+			})
+		}
+	}
+
+	return advisories, nil
+}
+
+// legacyAdvisory is an advisory of the npm 6 format, still printed by pnpm and yarn
+type legacyAdvisory struct {
+	ID                 json.Number `json:"id"`
+	ModuleName         string      `json:"module_name"`
+	Title              string      `json:"title"`
+	URL                string      `json:"url"`
+	Severity           string      `json:"severity"`
+	VulnerableVersions string      `json:"vulnerable_versions"`
+	PatchedVersions    string      `json:"patched_versions"`
+	Findings           []struct {
+		Version string   `json:"version"`
+		Paths   []string `json:"paths"`
+	} `json:"findings"`
+}
+
+// advisory converts a legacy advisory
+func (a legacyAdvisory) advisory() Advisory {
+	advisory := Advisory{
+		ID:              a.ID.String(),
+		Package:         a.ModuleName,
+		Title:           a.Title,
+		URL:             a.URL,
+		Severity:        ParseSeverity(a.Severity),
+		VulnerableRange: a.VulnerableVersions,
+		PatchedRange:    a.PatchedVersions,
+		FixAvailable:    a.PatchedVersions != "" && a.PatchedVersions != "<0.0.0",
+	}
+	if advisory.PatchedRange == "" || advisory.PatchedRange == "<0.0.0" {
+		advisory.PatchedRange = PatchedRange(a.VulnerableVersions)
+	}
+
+	for _, finding := range a.Findings {
+		for _, path := range finding.Paths {
+			// pnpm and npm 6 paths look like ".>a>minimist" or "a>minimist"
+			path = strings.TrimPrefix(strings.TrimPrefix(path, "."), ">")
+			advisory.Paths = append(advisory.Paths, strings.ReplaceAll(path, ">", " > "))
+			if !strings.Contains(path, ">") {
+				advisory.Direct = true
+			}
+		}
+	}
+
+	return advisory
+}
+
+// parseAdvisories parses an id-keyed advisories object (npm 6, pnpm, yarn 3)
+func parseAdvisories(data json.RawMessage) ([]Advisory, error) {
+	var raw map[string]legacyAdvisory
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var advisories []Advisory
+	for _, a := range raw {
+		advisories = append(advisories, a.advisory())
+	}
+	return advisories, nil
+}
+
+// parseLines parses yarn classic (auditAdvisory events) and yarn 4
+// (value/children entries) output, both one JSON object per line
+func parseLines(output []byte) ([]Advisory, error) {
+	byID := make(map[string]*Advisory)
+	var order []string
+
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var event struct {
+			Type string `json:"type"`
+			Data struct {
+				Resolution struct {
+					Path string `json:"path"`
+				} `json:"resolution"`
+				Advisory legacyAdvisory `json:"advisory"`
+			} `json:"data"`
+			// yarn 4
+			Value    string `json:"value"`
+			Children struct {
+				ID                 json.Number `json:"ID"`
+				Issue              string      `json:"Issue"`
+				URL                string      `json:"URL"`
+				Severity           string      `json:"Severity"`
+				VulnerableVersions string      `json:"Vulnerable Versions"`
+				Dependents         []string    `json:"Dependents"`
+			} `json:"children"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("invalid audit line: %v", err)
+		}
+
+		var advisory Advisory
+		var path string
+		switch {
+		case event.Type == "auditAdvisory":
+			advisory = event.Data.Advisory.advisory()
+			advisory.Paths = nil
+			path = strings.ReplaceAll(event.Data.Resolution.Path, ">", " > ")
+		case event.Value != "" && event.Children.ID != "":
+			advisory = Advisory{
+				ID:              event.Children.ID.String(),
+				Package:         event.Value,
+				Title:           event.Children.Issue,
+				URL:             event.Children.URL,
+				Severity:        ParseSeverity(event.Children.Severity),
+				VulnerableRange: event.Children.VulnerableVersions,
+				PatchedRange:    PatchedRange(event.Children.VulnerableVersions),
+			}
+			advisory.FixAvailable = advisory.PatchedRange != ""
+			for _, dependent := range event.Children.Dependents {
+				advisory.Paths = append(advisory.Paths, dependent+" > "+event.Value)
+			}
+		default:
+			continue // Summary and info lines
+		}
+
+		// yarn classic prints one event per path of the same advisory
+		key := advisory.ID + "/" + advisory.Package
+		existing, ok := byID[key]
+		if !ok {
+			existing = &advisory
+			byID[key] = existing
+			order = append(order, key)
+		}
+		if path != "" {
+			existing.Paths = append(existing.Paths, path)
+			if !strings.Contains(path, ">") {
+				existing.Direct = true
+			}
+		}
+	}
+
+	advisories := make([]Advisory, 0, len(order))
+	for _, key := range order {
+		advisories = append(advisories, *byID[key])
+	}
+	return advisories, nil
+}
+
+// parseBulk parses the registry bulk advisory format printed by bun audit:
+// package names mapped to their advisories
+func parseBulk(probe map[string]json.RawMessage) ([]Advisory, error) {
+	var advisories []Advisory
+	for name, data := range probe {
+		var entries []struct {
+			ID                 json.Number `json:"id"`
+			Title              string      `json:"title"`
+			URL                string      `json:"url"`
+			Severity           string      `json:"severity"`
+			VulnerableVersions string      `json:"vulnerable_versions"`
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("unrecognized audit output")
+		}
+
+		for _, entry := range entries {
+			advisory := Advisory{
+				ID:              entry.ID.String(),
+				Package:         name,
+				Title:           entry.Title,
+				URL:             entry.URL,
+				Severity:        ParseSeverity(entry.Severity),
+				VulnerableRange: entry.VulnerableVersions,
+				PatchedRange:    PatchedRange(entry.VulnerableVersions),
+			}
+			advisory.FixAvailable = advisory.PatchedRange != ""
+			advisories = append(advisories, advisory)
+		}
+	}
+	return advisories, nil
+}
+
+// PatchedRange derives the fixed versions from a vulnerable range by
+// taking the upper bound of its last set: "<1.2.6" or ">=1.0.0 <1.2.6"
+// give ">=1.2.6", "<=1.2.5" gives ">1.2.5". It returns "" when the range
+// has no upper bound.
+func PatchedRange(vulnerable string) string {
+	sets := strings.Split(vulnerable, "||")
+	last := strings.Fields(sets[len(sets)-1])
+
+	for i := len(last) - 1; i >= 0; i-- {
+		comparator := last[i]
+		switch {
+		case strings.HasPrefix(comparator, "<="):
+			return ">" + strings.TrimPrefix(comparator, "<=")
+		case strings.HasPrefix(comparator, "<"):
+			return ">=" + strings.TrimPrefix(comparator, "<")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Advisories shared by the fixtures
+var (
+	minimistGHSA = Advisory{
+		ID:              "1096465",
+		Package:         "minimist",
+		Title:           "Prototype Pollution in minimist",
+		URL:             "https://github.com/advisories/GHSA-xvch-5gv4-984h",
+		Severity:        Critical,
+		VulnerableRange: "<0.2.4",
+		PatchedRange:    ">=0.2.4",
+		FixAvailable:    true,
+	}
+	lodashGHSA = Advisory{
+		ID:              "1096996",
+		Package:         "lodash",
+		Title:           "Command Injection in lodash",
+		URL:             "https://github.com/advisories/GHSA-35jh-r3h4-6jhm",
+		Severity:        High,
+		VulnerableRange: "<4.17.21",
+		PatchedRange:    ">=4.17.21",
+		FixAvailable:    true,
+	}
+)
+
+// with returns a copy of an advisory changed by fn
+func with(advisory Advisory, fn func(*Advisory)) Advisory {
+	fn(&advisory)
+	return advisory
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Advisory
+		summary string
+	}{
+		{
+			fixture: "npm-v7.json",
+			want: []Advisory{
+				with(minimistGHSA, func(a *Advisory) {
+					a.Paths = []string{"mkdirp > minimist"}
+					a.FixPackage = "mkdirp"
+					a.FixVersion = "3.0.1"
+					a.FixIsMajor = true
+				}),
+				with(lodashGHSA, func(a *Advisory) {
+					a.Paths = []string{"lodash"}
+					a.Direct = true
+				}),
+				{
+					ID:              "1097130",
+					Package:         "lodash",
+					Title:           "Regular Expression Denial of Service (ReDoS) in lodash",
+					URL:             "https://github.com/advisories/GHSA-29mw-wpgm-hmr9",
+					Severity:        Moderate,
+					VulnerableRange: "<4.17.21",
+					PatchedRange:    ">=4.17.21",
+					Paths:           []string{"lodash"},
+					Direct:          true,
+					FixAvailable:    true,
+				},
+			},
+			summary: "1 critical, 1 high, 1 moderate",
+		},
+		{
+			fixture: "npm-v6.json",
+			want: []Advisory{
+				{
+					ID:              "1523",
+					Package:         "lodash",
+					Title:           "Prototype Pollution",
+					URL:             "https://npmjs.com/advisories/1523",
+					Severity:        Low,
+					VulnerableRange: "<4.17.19",
+					PatchedRange:    ">=4.17.19",
+					Paths:           []string{"lodash"},
+					Direct:          true,
+					FixAvailable:    true,
+				},
+				{
+					ID:              "1179",
+					Package:         "minimist",
+					Title:           "Prototype Pollution",
+					URL:             "https://npmjs.com/advisories/1179",
+					Severity:        Low,
+					VulnerableRange: "<0.2.1 || >=1.0.0 <1.2.3",
+					PatchedRange:    ">=0.2.1 <1.0.0 || >=1.2.3",
+					Paths:           []string{"mkdirp > minimist"},
+					FixAvailable:    true,
+				},
+			},
+			summary: "2 low",
+		},
+		{
+			fixture: "pnpm.json",
+			want: []Advisory{
+				with(minimistGHSA, func(a *Advisory) {
+					a.Paths = []string{"mkdirp > minimist"}
+				}),
+				{
+					// No patched version: "<0.0.0"
+					ID:              "1097493",
+					Package:         "request",
+					Title:           "Server-Side Request Forgery in Request",
+					URL:             "https://github.com/advisories/GHSA-p8p7-x288-28g6",
+					Severity:        Moderate,
+					VulnerableRange: "<=2.88.2",
+					PatchedRange:    ">2.88.2",
+					Paths:           []string{"request", "legacy-client > request"},
+					Direct:          true,
+				},
+			},
+			summary: "1 critical, 1 moderate",
+		},
+		{
+			fixture: "yarn-classic.ndjson",
+			want: []Advisory{
+				with(minimistGHSA, func(a *Advisory) {
+					a.Paths = []string{"mkdirp > minimist", "optimist > minimist"}
+				}),
+				with(lodashGHSA, func(a *Advisory) {
+					a.Paths = []string{"lodash"}
+					a.Direct = true
+				}),
+			},
+			summary: "1 critical, 1 high",
+		},
+		{
+			fixture: "yarn-berry.ndjson",
+			want: []Advisory{
+				with(minimistGHSA, func(a *Advisory) {
+					a.Paths = []string{"mkdirp@npm:0.5.1 > minimist", "optimist@npm:0.6.1 > minimist"}
+				}),
+				with(lodashGHSA, func(a *Advisory) {
+					a.Paths = []string{"app@workspace:. > lodash"}
+				}),
+				{
+					// Every version is vulnerable
+					ID:              "1097493",
+					Package:         "request",
+					Title:           "Server-Side Request Forgery in Request",
+					URL:             "https://github.com/advisories/GHSA-p8p7-x288-28g6",
+					Severity:        Moderate,
+					VulnerableRange: ">=0.0.0",
+					Paths:           []string{"app@workspace:. > request"},
+				},
+			},
+			summary: "1 critical, 1 high, 1 moderate",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := Parse(output)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if len(report.Advisories) != len(tc.want) {
+				t.Fatalf("got %d advisories, want %d: %+v", len(report.Advisories), len(tc.want), report.Advisories)
+			}
+			for i, want := range tc.want {
+				if got := report.Advisories[i]; !reflect.DeepEqual(got, want) {
+					t.Errorf("advisory %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+			if got := report.Summary(); got != tc.summary {
+				t.Errorf("Summary() = %q, want %q", got, tc.summary)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "npm-error.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Parse(output)
+	if err == nil || !strings.Contains(err.Error(), "requires an existing lockfile") {
+		t.Fatalf("Parse error = %v, want the npm error summary", err)
+	}
+
+	if _, err := Parse([]byte("  \n")); err == nil {
+		t.Error("Parse of empty output succeeded, want an error")
+	}
+}
+
+func TestPatchedRange(t *testing.T) {
+	tests := []struct {
+		vulnerable string
+		want       string
+	}{
+		{"<1.2.6", ">=1.2.6"},
+		{">=1.0.0 <1.2.6", ">=1.2.6"},
+		{"<=1.2.5", ">1.2.5"},
+		{"<0.2.1 || >=1.0.0 <1.2.3", ">=1.2.3"},
+		{">=0.0.0", ""},
+		{"*", ""},
+	}
+	for _, tc := range tests {
+		if got := PatchedRange(tc.vulnerable); got != tc.want {
+			t.Errorf("PatchedRange(%q) = %q, want %q", tc.vulnerable, got, tc.want)
+		}
+	}
+}
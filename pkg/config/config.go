@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds the per-project LazyNode settings stored in .lazynode/config.json
+type Config struct {
+	// PackageManager overrides package manager detection ("npm", "pnpm", "yarn", "yarn-berry" or "bun")
+	PackageManager string `json:"packageManager,omitempty"`
+	// PTY runs scripts and npx commands under a pseudo-terminal (default false).
+	// Stdout and stderr are then one stream, so lines are no longer told apart.
+	PTY *bool `json:"pty,omitempty"`
+	// StopGracePeriod is how long to wait after each stop signal, e.g. "3s"
+	StopGracePeriod string `json:"stopGracePeriod,omitempty"`
+	// Offline answers registry requests from the cache in .lazynode/registry-cache
+	Offline bool `json:"offline,omitempty"`
+}
+
+// GracePeriod returns the stop grace period, defaulting to three seconds
+func (c *Config) GracePeriod() time.Duration {
+	if d, err := time.ParseDuration(c.StopGracePeriod); err == nil && d > 0 {
+		return d
+	}
+	return 3 * time.Second
+}
+
+// UsePTY reports whether processes should run under a pseudo-terminal,
+// which has to be turned on
+func (c *Config) UsePTY() bool {
+	return c.PTY != nil && *c.PTY
+}
+
+// Path returns the location of the config file for the given project directory
+func Path(projectDir string) string {
+	return filepath.Join(projectDir, ".lazynode", "config.json")
+}
+
+// Load reads the config for the given project directory.
+// A missing config file is not an error and yields the default config.
+func Load(projectDir string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(Path(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
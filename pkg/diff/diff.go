@@ -0,0 +1,252 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is what happens to a line between the old and the new text
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Line is a line of a diff
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a group of changes with the lines around them
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the "@@ -1,4 +1,5 @@" line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// maxEdits bounds the work done on very different texts, such as a
+// lockfile rewritten by another client. Past it the changed block is shown
+// as removed and added as a whole.
+const maxEdits = 2000
+
+// SplitLines splits a text into lines, ignoring a final newline
+func SplitLines(text []byte) []string {
+	s := strings.ReplaceAll(string(text), "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Lines returns the shortest edit script turning a into b, using Myers'
+// algorithm on what remains after the common prefix and suffix
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Equal, text})
+	}
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Equal, text})
+	}
+	return lines
+}
+
+// window is the furthest x reached on each diagonal k in [-radius, radius]
+type window struct {
+	radius int
+	x      []int
+}
+
+func (w window) at(k int) int {
+	return w.x[k+w.radius]
+}
+
+// myers finds the edit script with the fewest insertions and deletions
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace []window
+
+	edits := -1
+	for d := 0; d <= limit && edits < 0; d++ {
+		// Keep the diagonals the backtrack of this round reads
+		trace = append(trace, window{d + 1, append([]int(nil), v[offset-d-1:offset+d+2]...)})
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Down: insert from b
+			} else {
+				x = v[offset+k-1] + 1 // Right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				edits = d
+				break
+			}
+		}
+	}
+
+	if edits < 0 {
+		// Too different, replace the block
+		lines := make([]Line, 0, n+m)
+		for _, text := range a {
+			lines = append(lines, Line{Delete, text})
+		}
+		for _, text := range b {
+			lines = append(lines, Line{Insert, text})
+		}
+		return lines
+	}
+
+	// Walk back from the end, collecting the lines in reverse
+	var reversed []Line
+	x, y := n, m
+	for d := edits; d > 0; d-- {
+		w := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && w.at(k-1) < w.at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := w.at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if prevK == k+1 {
+			reversed = append(reversed, Line{Insert, b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, Line{Delete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Line{Equal, a[x-1]})
+		x--
+		y--
+	}
+
+	lines := make([]Line, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
+
+// Unified compares two texts and groups the changes into hunks with the
+// given number of unchanged lines around them, like diff -u
+func Unified(old, new []byte, context int) []Hunk {
+	lines := Lines(SplitLines(old), SplitLines(new))
+
+	// Line numbers reached before each line
+	oldBefore := make([]int, len(lines)+1)
+	newBefore := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldBefore[i+1], newBefore[i+1] = oldBefore[i], newBefore[i]
+		if line.Op != Insert {
+			oldBefore[i+1]++
+		}
+		if line.Op != Delete {
+			newBefore[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// Take in the changes separated by less than twice the context
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Op != Equal {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		stop := end + context + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		hunk := Hunk{
+			OldStart: oldBefore[start],
+			OldLines: oldBefore[stop] - oldBefore[start],
+			NewStart: newBefore[start],
+			NewLines: newBefore[stop] - newBefore[start],
+			Lines:    lines[start:stop],
+		}
+		// Ranges start at the first line, or the line before when empty
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+		hunks = append(hunks, hunk)
+		i = stop
+	}
+	return hunks
+}
+
+// Stats counts the added and removed lines of hunks
+func Stats(hunks []Hunk) (added, removed int) {
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case Insert:
+				added++
+			case Delete:
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
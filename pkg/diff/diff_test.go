@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render writes lines the way diff -u does: " ", "-" or "+" and the text
+func render(lines []Line) []string {
+	prefixes := map[Op]string{Equal: " ", Delete: "-", Insert: "+"}
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = prefixes[line.Op] + line.Text
+	}
+	return rendered
+}
+
+// numbered returns "1\n2\n...\nn\n" with the given lines replaced
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if text, ok := replace[i]; ok {
+			b.WriteString(text + "\n")
+		} else {
+			fmt.Fprintf(&b, "%d\n", i)
+		}
+	}
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		context  int
+		headers  []string
+		lines    [][]string
+	}{
+		{
+			name: "unchanged",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+		},
+		{
+			name:    "empty to text",
+			old:     "",
+			new:     "a\nb\n",
+			context: 3,
+			headers: []string{"@@ -0,0 +1,2 @@"},
+			lines:   [][]string{{"+a", "+b"}},
+		},
+		{
+			name:    "text to empty",
+			old:     "a\nb\n",
+			new:     "",
+			context: 3,
+			headers: []string{"@@ -1,2 +0,0 @@"},
+			lines:   [][]string{{"-a", "-b"}},
+		},
+		{
+			name:    "changed line",
+			old:     "a\nb\nc\nd\ne\n",
+			new:     "a\nb\nX\nd\ne\n",
+			context: 1,
+			headers: []string{"@@ -2,3 +2,3 @@"},
+			lines:   [][]string{{" b", "-c", "+X", " d"}},
+		},
+		{
+			name:    "inserted line",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nnew\nc\n",
+			context: 1,
+			headers: []string{"@@ -2,2 +2,3 @@"},
+			lines:   [][]string{{" b", "+new", " c"}},
+		},
+		{
+			name:    "deleted first line",
+			old:     "a\nb\nc\n",
+			new:     "b\nc\n",
+			context: 1,
+			headers: []string{"@@ -1,2 +1,1 @@"},
+			lines:   [][]string{{"-a", " b"}},
+		},
+		{
+			name:    "distant changes",
+			old:     numbered(10, nil),
+			new:     numbered(10, map[int]string{2: "two", 9: "nine"}),
+			context: 1,
+			headers: []string{"@@ -1,3 +1,3 @@", "@@ -8,3 +8,3 @@"},
+			lines: [][]string{
+				{" 1", "-2", "+two", " 3"},
+				{" 8", "-9", "+nine", " 10"},
+			},
+		},
+		{
+			name:    "changes within twice the context",
+			old:     numbered(10, nil),
+			new:     numbered(10, map[int]string{2: "two", 9: "nine"}),
+			context: 3,
+			headers: []string{"@@ -1,10 +1,10 @@"},
+			lines: [][]string{
+				{" 1", "-2", "+two", " 3", " 4", " 5", " 6", " 7", " 8", "-9", "+nine", " 10"},
+			},
+		},
+		{
+			name:    "windows line endings",
+			old:     "a\r\nb\r\n",
+			new:     "a\nc\n",
+			context: 1,
+			headers: []string{"@@ -1,2 +1,2 @@"},
+			lines:   [][]string{{" a", "-b", "+c"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hunks := Unified([]byte(tc.old), []byte(tc.new), tc.context)
+
+			var headers []string
+			var lines [][]string
+			for _, hunk := range hunks {
+				headers = append(headers, hunk.Header())
+				lines = append(lines, render(hunk.Lines))
+			}
+			if !reflect.DeepEqual(headers, tc.headers) {
+				t.Errorf("headers = %q, want %q", headers, tc.headers)
+			}
+			if !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("lines = %q, want %q", lines, tc.lines)
+			}
+		})
+	}
+}
+
+func TestLinesMaxEdits(t *testing.T) {
+	// b inserts a line after every line of a: n insertions
+	interleaved := func(n int) ([]string, []string) {
+		var a, b []string
+		for i := 0; i < n; i++ {
+			a = append(a, fmt.Sprintf("line %d", i))
+			b = append(b, fmt.Sprintf("line %d", i), fmt.Sprintf("new %d", i))
+		}
+		return a, b
+	}
+
+	// Within the bound the script is the shortest
+	a, b := interleaved(100)
+	added, removed := Stats([]Hunk{{Lines: Lines(a, b)}})
+	if added != 100 || removed != 0 {
+		t.Errorf("100 insertions: +%d -%d, want +100 -0", added, removed)
+	}
+
+	// Past it the block after the common prefix is replaced as a whole
+	a, b = interleaved(maxEdits + 100)
+	lines := Lines(a, b)
+	added, removed = Stats([]Hunk{{Lines: lines}})
+	if added != len(b)-1 || removed != len(a)-1 {
+		t.Errorf("%d insertions: +%d -%d, want +%d -%d", len(a), added, removed, len(b)-1, len(a)-1)
+	}
+	if got := render(lines[:2]); !reflect.DeepEqual(got, []string{" line 0", "-line 1"}) {
+		t.Errorf("replaced block starts with %q, want the prefix then the removed lines", got)
+	}
+	if last := lines[len(lines)-1]; last.Op != Insert {
+		t.Errorf("replaced block ends with %q, want the added lines", render([]Line{last}))
+	}
+}
@@ -0,0 +1,38 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitHead returns a file as committed in HEAD of the repository holding
+// it. A file that isn't committed yet returns false.
+func GitHead(path string) ([]byte, bool, error) {
+	cmd := exec.Command("git", "show", "HEAD:./"+filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err == nil {
+		return output, true, nil
+	}
+
+	message := strings.TrimSpace(stderr.String())
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, false, fmt.Errorf("git is not installed")
+	case strings.Contains(message, "not a git repository"):
+		return nil, false, fmt.Errorf("the project is not in a git repository")
+	case strings.Contains(message, "does not exist"),
+		strings.Contains(message, "exists on disk, but not in"),
+		strings.Contains(message, "invalid object name"):
+		// Not committed, or nothing committed at all
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("git show error: %v - %s", err, message)
+}
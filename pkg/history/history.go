@@ -0,0 +1,426 @@
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+)
+
+// maxEntries is the number of operations kept in the journal
+const maxEntries = 100
+
+// lockfileNames are the lockfiles snapshotted next to package.json
+var lockfileNames = []string{
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lock",
+	"bun.lockb",
+}
+
+// File is a file changed by an operation. Before and After name the
+// snapshots of its content, "" when the file didn't exist.
+type File struct {
+	Path   string `json:"path"` // Relative to the project directory, with slashes
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Entry is an operation that changed package.json or the lockfile
+type Entry struct {
+	ID          int       `json:"id"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+	Failed      bool      `json:"failed,omitempty"` // The operation returned an error
+	Files       []File    `json:"files"`
+}
+
+// journal is the layout of .lazynode/history/journal.json
+type journal struct {
+	// Position is the number of entries applied: undo goes back from it
+	// and redo forward
+	Position int     `json:"position"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Journal records package.json and the lockfile around every mutating
+// operation, under .lazynode/history, so that they can be undone and redone.
+// A nil Journal records nothing.
+type Journal struct {
+	ProjectDir string
+	Dir        string
+
+	running  sync.Mutex // Held across an operation, so that they run one at a time
+	mu       sync.Mutex
+	revision int // Operations recorded, undone and redone so far
+}
+
+// journals holds the journal of every project opened, so that everything
+// writing to a project shares one lock and sees the operations of the others
+var journals = struct {
+	sync.Mutex
+	byDir map[string]*Journal
+}{byDir: make(map[string]*Journal)}
+
+// Open returns the journal of the project in projectDir. Every call for the
+// same directory returns the same journal.
+func Open(projectDir string) *Journal {
+	key := filepath.Clean(projectDir)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+
+	journals.Lock()
+	defer journals.Unlock()
+
+	if j, ok := journals.byDir[key]; ok {
+		return j
+	}
+	j := &Journal{
+		ProjectDir: projectDir,
+		Dir:        filepath.Join(projectDir, ".lazynode", "history"),
+	}
+	journals.byDir[key] = j
+	return j
+}
+
+// Record runs op and adds an entry when it changed package.json or the
+// lockfile, failed or not. Operations run one at a time, so that each entry
+// holds only the changes of its own operation: op must not record on the
+// same journal. A journal that can't be written doesn't fail the operation.
+func (j *Journal) Record(description string, op func() error) error {
+	return j.RecordFiles(description, nil, op)
+}
+
+// RecordFiles is Record, also tracking files outside the project, such as
+// the package.json of other packages of a monorepo
+func (j *Journal) RecordFiles(description string, paths []string, op func() error) error {
+	if j == nil {
+		return op()
+	}
+
+	j.running.Lock()
+	defer j.running.Unlock()
+
+	before := j.snapshot(paths)
+	err := op()
+	after := j.snapshot(paths)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.revision++
+	j.add(description, before, after, paths, err != nil)
+	return err
+}
+
+// Revision counts the operations recorded, undone and redone since the
+// journal was opened, so that readers of the files can tell they are behind
+func (j *Journal) Revision() int {
+	if j == nil {
+		return 0
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.revision
+}
+
+// Entries returns the recorded entries, oldest first, and the number of
+// them currently applied
+func (j *Journal) Entries() ([]Entry, int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	state, err := j.load()
+	if err != nil {
+		return nil, 0, err
+	}
+	return state.Entries, state.Position, nil
+}
+
+// Last returns the last applied entry, false when there is none
+func (j *Journal) Last() (*Entry, bool, error) {
+	entries, position, err := j.Entries()
+	if err != nil || position == 0 {
+		return nil, false, err
+	}
+	return &entries[position-1], true, nil
+}
+
+// Read returns the content of a snapshot
+func (j *Journal) Read(object string) ([]byte, error) {
+	return os.ReadFile(j.objectPath(object))
+}
+
+// FilePath returns the location of a recorded file
+func (j *Journal) FilePath(file File) string {
+	return filepath.Join(j.ProjectDir, filepath.FromSlash(file.Path))
+}
+
+// Undo puts the files of the last applied entry back as they were before
+// it, once the running operation, if any, is done
+func (j *Journal) Undo() (*Entry, error) {
+	j.running.Lock()
+	defer j.running.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	state, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+	if state.Position == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	entry := state.Entries[state.Position-1]
+	j.revision++
+	if err := j.restore(entry, true); err != nil {
+		return nil, err
+	}
+
+	state.Position--
+	return &entry, j.save(state)
+}
+
+// Redo applies the files of the entry after the last applied one again
+func (j *Journal) Redo() (*Entry, error) {
+	j.running.Lock()
+	defer j.running.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	state, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+	if state.Position == len(state.Entries) {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	entry := state.Entries[state.Position]
+	j.revision++
+	if err := j.restore(entry, false); err != nil {
+		return nil, err
+	}
+
+	state.Position++
+	return &entry, j.save(state)
+}
+
+// trackedFiles returns package.json and the lockfiles of the project,
+// including a workspace lockfile in a parent directory, and the extra paths
+func (j *Journal) trackedFiles(extra []string) []string {
+	paths := []string{filepath.Join(j.ProjectDir, "package.json")}
+	for _, name := range lockfileNames {
+		paths = append(paths, filepath.Join(j.ProjectDir, name))
+	}
+
+	if path, _, err := lockfile.Find(j.ProjectDir); err == nil && filepath.Dir(path) != j.ProjectDir {
+		paths = append(paths, path)
+	}
+
+	// Skip the extra paths tracked already
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		seen[path] = true
+	}
+	for _, path := range extra {
+		if path = filepath.Clean(path); !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// snapshot reads the tracked files that exist
+func (j *Journal) snapshot(extra []string) map[string][]byte {
+	contents := make(map[string][]byte)
+	for _, path := range j.trackedFiles(extra) {
+		if data, err := os.ReadFile(path); err == nil {
+			contents[path] = data
+		}
+	}
+	return contents
+}
+
+// add stores the files that differ between two snapshots as a new entry.
+// Entries undone before are dropped: they can't be redone after this.
+func (j *Journal) add(description string, before, after map[string][]byte, extra []string, failed bool) error {
+	entry := Entry{Description: description, Time: time.Now(), Failed: failed}
+
+	for _, path := range j.trackedFiles(extra) {
+		was, existed := before[path]
+		now, exists := after[path]
+		if existed == exists && bytes.Equal(was, now) {
+			continue
+		}
+
+		file := File{Path: j.relative(path)}
+		var err error
+		if existed {
+			if file.Before, err = j.store(was); err != nil {
+				return err
+			}
+		}
+		if exists {
+			if file.After, err = j.store(now); err != nil {
+				return err
+			}
+		}
+		entry.Files = append(entry.Files, file)
+	}
+
+	if len(entry.Files) == 0 {
+		return nil
+	}
+
+	state, err := j.load()
+	if err != nil {
+		return err
+	}
+
+	state.Entries = state.Entries[:state.Position]
+	if len(state.Entries) > 0 {
+		entry.ID = state.Entries[len(state.Entries)-1].ID + 1
+	} else {
+		entry.ID = 1
+	}
+	state.Entries = append(state.Entries, entry)
+
+	// Forget the oldest operations
+	if len(state.Entries) > maxEntries {
+		state.Entries = state.Entries[len(state.Entries)-maxEntries:]
+	}
+	state.Position = len(state.Entries)
+
+	if err := j.save(state); err != nil {
+		return err
+	}
+	return j.collect(state)
+}
+
+// restore writes back the files of an entry, as they were before it or after
+func (j *Journal) restore(entry Entry, before bool) error {
+	for _, file := range entry.Files {
+		path := j.FilePath(file)
+		object := file.After
+		if before {
+			object = file.Before
+		}
+
+		if object == "" {
+			// The file didn't exist
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(j.objectPath(object))
+		if err != nil {
+			return fmt.Errorf("snapshot of %s is missing: %v", file.Path, err)
+		}
+		if err := packagejson.WriteFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// store saves a file content under its hash, so identical snapshots are
+// kept once, and returns the hash
+func (j *Journal) store(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	object := hex.EncodeToString(sum[:])
+
+	path := j.objectPath(object)
+	if _, err := os.Stat(path); err == nil {
+		return object, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+	return object, packagejson.WriteFile(path, data)
+}
+
+// collect deletes the snapshots no entry refers to anymore
+func (j *Journal) collect(state *journal) error {
+	used := make(map[string]bool)
+	for _, entry := range state.Entries {
+		for _, file := range entry.Files {
+			used[file.Before] = true
+			used[file.After] = true
+		}
+	}
+
+	objects, err := os.ReadDir(filepath.Join(j.Dir, "objects"))
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if !used[object.Name()] {
+			os.Remove(j.objectPath(object.Name()))
+		}
+	}
+	return nil
+}
+
+// objectPath returns where a snapshot is stored
+func (j *Journal) objectPath(object string) string {
+	return filepath.Join(j.Dir, "objects", object)
+}
+
+// relative returns a path relative to the project directory, with slashes
+func (j *Journal) relative(path string) string {
+	if rel, err := filepath.Rel(j.ProjectDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// load reads the journal, empty when there is none yet
+func (j *Journal) load() (*journal, error) {
+	state := &journal{}
+
+	data, err := os.ReadFile(filepath.Join(j.Dir, "journal.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to read the history: %v", err)
+	}
+	if state.Position > len(state.Entries) {
+		state.Position = len(state.Entries)
+	}
+	return state, nil
+}
+
+// save writes the journal
+func (j *Journal) save(state *journal) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(j.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	return packagejson.WriteFile(filepath.Join(j.Dir, "journal.json"), data)
+}
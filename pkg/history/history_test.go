@@ -0,0 +1,88 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenSharesJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	first := Open(dir)
+	if second := Open(dir + string(filepath.Separator)); second != first {
+		t.Fatal("Open returned two journals for the same directory")
+	}
+	if other := Open(t.TempDir()); other == first {
+		t.Fatal("Open returned the same journal for two directories")
+	}
+}
+
+func TestConcurrentRecords(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "package.json")
+	lock := filepath.Join(dir, "package-lock.json")
+	if err := os.WriteFile(manifest, []byte(`{"name": "app"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A background install and an edit from elsewhere, each having opened
+	// the journal on its own, overlap
+	install, edit := Open(dir), Open(dir)
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- install.Record("Install lodash", func() error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return os.WriteFile(lock, []byte(`{"lockfileVersion": 3}`), 0644)
+		})
+	}()
+
+	<-started
+	err := edit.Record("Set description", func() error {
+		return os.WriteFile(manifest, []byte(`{"name": "app", "description": "An app"}`), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	entries, position, err := edit.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || position != 2 {
+		t.Fatalf("entries = %+v at %d, want one entry per operation", entries, position)
+	}
+	for i, want := range []struct{ description, path string }{
+		{"Install lodash", "package-lock.json"},
+		{"Set description", "package.json"},
+	} {
+		entry := entries[i]
+		if entry.Description != want.description || len(entry.Files) != 1 || entry.Files[0].Path != want.path {
+			t.Errorf("entry %d = %+v, want %q changing %s", i, entry, want.description, want.path)
+		}
+	}
+
+	// Undoing the edit leaves the install alone
+	if _, err := edit.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lock); err != nil {
+		t.Errorf("lockfile after undoing the edit: %v", err)
+	}
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name": "app"}` {
+		t.Errorf("package.json after undo = %s", data)
+	}
+	if install.Revision() != 3 {
+		t.Errorf("Revision() = %d after two records and an undo, want 3", install.Revision())
+	}
+}
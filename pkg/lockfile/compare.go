@@ -0,0 +1,141 @@
+package lockfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+)
+
+// KindOf returns the kind of a lockfile from its file name
+func KindOf(name string) (Kind, bool) {
+	for _, file := range files {
+		if file.name == name {
+			return file.kind, true
+		}
+	}
+	return "", false
+}
+
+// Change is a package whose resolved versions differ between two lockfiles
+type Change struct {
+	Name string
+	From []string // Versions before, oldest first
+	To   []string // Versions after, oldest first
+}
+
+// String describes the change, e.g. "lodash 4.17.20→4.17.21"
+func (c Change) String() string {
+	switch {
+	case len(c.From) == 0:
+		return c.Name + " " + strings.Join(c.To, ", ")
+	case len(c.To) == 0:
+		return c.Name + " " + strings.Join(c.From, ", ")
+	}
+	return fmt.Sprintf("%s %s→%s", c.Name, strings.Join(c.From, ", "), strings.Join(c.To, ", "))
+}
+
+// Comparison is how the resolved packages changed between two lockfiles
+type Comparison struct {
+	Added      []Change
+	Removed    []Change
+	Upgraded   []Change // The newest version went up
+	Downgraded []Change // The newest version went down
+	Changed    []Change // Copies added or removed, same newest version
+}
+
+// Compare lists the packages whose resolved versions differ between two
+// graphs. Workspace packages are left out.
+func Compare(old, new *Graph) Comparison {
+	before, after := versionsByName(old), versionsByName(new)
+
+	names := make([]string, 0, len(before)+len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var c Comparison
+	for _, name := range names {
+		change := Change{Name: name, From: before[name], To: after[name]}
+		switch {
+		case len(change.From) == 0:
+			c.Added = append(c.Added, change)
+		case len(change.To) == 0:
+			c.Removed = append(c.Removed, change)
+		case strings.Join(change.From, " ") == strings.Join(change.To, " "):
+			// Unchanged
+		default:
+			switch order := semver.Compare(change.To[len(change.To)-1], change.From[len(change.From)-1]); {
+			case order > 0:
+				c.Upgraded = append(c.Upgraded, change)
+			case order < 0:
+				c.Downgraded = append(c.Downgraded, change)
+			default:
+				c.Changed = append(c.Changed, change)
+			}
+		}
+	}
+	return c
+}
+
+// Empty reports whether no package changed
+func (c Comparison) Empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Upgraded)+len(c.Downgraded)+len(c.Changed) == 0
+}
+
+// Summary describes the comparison in one line, e.g. "added 12 packages,
+// upgraded lodash 4.17.20→4.17.21, removed 3 packages"
+func (c Comparison) Summary() string {
+	if c.Empty() {
+		return "no package changes"
+	}
+
+	var parts []string
+	add := func(verb string, changes []Change) {
+		switch {
+		case len(changes) == 0:
+		case len(changes) <= 3:
+			descriptions := make([]string, len(changes))
+			for i, change := range changes {
+				descriptions[i] = change.String()
+			}
+			parts = append(parts, verb+" "+strings.Join(descriptions, ", "))
+		default:
+			parts = append(parts, fmt.Sprintf("%s %d packages", verb, len(changes)))
+		}
+	}
+
+	add("added", c.Added)
+	add("upgraded", c.Upgraded)
+	add("downgraded", c.Downgraded)
+	add("changed", c.Changed)
+	add("removed", c.Removed)
+	return strings.Join(parts, ", ")
+}
+
+// versionsByName returns the resolved versions of every package, oldest first
+func versionsByName(g *Graph) map[string][]string {
+	versions := make(map[string][]string)
+	if g == nil {
+		return versions
+	}
+
+	for _, n := range g.Nodes {
+		if !n.Workspace {
+			versions[n.Name] = append(versions[n.Name], n.Version)
+		}
+	}
+	for name := range versions {
+		sort.Slice(versions[name], func(i, j int) bool {
+			return semver.Compare(versions[name][i], versions[name][j]) < 0
+		})
+	}
+	return versions
+}
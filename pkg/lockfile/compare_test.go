@@ -0,0 +1,73 @@
+package lockfile
+
+import "testing"
+
+// graphOf creates a graph resolving the given name@version IDs
+func graphOf(ids ...string) *Graph {
+	graph := newGraph(KindNpm, "3")
+	for _, id := range ids {
+		name, version := splitSpec(id)
+		graph.node(name, version)
+	}
+	return graph
+}
+
+func TestCompareSummary(t *testing.T) {
+	old := graphOf("lodash@4.17.20", "react@17.0.2", "ms@2.0.0", "ms@2.1.2", "left-pad@1.3.0", "@types/node@20.12.7")
+	tests := []struct {
+		name string
+		old  *Graph
+		new  *Graph
+		want string
+	}{
+		{
+			name: "unchanged",
+			old:  old,
+			new:  graphOf("lodash@4.17.20", "react@17.0.2", "ms@2.0.0", "ms@2.1.2", "left-pad@1.3.0", "@types/node@20.12.7"),
+			want: "no package changes",
+		},
+		{
+			name: "every kind of change",
+			old:  old,
+			new:  graphOf("lodash@4.17.21", "react@16.14.0", "ms@2.1.2", "chalk@5.3.0", "@types/node@20.12.7"),
+			want: "added chalk 5.3.0, upgraded lodash 4.17.20→4.17.21, downgraded react 17.0.2→16.14.0, " +
+				"changed ms 2.0.0, 2.1.2→2.1.2, removed left-pad 1.3.0",
+		},
+		{
+			name: "many changes are counted",
+			old:  old,
+			new:  graphOf("a@1.0.0", "b@1.0.0", "c@1.0.0", "d@1.0.0"),
+			want: "added 4 packages, removed 5 packages",
+		},
+		{
+			name: "versions sort by semver",
+			old:  graphOf("ms@2.9.0"),
+			new:  graphOf("ms@2.10.0"),
+			want: "upgraded ms 2.9.0→2.10.0",
+		},
+		{
+			name: "no previous lockfile",
+			old:  nil,
+			new:  graphOf("lodash@4.17.21"),
+			want: "added lodash 4.17.21",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Compare(tc.old, tc.new).Summary(); got != tc.want {
+				t.Errorf("Summary() = %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompareSkipsWorkspaces(t *testing.T) {
+	old := graphOf("lodash@4.17.21")
+	new := graphOf("lodash@4.17.21")
+	new.node("my-lib", "0.2.0").Workspace = true
+
+	if c := Compare(old, new); !c.Empty() {
+		t.Errorf("Compare reported workspace packages: %s", c.Summary())
+	}
+}
@@ -0,0 +1,57 @@
+package lockfile
+
+import "sort"
+
+// Duplicate is a package resolved at several versions
+type Duplicate struct {
+	Name     string
+	Versions []string // Oldest first
+	// Dependents are the packages requiring each version, by version
+	Dependents map[string][]string
+}
+
+// Duplicates lists the packages the graph resolves at more than one
+// version, by name. Workspace packages are left out.
+func Duplicates(g *Graph) []Duplicate {
+	var duplicates []Duplicate
+	for name, versions := range versionsByName(g) {
+		if len(versions) > 1 {
+			duplicates = append(duplicates, Duplicate{Name: name, Versions: versions, Dependents: make(map[string][]string)})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].Name < duplicates[j].Name
+	})
+
+	// Find who requires each version
+	index := make(map[string]*Duplicate)
+	for i := range duplicates {
+		index[duplicates[i].Name] = &duplicates[i]
+	}
+	for _, n := range g.allNodes() {
+		for _, edge := range n.Dependencies {
+			duplicate, ok := index[edge.Name]
+			if !ok || edge.To == nil {
+				continue
+			}
+			dependent := n.ID()
+			if n.Workspace {
+				switch {
+				case n.Name != "":
+					dependent = n.Name
+				case n.Path != "":
+					dependent = n.Path
+				default:
+					dependent = "(root)"
+				}
+			}
+			duplicate.Dependents[edge.To.Version] = append(duplicate.Dependents[edge.To.Version], dependent)
+		}
+	}
+	for _, duplicate := range duplicates {
+		for _, dependents := range duplicate.Dependents {
+			sort.Strings(dependents)
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,67 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Duplicate
+	}{
+		{
+			// The workspace package my-lib is left out
+			fixture: "npm-v3.json",
+			want: []Duplicate{{
+				Name:     "ms",
+				Versions: []string{"2.0.0", "2.1.2"},
+				Dependents: map[string][]string{
+					"2.0.0": {"app"},
+					"2.1.2": {"debug@4.3.4"},
+				},
+			}},
+		},
+		{
+			// util@1.0.0 is installed twice and reaches both helpers
+			fixture: "npm-nested.json",
+			want: []Duplicate{
+				{
+					Name:     "helper",
+					Versions: []string{"1.0.0", "2.0.0"},
+					Dependents: map[string][]string{
+						"1.0.0": {"util@1.0.0"},
+						"2.0.0": {"util@1.0.0"},
+					},
+				},
+				{
+					Name:     "util",
+					Versions: []string{"1.0.0", "2.0.0"},
+					Dependents: map[string][]string{
+						"1.0.0": {"a@1.0.0", "b@1.0.0"},
+						"2.0.0": {"app"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			graph, err := Parse(KindNpm, data, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := Duplicates(graph); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Duplicates = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
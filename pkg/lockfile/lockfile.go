@@ -0,0 +1,352 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+)
+
+// Kind identifies a lockfile format
+type Kind string
+
+const (
+	KindNpm       Kind = "npm"        // package-lock.json / npm-shrinkwrap.json v2 and v3
+	KindYarn      Kind = "yarn"       // yarn.lock v1
+	KindYarnBerry Kind = "yarn-berry" // yarn.lock written by yarn 2+
+	KindPnpm      Kind = "pnpm"       // pnpm-lock.yaml
+)
+
+// ErrNotFound is returned when a project has no supported lockfile
+var ErrNotFound = errors.New("no lockfile found")
+
+// DependencyType is the package.json section an edge comes from
+type DependencyType string
+
+const (
+	Prod     DependencyType = "dependencies"
+	Dev      DependencyType = "devDependencies"
+	Optional DependencyType = "optionalDependencies"
+	Peer     DependencyType = "peerDependencies"
+)
+
+// Edge is a dependency of a node on another package
+type Edge struct {
+	Name  string
+	Range string
+	Type  DependencyType
+	// OptionalPeer is set for peers marked optional in peerDependenciesMeta
+	OptionalPeer bool
+	// To is the resolved package, nil if the lockfile has no match
+	To *Node
+}
+
+// Required reports whether a missing target is an error
+func (e *Edge) Required() bool {
+	return e.Type != Optional && !e.OptionalPeer
+}
+
+// Node is a resolved package, or a workspace importer
+type Node struct {
+	Name      string
+	Version   string
+	Resolved  string
+	Integrity string
+	// Dev and Optional are set when the package is only needed for those
+	// dependency types, at every install path
+	Dev      bool
+	Optional bool
+	// Workspace is set for the root project and workspace packages
+	Workspace bool
+	// Path is the shallowest install path for npm, or the workspace path for importers
+	Path         string
+	Dependencies []*Edge
+}
+
+// ID returns the node key, "name@version"
+func (n *Node) ID() string {
+	return n.Name + "@" + n.Version
+}
+
+// Dependency returns the first edge to the named dependency, or nil
+func (n *Node) Dependency(name string) *Edge {
+	for _, edge := range n.Dependencies {
+		if edge.Name == name {
+			return edge
+		}
+	}
+	return nil
+}
+
+// addDependency adds an edge unless the node already depends on the same
+// package. A node shared by several npm install paths keeps an edge for
+// every package a name resolves to from those paths.
+func (n *Node) addDependency(edge *Edge) {
+	var unresolved *Edge
+	for _, existing := range n.Dependencies {
+		if existing.Name != edge.Name {
+			continue
+		}
+		if existing.To == edge.To || edge.To == nil {
+			return
+		}
+		if existing.To == nil && unresolved == nil {
+			unresolved = existing
+		}
+	}
+
+	if unresolved != nil {
+		unresolved.To = edge.To
+		return
+	}
+	n.Dependencies = append(n.Dependencies, edge)
+}
+
+// Graph is the resolved dependency graph of a project
+type Graph struct {
+	// Path is the lockfile location, empty when parsed from memory
+	Path string
+	Kind Kind
+	// LockfileVersion is the format version declared by the lockfile
+	LockfileVersion string
+	// Root is the project owning the lockfile
+	Root *Node
+	// Importers holds the root ("") and every workspace package by relative path
+	Importers map[string]*Node
+	// Nodes holds every resolved package by ID
+	Nodes map[string]*Node
+}
+
+// newGraph creates an empty graph with a root importer
+func newGraph(kind Kind, version string) *Graph {
+	root := &Node{Workspace: true}
+
+	return &Graph{
+		Kind:            kind,
+		LockfileVersion: version,
+		Root:            root,
+		Importers:       map[string]*Node{"": root},
+		Nodes:           make(map[string]*Node),
+	}
+}
+
+// node returns the node for name@version, creating it if needed
+func (g *Graph) node(name, version string) *Node {
+	id := name + "@" + version
+	if n, ok := g.Nodes[id]; ok {
+		return n
+	}
+
+	n := &Node{Name: name, Version: version}
+	g.Nodes[id] = n
+	return n
+}
+
+// String describes the graph, for logs
+func (g *Graph) String() string {
+	return fmt.Sprintf("%s lockfile v%s: %d packages, %d importers",
+		g.Kind, g.LockfileVersion, len(g.Nodes), len(g.Importers))
+}
+
+// Node returns the package with the given name and version, or nil
+func (g *Graph) Node(name, version string) *Node {
+	return g.Nodes[name+"@"+version]
+}
+
+// Lookup returns every resolved version of a package, sorted by version
+func (g *Graph) Lookup(name string) []*Node {
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if n.Name == name {
+			nodes = append(nodes, n)
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return semver.Compare(nodes[i].Version, nodes[j].Version) < 0
+	})
+	return nodes
+}
+
+// Importer returns the root or workspace package in dir, or nil.
+// It needs the graph to be loaded from a file.
+func (g *Graph) Importer(dir string) *Node {
+	rel, err := filepath.Rel(filepath.Dir(g.Path), dir)
+	if err != nil {
+		return nil
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	return g.Importers[rel]
+}
+
+// Versions returns the resolved version of each direct dependency of the node
+func (n *Node) Versions() map[string]string {
+	versions := make(map[string]string)
+	for _, edge := range n.Dependencies {
+		if edge.To != nil {
+			versions[edge.Name] = edge.To.Version
+		}
+	}
+	return versions
+}
+
+// Reachable returns every node an importer depends on, directly or not
+func (g *Graph) Reachable() map[*Node]bool {
+	reachable := make(map[*Node]bool)
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if reachable[n] {
+			return
+		}
+		reachable[n] = true
+
+		for _, edge := range n.Dependencies {
+			if edge.To != nil {
+				visit(edge.To)
+			}
+		}
+	}
+
+	for _, importer := range g.Importers {
+		visit(importer)
+	}
+	return reachable
+}
+
+// Extraneous returns the packages in the lockfile that nothing depends on
+func (g *Graph) Extraneous() []*Node {
+	reachable := g.Reachable()
+
+	var extraneous []*Node
+	for _, n := range g.Nodes {
+		if !reachable[n] {
+			extraneous = append(extraneous, n)
+		}
+	}
+
+	sort.Slice(extraneous, func(i, j int) bool {
+		return extraneous[i].ID() < extraneous[j].ID()
+	})
+	return extraneous
+}
+
+// peerMeta is the peerDependenciesMeta field shared by every format
+type peerMeta map[string]struct {
+	Optional bool `json:"optional" yaml:"optional"`
+}
+
+// Manifest is the part of a package.json needed to resolve the root of lockfiles
+// that do not record it (yarn v1)
+type Manifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// sections returns the dependency maps of the manifest by type
+func (m *Manifest) sections() map[DependencyType]map[string]string {
+	return map[DependencyType]map[string]string{
+		Prod:     m.Dependencies,
+		Dev:      m.DevDependencies,
+		Optional: m.OptionalDependencies,
+		Peer:     m.PeerDependencies,
+	}
+}
+
+// files lists the lockfile names of each kind, in detection order
+var files = []struct {
+	name string
+	kind Kind
+}{
+	{"pnpm-lock.yaml", KindPnpm},
+	{"yarn.lock", KindYarn},
+	{"package-lock.json", KindNpm},
+	{"npm-shrinkwrap.json", KindNpm},
+}
+
+// Find looks for a lockfile in dir and its parents, so workspace packages
+// resolve to the lockfile at the workspace root
+func Find(dir string) (string, Kind, error) {
+	for {
+		for _, file := range files {
+			path := filepath.Join(dir, file.name)
+			if _, err := os.Stat(path); err == nil {
+				return path, file.kind, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// Load finds and parses the lockfile of the project in dir
+func Load(dir string) (*Graph, error) {
+	path, kind, err := Find(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// yarn v1 lockfiles do not record the root, so read it from package.json
+	manifest := &Manifest{}
+	manifestData, err := os.ReadFile(filepath.Join(filepath.Dir(path), "package.json"))
+	if err == nil {
+		if err := json.Unmarshal(manifestData, manifest); err != nil {
+			return nil, fmt.Errorf("package.json parse error: %v", err)
+		}
+	}
+
+	graph, err := Parse(kind, data, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s parse error: %v", filepath.Base(path), err)
+	}
+	graph.Path = path
+
+	// Name the root after package.json when the lockfile does not
+	if graph.Root.Name == "" {
+		graph.Root.Name = manifest.Name
+		graph.Root.Version = manifest.Version
+	}
+
+	return graph, nil
+}
+
+// Parse parses lockfile data of the given kind. A yarn.lock is parsed as
+// berry when it has a __metadata block. The manifest provides the root
+// dependencies for yarn v1 and may be nil otherwise.
+func Parse(kind Kind, data []byte, manifest *Manifest) (*Graph, error) {
+	switch kind {
+	case KindNpm:
+		return parseNpm(data)
+	case KindYarn, KindYarnBerry:
+		if strings.Contains(string(data), "__metadata:") {
+			return parseYarnBerry(data)
+		}
+		return parseYarn(data, manifest)
+	case KindPnpm:
+		return parsePnpm(data)
+	}
+
+	return nil, fmt.Errorf("unsupported lockfile kind: %s", kind)
+}
@@ -0,0 +1,290 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// edge is an expected dependency. Nodes are named by ID, importers by path
+// and the root by "."; an empty to is an unresolved dependency.
+type edge struct {
+	from, name, rng string
+	kind            DependencyType
+	to              string
+	optionalPeer    bool
+}
+
+// find returns the node, importer or root named as in edge
+func find(graph *Graph, id string) *Node {
+	if id == "." {
+		return graph.Root
+	}
+	if n, ok := graph.Importers[id]; ok {
+		return n
+	}
+	return graph.Nodes[id]
+}
+
+func TestParse(t *testing.T) {
+	// The same React tree is locked by every pnpm version
+	pnpmIntegrity := map[string]string{
+		"@types/react@18.2.79": "sha512-RwGAGXPl9kSXwdNTafkOEuFrTBD5SA2B3iEB96xi8+xu5ddUa/cpvyVCSNn+asgLCTHkb5ZxN8gbuibYJi4s1w==",
+		"react-dom@18.2.0":     "sha512-6IMTriUmvsjHUjNtEDudZfuDQUoWXVxKHhlEGSk81n4YFS+r/Kl99wXiwlVXtPBtJenozv2P+hxDsw9eA7Xo6g==",
+		"react@18.2.0":         "sha512-/3IjMdb2L9QbBdWiW5e3P2/npwMBaU9mHCSCUzNln0ZCYbcfTsGbTJrU/kGemdH2IWmB2ioZ+zkxtmq6g09fGQ==",
+	}
+	pnpmEdges := []edge{
+		{from: ".", name: "react", rng: "^18.2.0", kind: Prod, to: "react@18.2.0"},
+		{from: ".", name: "react-dom", rng: "^18.2.0", kind: Prod, to: "react-dom@18.2.0"},
+		{from: "@types/react@18.2.79", name: "csstype", rng: "3.1.3", kind: Prod, to: "csstype@3.1.3"},
+		{from: "loose-envify@1.4.0", name: "js-tokens", rng: "4.0.0", kind: Prod, to: "js-tokens@4.0.0"},
+		{from: "react-dom@18.2.0", name: "scheduler", rng: "0.23.2", kind: Prod, to: "scheduler@0.23.2"},
+		// Peers are resolved through the dependencies of the package
+		{from: "react-dom@18.2.0", name: "react", rng: "^18.2.0", kind: Peer, to: "react@18.2.0"},
+	}
+
+	tests := []struct {
+		fixture   string
+		kind      Kind
+		manifest  *Manifest
+		root      string
+		version   string
+		packages  int
+		integrity map[string]string
+		edges     []edge
+	}{
+		{
+			fixture:  "npm-v3.json",
+			kind:     KindNpm,
+			root:     "app@1.0.0",
+			version:  "3",
+			packages: 4,
+			integrity: map[string]string{
+				"debug@4.3.4": "sha512-PRWFHuSU3eDtQJPvnNY7Jcket1j0t5OuOsFzPPzsekD52Zl8qUfFIPEiswXqIvHWGVHOgX+7G/vCNNhehwxfkQ==",
+				"ms@2.1.2":    "sha512-sGkPx+VjMtmA6MX27oA4FBFELFCZZ4S4XqeGOXCv68tT+jb3vk/RyaKWP0PTKyWtmLSM0b+adUTEvbs1PEaH2w==",
+				"ms@2.0.0":    "sha512-Tpp60P6IUJDTuOq/5Z8cdskzJujfwqfOTkrwIwj7IRISpnkJnT6SyJ4PCPnGMoFjC9ddhal5KVIYtAt97ix05A==",
+			},
+			edges: []edge{
+				{from: ".", name: "debug", rng: "^4.3.4", kind: Prod, to: "debug@4.3.4"},
+				{from: ".", name: "ms", rng: "^2.0.0", kind: Dev, to: "ms@2.0.0"},
+				// The workspace link resolves to the workspace package
+				{from: ".", name: "my-lib", rng: "*", kind: Prod, to: "packages/my-lib"},
+				// The nearest node_modules wins
+				{from: "debug@4.3.4", name: "ms", rng: "2.1.2", kind: Prod, to: "ms@2.1.2"},
+				{from: "packages/my-lib", name: "lodash", rng: "^4.17.21", kind: Prod, to: "lodash@4.17.21"},
+				{from: "packages/my-lib", name: "react", rng: "^18.0.0", kind: Peer, optionalPeer: true},
+			},
+		},
+		{
+			fixture:  "npm-v2.json",
+			kind:     KindNpm,
+			root:     "app@1.0.0",
+			version:  "2",
+			packages: 3,
+			integrity: map[string]string{
+				"@babel/highlight@7.24.5": "sha512-8lLmua6AVh/8SLJRRVD6V8p73Hir9w5mJrhE+IPpILG31KKlI9iz5zmBYKcWPS59qSfgP9RaSBQSHHE81WKuEw==",
+				"fsevents@2.3.3":          "sha512-5xoDfX+fL7faATnagmWPpbFtwh/R77WmMMqqHGS65C3vvB0YHrgF+B1YmZ3441tMj5n63k0212XNoJwzlhffQw==",
+			},
+			edges: []edge{
+				{from: ".", name: "@babel/highlight", rng: "^7.24.0", kind: Prod, to: "@babel/highlight@7.24.5"},
+				{from: ".", name: "fsevents", rng: "^2.3.3", kind: Optional, to: "fsevents@2.3.3"},
+				{from: "@babel/highlight@7.24.5", name: "js-tokens", rng: "^4.0.0", kind: Prod, to: "js-tokens@4.0.0"},
+			},
+		},
+		{
+			fixture: "yarn-v1.lock",
+			kind:    KindYarn,
+			manifest: &Manifest{
+				Name:            "app",
+				Version:         "1.0.0",
+				Dependencies:    map[string]string{"@babel/code-frame": "^7.0.0"},
+				DevDependencies: map[string]string{"picocolors": "^1.0.1"},
+			},
+			root:     "app@1.0.0",
+			version:  "1",
+			packages: 4,
+			integrity: map[string]string{
+				"@babel/code-frame@7.24.2": "sha512-y5+tLQyV8pg3fsiln67BVLD1P13Eg4lh5RW9mF0zUuvLrv9uIQ4MCL+CRT+FTsBlBjcIan6PGsLcBN0m3ClUyQ==",
+				"picocolors@1.0.1":         "sha512-anP1Z8qwhkbmu7MFP5iTt+wQKXgwzf7zTyGlcdzabySa9vd0Xt392U0rVmz9poOaBj0uHJKyyo9/upk0HrEQew==",
+			},
+			edges: []edge{
+				{from: ".", name: "@babel/code-frame", rng: "^7.0.0", kind: Prod, to: "@babel/code-frame@7.24.2"},
+				{from: ".", name: "picocolors", rng: "^1.0.1", kind: Dev, to: "picocolors@1.0.1"},
+				{from: "@babel/code-frame@7.24.2", name: "@babel/highlight", rng: "^7.24.2", kind: Prod, to: "@babel/highlight@7.24.5"},
+				// Both specs of a block resolve to it
+				{from: "@babel/code-frame@7.24.2", name: "picocolors", rng: "^1.0.0", kind: Prod, to: "picocolors@1.0.1"},
+				{from: "@babel/highlight@7.24.5", name: "js-tokens", rng: "^4.0.0", kind: Prod, to: "js-tokens@4.0.0"},
+			},
+		},
+		{
+			fixture:  "yarn-berry.lock",
+			kind:     KindYarnBerry,
+			root:     "app@0.0.0-use.local",
+			version:  "8",
+			packages: 1,
+			integrity: map[string]string{
+				"lodash@4.17.21": "10c0/d8cbea072bb08655bb4c989da418994b073a608dffa608b09ac04b43a791b12aeae7cd7ad919aa4c925f33b48490b5cfe6c1f71d827956071dae2e7bb3a6b74c",
+			},
+			edges: []edge{
+				{from: ".", name: "lodash", rng: "^4.17.21", kind: Prod, to: "lodash@4.17.21"},
+				{from: ".", name: "my-lib", rng: "workspace:^", kind: Prod, to: "packages/my-lib"},
+				{from: "packages/my-lib", name: "lodash", rng: "^4.17.20", kind: Prod, to: "lodash@4.17.21"},
+				{from: "packages/my-lib", name: "react", rng: "^18.0.0", kind: Peer, optionalPeer: true},
+			},
+		},
+		{
+			fixture:   "pnpm-v5.yaml",
+			kind:      KindPnpm,
+			root:      "@",
+			version:   "5.4",
+			packages:  7,
+			integrity: pnpmIntegrity,
+			edges: append([]edge{
+				{from: ".", name: "@types/react", rng: "^18.2.0", kind: Dev, to: "@types/react@18.2.79"},
+			}, pnpmEdges...),
+		},
+		{
+			fixture:   "pnpm-v6.yaml",
+			kind:      KindPnpm,
+			root:      "@",
+			version:   "6.0",
+			packages:  7,
+			integrity: pnpmIntegrity,
+			edges: append([]edge{
+				{from: ".", name: "@types/react", rng: "^18.2.0", kind: Dev, to: "@types/react@18.2.79"},
+			}, pnpmEdges...),
+		},
+		{
+			fixture:   "pnpm-v9.yaml",
+			kind:      KindPnpm,
+			root:      "@",
+			version:   "9.0",
+			packages:  7,
+			integrity: pnpmIntegrity,
+			edges: append([]edge{
+				{from: ".", name: "ui", rng: "workspace:*", kind: Prod, to: "packages/ui"},
+				{from: "packages/ui", name: "@types/react", rng: "^18.2.0", kind: Dev, to: "@types/react@18.2.79"},
+			}, pnpmEdges...),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			graph, err := Parse(tc.kind, data, tc.manifest)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if graph.Kind != tc.kind || graph.LockfileVersion != tc.version {
+				t.Errorf("graph is %s v%s, want %s v%s", graph.Kind, graph.LockfileVersion, tc.kind, tc.version)
+			}
+			if got := graph.Root.ID(); got != tc.root {
+				t.Errorf("root = %s, want %s", got, tc.root)
+			}
+			if len(graph.Nodes) != tc.packages {
+				var ids []string
+				for id := range graph.Nodes {
+					ids = append(ids, id)
+				}
+				t.Errorf("got %d packages, want %d: %s", len(graph.Nodes), tc.packages, strings.Join(ids, ", "))
+			}
+
+			for id, integrity := range tc.integrity {
+				n := graph.Nodes[id]
+				if n == nil {
+					t.Errorf("%s is missing", id)
+					continue
+				}
+				if n.Integrity != integrity {
+					t.Errorf("%s integrity = %q, want %q", id, n.Integrity, integrity)
+				}
+			}
+
+			for _, want := range tc.edges {
+				from := find(graph, want.from)
+				if from == nil {
+					t.Errorf("%s is missing", want.from)
+					continue
+				}
+				got := from.Dependency(want.name)
+				if got == nil {
+					t.Errorf("%s has no dependency on %s", want.from, want.name)
+					continue
+				}
+
+				if got.Range != want.rng || got.Type != want.kind || got.OptionalPeer != want.optionalPeer {
+					t.Errorf("%s → %s is %s %q (optional peer %v), want %s %q (optional peer %v)",
+						want.from, want.name, got.Type, got.Range, got.OptionalPeer, want.kind, want.rng, want.optionalPeer)
+				}
+
+				var to *Node
+				if want.to != "" {
+					to = find(graph, want.to)
+				}
+				if got.To != to {
+					t.Errorf("%s → %s resolves to %v, want %s", want.from, want.name, got.To, want.to)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNpmV1(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "npm-v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Parse(KindNpm, data, nil)
+	if err == nil || !strings.Contains(err.Error(), "lockfileVersion 1 is not supported") {
+		t.Fatalf("Parse error = %v, want lockfileVersion 1 to be refused", err)
+	}
+}
+
+func TestParseNpmNestedDuplicates(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "npm-nested.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Install paths come out of a map: parse repeatedly to catch any order dependence
+	for i := 0; i < 20; i++ {
+		graph, err := Parse(KindNpm, data, nil)
+		if err != nil {
+			t.Fatalf("Parse returned error: %v", err)
+		}
+
+		// util@1.0.0 is installed under a (prod) and under b (dev)
+		util := graph.Nodes["util@1.0.0"]
+		if util == nil {
+			t.Fatal("util@1.0.0 is missing")
+		}
+		if util.Dev {
+			t.Error("util@1.0.0 is marked dev, but a needs it in production")
+		}
+		if util.Path != "node_modules/a/node_modules/util" {
+			t.Errorf("util@1.0.0 path = %s, want the first of its install paths", util.Path)
+		}
+		if helper := graph.Nodes["helper@2.0.0"]; helper == nil || !helper.Dev {
+			t.Errorf("helper@2.0.0 = %+v, want it marked dev", helper)
+		}
+
+		// helper resolves differently from each install path of util
+		var targets []string
+		for _, edge := range util.Dependencies {
+			if edge.Name != "helper" || edge.To == nil {
+				t.Errorf("util@1.0.0 → %s resolves to %v", edge.Name, edge.To)
+				continue
+			}
+			targets = append(targets, edge.To.ID())
+		}
+		if strings.Join(targets, ", ") != "helper@1.0.0, helper@2.0.0" {
+			t.Fatalf("util@1.0.0 → helper resolves to %v, want helper@1.0.0 and helper@2.0.0", targets)
+		}
+	}
+}
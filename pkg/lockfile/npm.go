@@ -0,0 +1,207 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// npmLockfile is the layout of package-lock.json v2 and v3
+type npmLockfile struct {
+	Name            string                `json:"name"`
+	Version         string                `json:"version"`
+	LockfileVersion int                   `json:"lockfileVersion"`
+	Packages        map[string]npmPackage `json:"packages"`
+}
+
+// npmPackage is one entry of the packages map, keyed by install path
+type npmPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Integrity            string            `json:"integrity"`
+	Link                 bool              `json:"link"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	DevOptional          bool              `json:"devOptional"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	PeerDependenciesMeta peerMeta          `json:"peerDependenciesMeta"`
+}
+
+// parseNpm parses a package-lock.json. Packages are keyed by install path,
+// so dependencies resolve the way node does: the nearest node_modules wins.
+func parseNpm(data []byte) (*Graph, error) {
+	var lock npmLockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	if lock.Packages == nil {
+		return nil, fmt.Errorf("lockfileVersion %d is not supported, run npm install to upgrade it", lock.LockfileVersion)
+	}
+
+	graph := newGraph(KindNpm, fmt.Sprint(lock.LockfileVersion))
+	byPath := make(map[string]*Node)
+
+	// Install paths of the same version share a node, so visit them in a
+	// fixed order for the result not to depend on map iteration
+	paths := npmPaths(lock.Packages)
+	seen := make(map[*Node]bool)
+
+	// Create a node for every install path
+	for _, p := range paths {
+		pkg := lock.Packages[p]
+		if pkg.Link {
+			continue // Resolved through the link target below
+		}
+
+		var n *Node
+		if p == "" {
+			n = graph.Root
+			n.Name = pkg.Name
+			if n.Name == "" {
+				n.Name = lock.Name
+			}
+			n.Version = pkg.Version
+		} else if !strings.Contains(p, "node_modules/") {
+			// Workspace packages live outside node_modules
+			n = &Node{
+				Name:      pkg.Name,
+				Version:   pkg.Version,
+				Workspace: true,
+			}
+			if n.Name == "" {
+				n.Name = path.Base(p)
+			}
+			graph.Importers[p] = n
+		} else {
+			name := pkg.Name
+			if name == "" {
+				name = npmPackageName(p)
+			}
+			n = graph.node(name, pkg.Version)
+			dev := pkg.Dev || pkg.DevOptional
+			optional := pkg.Optional || pkg.DevOptional
+			if seen[n] {
+				// Only dev or optional if it is so at every install path
+				n.Dev = n.Dev && dev
+				n.Optional = n.Optional && optional
+			} else {
+				n.Resolved = pkg.Resolved
+				n.Integrity = pkg.Integrity
+				n.Dev = dev
+				n.Optional = optional
+				seen[n] = true
+			}
+		}
+
+		if n.Path == "" {
+			n.Path = p
+		}
+		byPath[p] = n
+	}
+
+	// Point links, e.g. node_modules/my-lib -> packages/my-lib, at their target
+	for p, pkg := range lock.Packages {
+		if pkg.Link {
+			if target, ok := byPath[pkg.Resolved]; ok {
+				byPath[p] = target
+			}
+		}
+	}
+
+	// Resolve the dependencies of every install path
+	for _, p := range paths {
+		pkg := lock.Packages[p]
+		if pkg.Link {
+			continue
+		}
+		n := byPath[p]
+
+		sections := []struct {
+			deps map[string]string
+			kind DependencyType
+		}{
+			{pkg.Dependencies, Prod},
+			{pkg.DevDependencies, Dev},
+			{pkg.OptionalDependencies, Optional},
+			{pkg.PeerDependencies, Peer},
+		}
+
+		for _, section := range sections {
+			names := make([]string, 0, len(section.deps))
+			for name := range section.deps {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				rng := section.deps[name]
+				n.addDependency(&Edge{
+					Name:         name,
+					Range:        rng,
+					Type:         section.kind,
+					OptionalPeer: section.kind == Peer && pkg.PeerDependenciesMeta[name].Optional,
+					To:           resolveNpm(byPath, p, name),
+				})
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// npmPaths returns the install paths of a package-lock.json, shallowest first
+func npmPaths(packages map[string]npmPackage) []string {
+	paths := make([]string, 0, len(packages))
+	for p := range packages {
+		paths = append(paths, p)
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], "node_modules/"), strings.Count(paths[j], "node_modules/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// resolveNpm finds the package name requires from the install path from,
+// walking up through the parent node_modules folders
+func resolveNpm(byPath map[string]*Node, from, name string) *Node {
+	dir := from
+	for {
+		candidate := "node_modules/" + name
+		if dir != "" {
+			candidate = dir + "/" + candidate
+		}
+		if n, ok := byPath[candidate]; ok {
+			return n
+		}
+
+		if dir == "" {
+			return nil
+		}
+
+		// Move up to the enclosing package, or to the root
+		index := strings.LastIndex(dir, "/node_modules/")
+		if index < 0 {
+			dir = ""
+		} else {
+			dir = dir[:index]
+		}
+	}
+}
+
+// npmPackageName derives the package name from an install path
+func npmPackageName(p string) string {
+	index := strings.LastIndex(p, "node_modules/")
+	return p[index+len("node_modules/"):]
+}
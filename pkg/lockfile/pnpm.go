@@ -0,0 +1,252 @@
+package lockfile
+
+import (
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pnpmLockfile is the layout of pnpm-lock.yaml versions 5, 6 and 9
+type pnpmLockfile struct {
+	LockfileVersion string `yaml:"lockfileVersion"`
+	// Single project lockfiles (v5, v6) list the root dependencies at the top level
+	pnpmImporter `yaml:",inline"`
+	Importers    map[string]pnpmImporter `yaml:"importers"`
+	Packages     map[string]pnpmPackage  `yaml:"packages"`
+	// Snapshots hold the dependencies of each package since v9
+	Snapshots map[string]pnpmPackage `yaml:"snapshots"`
+}
+
+// pnpmImporter lists the direct dependencies of a project
+type pnpmImporter struct {
+	Dependencies         map[string]pnpmReference `yaml:"dependencies"`
+	DevDependencies      map[string]pnpmReference `yaml:"devDependencies"`
+	OptionalDependencies map[string]pnpmReference `yaml:"optionalDependencies"`
+	// Specifiers holds the package.json ranges in v5
+	Specifiers map[string]string `yaml:"specifiers"`
+}
+
+// pnpmReference is a resolved dependency: a plain version before v6.1,
+// or a specifier and version pair after
+type pnpmReference struct {
+	Specifier string `yaml:"specifier"`
+	Version   string `yaml:"version"`
+}
+
+// UnmarshalYAML accepts both the plain and the object form
+func (r *pnpmReference) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		r.Version = value.Value
+		return nil
+	}
+
+	type plain pnpmReference
+	return value.Decode((*plain)(r))
+}
+
+// pnpmPackage is an entry of the packages or snapshots map
+type pnpmPackage struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+		Tarball   string `yaml:"tarball"`
+	} `yaml:"resolution"`
+	Dev                  *bool             `yaml:"dev"`
+	Optional             bool              `yaml:"optional"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+	PeerDependencies     map[string]string `yaml:"peerDependencies"`
+	PeerDependenciesMeta peerMeta          `yaml:"peerDependenciesMeta"`
+}
+
+// parsePnpm parses a pnpm-lock.yaml. Packages are keyed by
+// "/name/version" (v5), "/name@version" (v6) or "name@version" (v9),
+// optionally followed by the versions of their peers.
+func parsePnpm(data []byte) (*Graph, error) {
+	var lock pnpmLockfile
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	graph := newGraph(KindPnpm, lock.LockfileVersion)
+	slashKeys := strings.HasPrefix(lock.LockfileVersion, "5")
+
+	// Create a node for every package
+	for key, pkg := range lock.Packages {
+		// Tarball and git dependencies carry their name and version in the entry
+		name, version := pnpmNameVersion(key, slashKeys)
+		if version == "" && pkg.Name != "" {
+			name, version = pkg.Name, pkg.Version
+		}
+
+		n := graph.node(name, version)
+		n.Integrity = pkg.Resolution.Integrity
+		n.Resolved = pkg.Resolution.Tarball
+		n.Dev = pkg.Dev != nil && *pkg.Dev
+		n.Optional = pkg.Optional
+	}
+
+	// Resolve the dependencies of every package, from snapshots when present
+	entries := lock.Packages
+	if lock.Snapshots != nil {
+		entries = lock.Snapshots
+	}
+
+	for key, pkg := range entries {
+		name, version := pnpmNameVersion(key, slashKeys)
+		n := graph.node(name, version)
+		if pkg.Optional {
+			n.Optional = true
+		}
+
+		sections := []struct {
+			deps map[string]string
+			kind DependencyType
+		}{
+			{pkg.Dependencies, Prod},
+			{pkg.OptionalDependencies, Optional},
+		}
+
+		for _, section := range sections {
+			for depName, reference := range section.deps {
+				n.addDependency(&Edge{
+					Name:  depName,
+					Range: pnpmVersion(reference, slashKeys),
+					Type:  section.kind,
+					To:    resolvePnpm(graph, depName, reference, slashKeys),
+				})
+			}
+		}
+
+		// Peers are listed on the package, which v9 keeps apart from its
+		// snapshots, and resolved through the regular dependencies
+		peers, meta := pkg.PeerDependencies, pkg.PeerDependenciesMeta
+		if lock.Snapshots != nil {
+			info := lock.Packages[pnpmVersion(key, false)]
+			peers, meta = info.PeerDependencies, info.PeerDependenciesMeta
+		}
+
+		for depName, rng := range peers {
+			edge := n.Dependency(depName)
+			if edge == nil {
+				edge = &Edge{Name: depName}
+				n.Dependencies = append(n.Dependencies, edge)
+			}
+			edge.Range = rng
+			edge.Type = Peer
+			edge.OptionalPeer = meta[depName].Optional
+		}
+	}
+
+	// Importers: the root and any workspace packages
+	importers := lock.Importers
+	if importers == nil {
+		importers = map[string]pnpmImporter{".": lock.pnpmImporter}
+	}
+
+	for p := range importers {
+		if p != "." {
+			graph.Importers[p] = &Node{
+				Name:      path.Base(p),
+				Path:      p,
+				Workspace: true,
+			}
+		}
+	}
+
+	for p, importer := range importers {
+		n := graph.Root
+		if p != "." {
+			n = graph.Importers[p]
+		}
+
+		sections := []struct {
+			deps map[string]pnpmReference
+			kind DependencyType
+		}{
+			{importer.Dependencies, Prod},
+			{importer.DevDependencies, Dev},
+			{importer.OptionalDependencies, Optional},
+		}
+
+		for _, section := range sections {
+			for depName, reference := range section.deps {
+				rng := reference.Specifier
+				if rng == "" {
+					rng = importer.Specifiers[depName]
+				}
+
+				// Workspace links point at another importer
+				var to *Node
+				if target, ok := strings.CutPrefix(reference.Version, "link:"); ok {
+					to = graph.Importers[path.Clean(path.Join(p, target))]
+				} else {
+					to = resolvePnpm(graph, depName, reference.Version, slashKeys)
+				}
+
+				n.addDependency(&Edge{
+					Name:  depName,
+					Range: rng,
+					Type:  section.kind,
+					To:    to,
+				})
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// resolvePnpm finds the package a dependency reference points at. The
+// reference is a version, or a full package key for aliased dependencies.
+func resolvePnpm(graph *Graph, name, reference string, slashKeys bool) *Node {
+	if strings.HasPrefix(reference, "link:") || strings.HasPrefix(reference, "file:") {
+		return nil
+	}
+
+	version := pnpmVersion(reference, slashKeys)
+
+	// Aliases reference another package: /string-width/4.2.3 or string-width@4.2.3
+	if strings.HasPrefix(reference, "/") || strings.Contains(version, "@") {
+		name, version = pnpmNameVersion(reference, slashKeys)
+	}
+
+	return graph.Node(name, version)
+}
+
+// pnpmNameVersion splits a package key into its name and version
+func pnpmNameVersion(key string, slashKeys bool) (string, string) {
+	key = strings.TrimPrefix(pnpmVersion(key, false), "/")
+
+	if !slashKeys {
+		return splitSpec(key)
+	}
+
+	// @scope/name/1.0.0_peers or name/1.0.0_peers
+	parts := strings.SplitN(key, "/", 3)
+	if strings.HasPrefix(key, "@") && len(parts) == 3 {
+		return parts[0] + "/" + parts[1], pnpmVersion(parts[2], true)
+	}
+	if len(parts) < 2 {
+		return key, ""
+	}
+	return parts[0], pnpmVersion(strings.Join(parts[1:], "/"), true)
+}
+
+// pnpmVersion strips the peer suffix from a version or key:
+// "1.0.0(react@18.2.0)" in v6 and v9, "1.0.0_react@18.2.0" in v5
+func pnpmVersion(reference string, slashKeys bool) string {
+	if index := strings.Index(reference, "("); index >= 0 {
+		reference = reference[:index]
+	}
+
+	if slashKeys && !strings.HasPrefix(reference, "/") {
+		if index := strings.Index(reference, "_"); index >= 0 {
+			reference = reference[:index]
+		}
+	}
+
+	return reference
+}
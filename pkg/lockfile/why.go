@@ -0,0 +1,135 @@
+package lockfile
+
+import (
+	"sort"
+	"strings"
+)
+
+// Step is one hop of a dependency path: From depends on Edge.To through Edge
+type Step struct {
+	From *Node
+	Edge *Edge
+}
+
+// Path is a chain of dependencies from an importer to a package
+type Path []Step
+
+// Target returns the package the path leads to
+func (p Path) Target() *Node {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[len(p)-1].Edge.To
+}
+
+// String renders the path as "app > a@^1.0.0 > b@^2.0.0"
+func (p Path) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	parts := []string{p[0].From.Name}
+	for _, step := range p {
+		parts = append(parts, step.Edge.Name+"@"+step.Edge.Range)
+	}
+	return strings.Join(parts, " > ")
+}
+
+// allNodes returns the importers sorted by path, then the packages sorted by ID
+func (g *Graph) allNodes() []*Node {
+	paths := make([]string, 0, len(g.Importers))
+	for p := range g.Importers {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	nodes := make([]*Node, 0, len(paths)+len(ids))
+	for _, p := range paths {
+		nodes = append(nodes, g.Importers[p])
+	}
+	for _, id := range ids {
+		nodes = append(nodes, g.Nodes[id])
+	}
+	return nodes
+}
+
+// whyPartialsPerPath bounds the unfinished paths Why keeps per path asked for
+const whyPartialsPerPath = 100
+
+// Why returns the paths from the importers to every version of the named
+// package, shortest first, like npm explain. At most limit paths are
+// returned as the number of paths grows quickly in large graphs.
+func (g *Graph) Why(name string, limit int) []Path {
+	// Index the dependents of every node once
+	dependents := make(map[*Node][]Step)
+	for _, from := range g.allNodes() {
+		for _, edge := range from.Dependencies {
+			if edge.To != nil {
+				dependents[edge.To] = append(dependents[edge.To], Step{From: from, Edge: edge})
+			}
+		}
+	}
+
+	// partial is a path from n to the target, still to be extended to an importer
+	type partial struct {
+		n      *Node
+		suffix Path
+	}
+	var level []partial
+	for _, n := range g.Lookup(name) {
+		level = append(level, partial{n: n})
+	}
+
+	// Walk backwards from the target one hop at a time, so every path of a
+	// length is found before any longer one. The paths still being walked
+	// are capped too, which only drops longer paths.
+	maxPartials := whyPartialsPerPath * limit
+	var paths []Path
+	for len(level) > 0 && len(paths) < limit {
+		var next []partial
+		for _, p := range level {
+			if p.n.Workspace {
+				paths = append(paths, p.suffix)
+				continue
+			}
+
+			for _, step := range dependents[p.n] {
+				if p.suffix.visits(step.From) || step.From == p.n {
+					continue // Skip cycles
+				}
+				if len(next) >= maxPartials {
+					break
+				}
+				next = append(next, partial{n: step.From, suffix: append(Path{step}, p.suffix...)})
+			}
+		}
+		level = next
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+		return paths[i].String() < paths[j].String()
+	})
+	if len(paths) > limit {
+		paths = paths[:limit]
+	}
+	return paths
+}
+
+// visits reports whether the path goes through n
+func (p Path) visits(n *Node) bool {
+	for _, step := range p {
+		if step.From == n || step.Edge.To == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package lockfile
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// link adds a dependency from one node to another, with the target version as range
+func link(from, to *Node) {
+	from.addDependency(&Edge{Name: to.Name, Range: to.Version, Type: Prod, To: to})
+}
+
+func TestWhyShortestFirst(t *testing.T) {
+	graph := newGraph(KindNpm, "3")
+	graph.Root.Name = "app"
+	target := graph.node("ms", "2.1.2")
+
+	// Many long paths: app > wide-N@1.0.0 > deep@1.0.0 > debug@4.3.4 > ms
+	debug := graph.node("debug", "4.3.4")
+	deep := graph.node("deep", "1.0.0")
+	link(debug, target)
+	link(deep, debug)
+	for i := 0; i < 30; i++ {
+		wide := graph.node(fmt.Sprintf("wide-%02d", i), "1.0.0")
+		link(wide, deep)
+		link(graph.Root, wide)
+	}
+
+	// A short one through a workspace package, and a direct dependency
+	lib := &Node{Name: "lib", Version: "1.0.0", Workspace: true}
+	graph.Importers["packages/lib"] = lib
+	link(lib, debug)
+	link(graph.Root, target)
+
+	// A cycle back to the target is not a path
+	link(target, debug)
+
+	var got []string
+	for _, path := range graph.Why("ms", 3) {
+		got = append(got, path.String())
+	}
+	want := []string{
+		"app > ms@2.1.2",
+		"lib > debug@4.3.4 > ms@2.1.2",
+		"app > wide-00@1.0.0 > deep@1.0.0 > debug@4.3.4 > ms@2.1.2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Why = %q, want %q", got, want)
+	}
+
+	if paths := graph.Why("ms", 100); len(paths) != 32 {
+		t.Errorf("Why found %d paths, want 32", len(paths))
+	}
+}
@@ -0,0 +1,307 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yarnEntry is one block of a yarn v1 lockfile
+type yarnEntry struct {
+	specs        []string
+	version      string
+	resolved     string
+	integrity    string
+	dependencies map[DependencyType]map[string]string
+}
+
+// parseYarn parses a yarn v1 lockfile. Its blocks are keyed by the
+// "name@range" specs that resolved to them, so edges resolve by spec.
+func parseYarn(data []byte, manifest *Manifest) (*Graph, error) {
+	entries, err := readYarnEntries(data)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := newGraph(KindYarn, "1")
+	bySpec := make(map[string]*Node)
+
+	// Create a node for every block
+	for _, entry := range entries {
+		if len(entry.specs) == 0 {
+			continue
+		}
+
+		name, _ := splitSpec(entry.specs[0])
+		n := graph.node(name, entry.version)
+		n.Resolved = entry.resolved
+		n.Integrity = entry.integrity
+
+		for _, spec := range entry.specs {
+			bySpec[spec] = n
+		}
+	}
+
+	// Resolve the dependencies of every block
+	for _, entry := range entries {
+		if len(entry.specs) == 0 {
+			continue
+		}
+		n := bySpec[entry.specs[0]]
+
+		for kind, deps := range entry.dependencies {
+			for name, rng := range deps {
+				n.addDependency(&Edge{
+					Name:  name,
+					Range: rng,
+					Type:  kind,
+					To:    bySpec[name+"@"+rng],
+				})
+			}
+		}
+	}
+
+	// The root dependencies come from package.json
+	if manifest != nil {
+		graph.Root.Name = manifest.Name
+		graph.Root.Version = manifest.Version
+
+		for kind, deps := range manifest.sections() {
+			for name, rng := range deps {
+				graph.Root.addDependency(&Edge{
+					Name:  name,
+					Range: rng,
+					Type:  kind,
+					To:    bySpec[name+"@"+rng],
+				})
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// readYarnEntries splits a yarn v1 lockfile into its blocks
+func readYarnEntries(data []byte) ([]*yarnEntry, error) {
+	var entries []*yarnEntry
+	var entry *yarnEntry
+	var section map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Skip comments and blank lines
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case indent == 0:
+			// A new block: "a@^1.0.0", a@~1.0.0:
+			if !strings.HasSuffix(trimmed, ":") {
+				return nil, fmt.Errorf("line %d: expected a package key", lineNumber)
+			}
+
+			entry = &yarnEntry{dependencies: make(map[DependencyType]map[string]string)}
+			for _, spec := range strings.Split(strings.TrimSuffix(trimmed, ":"), ",") {
+				entry.specs = append(entry.specs, unquote(strings.TrimSpace(spec)))
+			}
+			entries = append(entries, entry)
+			section = nil
+
+		case entry == nil:
+			return nil, fmt.Errorf("line %d: field outside of a package", lineNumber)
+
+		case indent <= 2:
+			// A field of the block, or the start of a dependency section
+			key, value := splitYarnField(trimmed)
+			section = nil
+
+			switch key {
+			case "version":
+				entry.version = value
+			case "resolved":
+				entry.resolved = value
+			case "integrity":
+				entry.integrity = value
+			case string(Prod), string(Optional), string(Peer):
+				section = make(map[string]string)
+				entry.dependencies[DependencyType(key)] = section
+			}
+
+		default:
+			// A dependency inside a section
+			if section != nil {
+				name, rng := splitYarnField(trimmed)
+				section[name] = rng
+			}
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// splitYarnField splits `key "value"` or `key:` into its parts
+func splitYarnField(field string) (string, string) {
+	field = strings.TrimSuffix(field, ":")
+
+	// Keys may be quoted and contain spaces-free scopes, e.g. "@babel/core" "^7.0.0"
+	var key, rest string
+	if strings.HasPrefix(field, `"`) {
+		end := strings.Index(field[1:], `"`)
+		if end < 0 {
+			return unquote(field), ""
+		}
+		key, rest = field[1:end+1], field[end+2:]
+	} else {
+		parts := strings.SplitN(field, " ", 2)
+		key = parts[0]
+		if len(parts) == 2 {
+			rest = parts[1]
+		}
+	}
+
+	return key, unquote(strings.TrimSpace(rest))
+}
+
+// unquote removes surrounding double quotes
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// splitSpec splits "name@range" while keeping the @ of scoped names
+func splitSpec(spec string) (string, string) {
+	if spec == "" {
+		return "", ""
+	}
+
+	index := strings.Index(spec[1:], "@")
+	if index < 0 {
+		return spec, ""
+	}
+	return spec[:index+1], spec[index+2:]
+}
+
+// berryEntry is one package of a yarn berry lockfile
+type berryEntry struct {
+	Version              string            `yaml:"version"`
+	Resolution           string            `yaml:"resolution"`
+	Checksum             string            `yaml:"checksum"`
+	LinkType             string            `yaml:"linkType"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+	PeerDependencies     map[string]string `yaml:"peerDependencies"`
+	PeerDependenciesMeta peerMeta          `yaml:"peerDependenciesMeta"`
+}
+
+// parseYarnBerry parses a yarn.lock written by yarn 2 or later. It is YAML,
+// keyed by descriptors such as "lodash@npm:^4.17.0", and records the
+// workspaces as "name@workspace:path" entries.
+func parseYarnBerry(data []byte) (*Graph, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var metadata struct {
+		Version string `yaml:"version"`
+	}
+	if node, ok := raw["__metadata"]; ok {
+		node.Decode(&metadata)
+	}
+
+	graph := newGraph(KindYarnBerry, metadata.Version)
+	byDescriptor := make(map[string]*Node)
+	entries := make(map[*Node]berryEntry)
+
+	// Create a node for every entry
+	for key, value := range raw {
+		if key == "__metadata" {
+			continue
+		}
+
+		var entry berryEntry
+		if err := value.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+
+		name, reference := splitSpec(entry.Resolution)
+		if name == "" {
+			name, _ = splitSpec(strings.TrimSpace(strings.Split(key, ",")[0]))
+		}
+
+		var n *Node
+		if strings.HasPrefix(reference, "workspace:") {
+			// Workspaces are importers, "." being the root
+			p := strings.TrimPrefix(reference, "workspace:")
+			if p == "." {
+				n = graph.Root
+				p = ""
+			} else {
+				n = &Node{Workspace: true}
+				graph.Importers[p] = n
+			}
+			n.Name = name
+			n.Version = entry.Version
+			n.Path = p
+		} else {
+			n = graph.node(name, entry.Version)
+			n.Resolved = entry.Resolution
+			n.Integrity = entry.Checksum
+		}
+		entries[n] = entry
+
+		for _, descriptor := range strings.Split(key, ",") {
+			byDescriptor[strings.TrimSpace(descriptor)] = n
+		}
+	}
+
+	// Resolve the dependencies of every entry
+	for n, entry := range entries {
+		sections := []struct {
+			deps map[string]string
+			kind DependencyType
+		}{
+			{entry.Dependencies, Prod},
+			{entry.OptionalDependencies, Optional},
+			{entry.PeerDependencies, Peer},
+		}
+
+		for _, section := range sections {
+			for name, rng := range section.deps {
+				n.addDependency(&Edge{
+					Name:         name,
+					Range:        strings.TrimPrefix(rng, "npm:"),
+					Type:         section.kind,
+					OptionalPeer: section.kind == Peer && entry.PeerDependenciesMeta[name].Optional,
+					To:           resolveBerry(byDescriptor, name, rng),
+				})
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// resolveBerry finds the entry of a dependency; ranges without a protocol
+// are recorded under their npm: descriptor
+func resolveBerry(byDescriptor map[string]*Node, name, rng string) *Node {
+	if n, ok := byDescriptor[name+"@"+rng]; ok {
+		return n
+	}
+	return byDescriptor[name+"@npm:"+rng]
+}
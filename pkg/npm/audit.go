@@ -0,0 +1,79 @@
+package npm
+
+import (
+	"fmt"
+
+	"github.com/VesperAkshay/lazynode/pkg/audit"
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+)
+
+// Audit runs the client's security audit and parses the advisories
+func (pm *PackageManager) Audit() (*audit.Report, error) {
+	output, err := pm.command(pm.Client.AuditArgs()...).Output()
+	if err != nil {
+		// audit commands return a non-zero exit code if vulnerabilities are found
+		// so we need to check if we got any output
+		if len(output) == 0 {
+			return nil, fmt.Errorf("%s audit error: %v", pm.Client.Name(), err)
+		}
+	}
+
+	report, err := audit.Parse(output)
+	if err != nil {
+		return nil, err
+	}
+
+	pm.LastAudit = report
+	return report, nil
+}
+
+// FixAdvisory fixes a single advisory: a direct dependency is installed at
+// the fixed version, anything else is updated within the ranges that
+// depend on it
+func (pm *PackageManager) FixAdvisory(advisory audit.Advisory) error {
+	return pm.History.Record("Fix advisory in "+advisory.Package, func() error {
+		if advisory.FixPackage != "" && advisory.FixVersion != "" {
+			pkg, direct := pm.Packages[advisory.FixPackage]
+			if direct {
+				return pm.addToSection(advisory.FixPackage+"@"+advisory.FixVersion, pkg.Type)
+			}
+		}
+
+		return pm.updatePackage(advisory.Package)
+	})
+}
+
+// AuditFix lets the client fix every advisory it can
+func (pm *PackageManager) AuditFix() error {
+	return pm.History.Record("Audit fix", func() error {
+		args := pm.Client.AuditFixArgs()
+		if args == nil {
+			return fmt.Errorf("%s cannot fix advisories, add an override instead", pm.Client.Name())
+		}
+
+		output, err := pm.command(args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s audit fix error: %v - %s", pm.Client.Name(), err, string(output))
+		}
+
+		// Reload packages after fixing
+		return pm.LoadPackages()
+	})
+}
+
+// SetOverride forces every copy of a package into the given range through
+// the client's overrides field of package.json
+func (pm *PackageManager) SetOverride(name, rng string) error {
+	return pm.History.Record(fmt.Sprintf("Override %s to %s", name, rng), func() error {
+		return pm.setOverride(name, rng)
+	})
+}
+
+// setOverride writes the override of SetOverride, without recording it
+func (pm *PackageManager) setOverride(name, rng string) error {
+	return pm.editPackageJSON(func(doc *packagejson.Document) error {
+		// The overrides object is created if needed
+		path := append(append([]string{}, pm.Client.OverridesField()...), name)
+		return doc.Set(path, rng)
+	})
+}
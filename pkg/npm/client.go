@@ -0,0 +1,643 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/config"
+)
+
+// Client describes a package manager backend (npm, pnpm, yarn or bun).
+// Each client knows the arguments for its own commands and how to parse
+// its own JSON output.
+type Client interface {
+	// Name returns the identifier of the client, e.g. "npm" or "yarn-berry"
+	Name() string
+	// Binary returns the executable to run
+	Binary() string
+	// ListArgs returns the arguments that list the installed top-level packages.
+	// A nil result means the client has no machine-readable listing.
+	ListArgs() []string
+	// ParseList parses the output of ListArgs into a map of package name to installed version
+	ParseList(output []byte) (map[string]string, error)
+	// InstallArgs returns the arguments to add a package
+	InstallArgs(name string, isDev bool) []string
+	// AddArgs returns the arguments to add a package to the section of a
+	// dependency type, e.g. TypePeerDependency. A package already declared
+	// elsewhere must be removed from package.json first.
+	AddArgs(name string, depType string) []string
+	// UninstallArgs returns the arguments to remove a package
+	UninstallArgs(name string) []string
+	// UpdateArgs returns the arguments to update a package within its range
+	UpdateArgs(name string) []string
+	// OutdatedArgs returns the arguments that report outdated packages.
+	// A nil result means the client cannot report outdated packages.
+	OutdatedArgs() []string
+	// ParseOutdated parses the output of OutdatedArgs
+	ParseOutdated(output []byte) (map[string]OutdatedPackage, error)
+	// TreeArgs returns the arguments that print the dependency tree
+	TreeArgs() []string
+	// RunArgs returns the arguments to run a package.json script
+	RunArgs(script string) []string
+	// AuditArgs returns the arguments that print a JSON security report
+	AuditArgs() []string
+	// AuditFixArgs returns the arguments that fix every advisory.
+	// A nil result means the client cannot fix advisories itself.
+	AuditFixArgs() []string
+	// DedupeArgs returns the arguments that reduce the lockfile to as few
+	// versions of each package as the ranges allow. A nil result means the
+	// client cannot dedupe.
+	DedupeArgs() []string
+	// OverridesField returns the package.json field that forces versions of
+	// transitive dependencies, e.g. ["pnpm", "overrides"]
+	OverridesField() []string
+	// WorkspaceArgs returns the arguments running a command for one package
+	// of a monorepo from its root, e.g. "--workspace" for npm and "--filter"
+	// for pnpm. A nil result means the client has no flag for the command:
+	// it runs in the package directory instead.
+	WorkspaceArgs(workspace string, args []string) []string
+}
+
+// OutdatedPackage describes a package reported by the outdated command
+type OutdatedPackage struct {
+	Name     string `json:"name"`
+	Current  string `json:"current"`
+	Wanted   string `json:"wanted"`
+	Latest   string `json:"latest"`
+	Location string `json:"location,omitempty"`
+	Type     string `json:"-"` // Dependency type, e.g. TypeDevDependency, set by the package manager
+}
+
+// Client names
+const (
+	ClientNpm       = "npm"
+	ClientPnpm      = "pnpm"
+	ClientYarn      = "yarn"
+	ClientYarnBerry = "yarn-berry"
+	ClientBun       = "bun"
+)
+
+// NewClient returns the client with the given name
+func NewClient(name string) (Client, error) {
+	switch name {
+	case ClientNpm:
+		return npmClient{}, nil
+	case ClientPnpm:
+		return pnpmClient{}, nil
+	case ClientYarn:
+		return yarnClient{}, nil
+	case ClientYarnBerry:
+		return yarnBerryClient{}, nil
+	case ClientBun:
+		return bunClient{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown package manager: %s", name)
+}
+
+// DetectClient picks the client for the project containing packageJSONPath.
+// The config override wins, then the packageManager field of package.json,
+// then the lockfile found in the project or one of its parents. npm is the default.
+func DetectClient(packageJSONPath string) (Client, error) {
+	projectDir := filepath.Dir(packageJSONPath)
+
+	// Check the config override first
+	cfg, err := config.Load(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	if cfg.PackageManager != "" {
+		return NewClient(cfg.PackageManager)
+	}
+
+	// Then the packageManager field (corepack), e.g. "pnpm@8.6.0"
+	if data, err := os.ReadFile(packageJSONPath); err == nil {
+		var packageJSON struct {
+			PackageManager string `json:"packageManager"`
+		}
+		if json.Unmarshal(data, &packageJSON) == nil && packageJSON.PackageManager != "" {
+			if client := clientFromPackageManagerField(packageJSON.PackageManager); client != nil {
+				return client, nil
+			}
+		}
+	}
+
+	// Finally look for a lockfile, walking up to support workspaces
+	dir := projectDir
+	for {
+		if client := clientFromLockfile(dir); client != nil {
+			return client, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return npmClient{}, nil
+}
+
+// clientFromPackageManagerField maps a corepack packageManager value to a client
+func clientFromPackageManagerField(value string) Client {
+	name, version := splitNameVersion(value)
+
+	switch name {
+	case "npm":
+		return npmClient{}
+	case "pnpm":
+		return pnpmClient{}
+	case "bun":
+		return bunClient{}
+	case "yarn":
+		// Yarn 2+ is berry
+		if version != "" && !strings.HasPrefix(version, "1.") {
+			return yarnBerryClient{}
+		}
+		return yarnClient{}
+	}
+
+	return nil
+}
+
+// clientFromLockfile returns the client owning a lockfile in dir, or nil
+func clientFromLockfile(dir string) Client {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch {
+	case exists("bun.lockb"), exists("bun.lock"):
+		return bunClient{}
+	case exists("pnpm-lock.yaml"):
+		return pnpmClient{}
+	case exists("yarn.lock"):
+		// Berry lockfiles carry a __metadata block
+		data, err := os.ReadFile(filepath.Join(dir, "yarn.lock"))
+		if err == nil && strings.Contains(string(data), "__metadata:") {
+			return yarnBerryClient{}
+		}
+		return yarnClient{}
+	case exists("package-lock.json"), exists("npm-shrinkwrap.json"):
+		return npmClient{}
+	}
+
+	return nil
+}
+
+// Command creates a client command for the project in dir. When the project
+// is a package of a monorepo, workspace names it and the command runs from
+// rootDir with the client's workspace flag, if it has one.
+func Command(client Client, dir, rootDir, workspace string, args ...string) *exec.Cmd {
+	if workspace != "" {
+		if workspaceArgs := client.WorkspaceArgs(workspace, args); workspaceArgs != nil {
+			cmd := exec.Command(client.Binary(), workspaceArgs...)
+			cmd.Dir = rootDir
+			return cmd
+		}
+	}
+
+	cmd := exec.Command(client.Binary(), args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// isPathSelector reports whether a workspace selector is the path of a
+// package without a name rather than its name
+func isPathSelector(workspace string) bool {
+	return strings.HasPrefix(workspace, ".") || filepath.IsAbs(workspace)
+}
+
+// splitNameVersion splits "name@version" while keeping the @ of scoped names
+func splitNameVersion(spec string) (string, string) {
+	index := strings.LastIndex(spec, "@")
+	if index <= 0 {
+		return spec, ""
+	}
+	return spec[:index], spec[index+1:]
+}
+
+// npmClient runs the npm CLI
+type npmClient struct{}
+
+func (npmClient) Name() string       { return ClientNpm }
+func (npmClient) Binary() string     { return "npm" }
+func (npmClient) ListArgs() []string { return []string{"list", "--json", "--depth=0"} }
+func (npmClient) TreeArgs() []string { return []string{"list", "--depth=1"} }
+
+func (npmClient) ParseList(output []byte) (map[string]string, error) {
+	var result struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string)
+	for name, info := range result.Dependencies {
+		versions[name] = info.Version
+	}
+
+	return versions, nil
+}
+
+func (npmClient) InstallArgs(name string, isDev bool) []string {
+	if isDev {
+		return []string{"install", name, "--save-dev"}
+	}
+	return []string{"install", name, "--save"}
+}
+
+func (npmClient) AddArgs(name string, depType string) []string {
+	switch depType {
+	case TypeDevDependency:
+		return []string{"install", name, "--save-dev"}
+	case TypePeerDependency:
+		return []string{"install", name, "--save-peer"}
+	case TypeOptionalDependency:
+		return []string{"install", name, "--save-optional"}
+	}
+	return []string{"install", name, "--save-prod"}
+}
+
+func (npmClient) UninstallArgs(name string) []string { return []string{"uninstall", name} }
+func (npmClient) UpdateArgs(name string) []string    { return []string{"update", name} }
+func (npmClient) OutdatedArgs() []string             { return []string{"outdated", "--json"} }
+func (npmClient) RunArgs(script string) []string     { return []string{"run", script} }
+func (npmClient) AuditArgs() []string                { return []string{"audit", "--json"} }
+func (npmClient) AuditFixArgs() []string             { return []string{"audit", "fix"} }
+func (npmClient) DedupeArgs() []string               { return []string{"dedupe"} }
+func (npmClient) OverridesField() []string           { return []string{"overrides"} }
+
+func (npmClient) WorkspaceArgs(workspace string, args []string) []string {
+	return append(append([]string{}, args...), "--workspace", workspace)
+}
+
+func (npmClient) ParseOutdated(output []byte) (map[string]OutdatedPackage, error) {
+	return parseOutdatedObject(output)
+}
+
+// parseOutdatedObject parses the name-keyed outdated JSON shared by npm and pnpm.
+// In workspaces npm reports an array of entries per package; the first one is kept.
+func parseOutdatedObject(output []byte) (map[string]OutdatedPackage, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]OutdatedPackage)
+	for name, data := range raw {
+		var info OutdatedPackage
+		if err := json.Unmarshal(data, &info); err != nil {
+			var infos []OutdatedPackage
+			if err := json.Unmarshal(data, &infos); err != nil || len(infos) == 0 {
+				continue
+			}
+			info = infos[0]
+		}
+		info.Name = name
+		result[name] = info
+	}
+
+	return result, nil
+}
+
+// pnpmClient runs the pnpm CLI
+type pnpmClient struct{}
+
+func (pnpmClient) Name() string       { return ClientPnpm }
+func (pnpmClient) Binary() string     { return "pnpm" }
+func (pnpmClient) ListArgs() []string { return []string{"list", "--json", "--depth=0"} }
+func (pnpmClient) TreeArgs() []string { return []string{"list", "--depth=1"} }
+
+func (pnpmClient) ParseList(output []byte) (map[string]string, error) {
+	type entry struct {
+		Version string `json:"version"`
+	}
+	var projects []struct {
+		Dependencies         map[string]entry `json:"dependencies"`
+		DevDependencies      map[string]entry `json:"devDependencies"`
+		OptionalDependencies map[string]entry `json:"optionalDependencies"`
+	}
+
+	if err := json.Unmarshal(output, &projects); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string)
+	for _, project := range projects {
+		for _, section := range []map[string]entry{project.Dependencies, project.DevDependencies, project.OptionalDependencies} {
+			for name, info := range section {
+				versions[name] = info.Version
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+func (pnpmClient) InstallArgs(name string, isDev bool) []string {
+	if isDev {
+		return []string{"add", name, "--save-dev"}
+	}
+	return []string{"add", name}
+}
+
+func (pnpmClient) AddArgs(name string, depType string) []string {
+	switch depType {
+	case TypeDevDependency:
+		return []string{"add", name, "--save-dev"}
+	case TypePeerDependency:
+		return []string{"add", name, "--save-peer"}
+	case TypeOptionalDependency:
+		return []string{"add", name, "--save-optional"}
+	}
+	return []string{"add", name}
+}
+
+func (pnpmClient) UninstallArgs(name string) []string { return []string{"remove", name} }
+func (pnpmClient) UpdateArgs(name string) []string    { return []string{"update", name} }
+func (pnpmClient) OutdatedArgs() []string             { return []string{"outdated", "--format", "json"} }
+func (pnpmClient) RunArgs(script string) []string     { return []string{"run", script} }
+func (pnpmClient) AuditArgs() []string                { return []string{"audit", "--json"} }
+func (pnpmClient) AuditFixArgs() []string             { return []string{"audit", "--fix"} }
+func (pnpmClient) DedupeArgs() []string               { return []string{"dedupe"} }
+func (pnpmClient) OverridesField() []string           { return []string{"pnpm", "overrides"} }
+
+func (pnpmClient) WorkspaceArgs(workspace string, args []string) []string {
+	return append([]string{"--filter", workspace}, args...)
+}
+
+func (pnpmClient) ParseOutdated(output []byte) (map[string]OutdatedPackage, error) {
+	return parseOutdatedObject(output)
+}
+
+// yarnClient runs yarn classic (v1)
+type yarnClient struct{}
+
+func (yarnClient) Name() string       { return ClientYarn }
+func (yarnClient) Binary() string     { return "yarn" }
+func (yarnClient) ListArgs() []string { return []string{"list", "--json", "--depth=0"} }
+func (yarnClient) TreeArgs() []string { return []string{"list", "--depth=1"} }
+
+func (yarnClient) ParseList(output []byte) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	// yarn v1 prints one JSON object per line; the tree is in the "tree" line
+	for _, line := range strings.Split(string(output), "\n") {
+		var event struct {
+			Type string `json:"type"`
+			Data struct {
+				Trees []struct {
+					Name string `json:"name"`
+				} `json:"trees"`
+			} `json:"data"`
+		}
+		if json.Unmarshal([]byte(line), &event) != nil || event.Type != "tree" {
+			continue
+		}
+
+		for _, tree := range event.Data.Trees {
+			name, version := splitNameVersion(tree.Name)
+			versions[name] = version
+		}
+		return versions, nil
+	}
+
+	return nil, fmt.Errorf("yarn list returned no tree")
+}
+
+func (yarnClient) InstallArgs(name string, isDev bool) []string {
+	if isDev {
+		return []string{"add", name, "--dev"}
+	}
+	return []string{"add", name}
+}
+
+func (yarnClient) AddArgs(name string, depType string) []string {
+	switch depType {
+	case TypeDevDependency:
+		return []string{"add", name, "--dev"}
+	case TypePeerDependency:
+		return []string{"add", name, "--peer"}
+	case TypeOptionalDependency:
+		return []string{"add", name, "--optional"}
+	}
+	return []string{"add", name}
+}
+
+func (yarnClient) UninstallArgs(name string) []string { return []string{"remove", name} }
+func (yarnClient) UpdateArgs(name string) []string    { return []string{"upgrade", name} }
+func (yarnClient) OutdatedArgs() []string             { return []string{"outdated", "--json"} }
+func (yarnClient) RunArgs(script string) []string     { return []string{"run", script} }
+func (yarnClient) AuditArgs() []string                { return []string{"audit", "--json"} }
+func (yarnClient) AuditFixArgs() []string             { return nil }
+func (yarnClient) DedupeArgs() []string               { return nil }
+func (yarnClient) OverridesField() []string           { return []string{"resolutions"} }
+
+func (yarnClient) WorkspaceArgs(workspace string, args []string) []string {
+	// yarn workspace only takes names: packages without one run in their directory
+	if isPathSelector(workspace) {
+		return nil
+	}
+	return append([]string{"workspace", workspace}, args...)
+}
+
+func (yarnClient) ParseOutdated(output []byte) (map[string]OutdatedPackage, error) {
+	result := make(map[string]OutdatedPackage)
+
+	// The outdated report is the "table" line with rows of
+	// Package, Current, Wanted, Latest, Package Type, URL
+	for _, line := range strings.Split(string(output), "\n") {
+		var event struct {
+			Type string `json:"type"`
+			Data struct {
+				Body [][]string `json:"body"`
+			} `json:"data"`
+		}
+		if json.Unmarshal([]byte(line), &event) != nil || event.Type != "table" {
+			continue
+		}
+
+		for _, row := range event.Data.Body {
+			if len(row) < 4 {
+				continue
+			}
+			result[row[0]] = OutdatedPackage{
+				Name:    row[0],
+				Current: row[1],
+				Wanted:  row[2],
+				Latest:  row[3],
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// yarnBerryClient runs yarn 2 and later
+type yarnBerryClient struct{}
+
+func (yarnBerryClient) Name() string       { return ClientYarnBerry }
+func (yarnBerryClient) Binary() string     { return "yarn" }
+func (yarnBerryClient) ListArgs() []string { return []string{"info", "--json"} }
+func (yarnBerryClient) TreeArgs() []string { return []string{"info", "--recursive"} }
+
+func (yarnBerryClient) ParseList(output []byte) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	// Each line looks like {"value":"lodash@npm:4.17.21","children":{"Version":"4.17.21"}}
+	for _, line := range strings.Split(string(output), "\n") {
+		var entry struct {
+			Value    string `json:"value"`
+			Children struct {
+				Version string `json:"Version"`
+			} `json:"children"`
+		}
+		if json.Unmarshal([]byte(line), &entry) != nil || entry.Value == "" {
+			continue
+		}
+
+		name, reference := splitNameVersion(entry.Value)
+		if index := strings.Index(reference, ":"); index >= 0 {
+			reference = reference[index+1:]
+		}
+
+		version := entry.Children.Version
+		if version == "" {
+			version = reference
+		}
+		versions[name] = version
+	}
+
+	return versions, nil
+}
+
+func (yarnBerryClient) InstallArgs(name string, isDev bool) []string {
+	if isDev {
+		return []string{"add", name, "--dev"}
+	}
+	return []string{"add", name}
+}
+
+func (yarnBerryClient) AddArgs(name string, depType string) []string {
+	switch depType {
+	case TypeDevDependency:
+		return []string{"add", name, "--dev"}
+	case TypePeerDependency:
+		return []string{"add", name, "--peer"}
+	case TypeOptionalDependency:
+		return []string{"add", name, "--optional"}
+	}
+	return []string{"add", name}
+}
+
+func (yarnBerryClient) UninstallArgs(name string) []string { return []string{"remove", name} }
+func (yarnBerryClient) UpdateArgs(name string) []string    { return []string{"up", name} }
+func (yarnBerryClient) OutdatedArgs() []string             { return nil }
+func (yarnBerryClient) RunArgs(script string) []string     { return []string{"run", script} }
+func (yarnBerryClient) AuditArgs() []string                { return []string{"npm", "audit", "--json", "--recursive"} }
+func (yarnBerryClient) AuditFixArgs() []string             { return nil }
+func (yarnBerryClient) DedupeArgs() []string               { return []string{"dedupe"} }
+func (yarnBerryClient) OverridesField() []string           { return []string{"resolutions"} }
+
+func (yarnBerryClient) WorkspaceArgs(workspace string, args []string) []string {
+	// yarn workspace only takes names: packages without one run in their directory
+	if isPathSelector(workspace) {
+		return nil
+	}
+	return append([]string{"workspace", workspace}, args...)
+}
+
+func (yarnBerryClient) ParseOutdated(output []byte) (map[string]OutdatedPackage, error) {
+	return nil, fmt.Errorf("yarn berry has no outdated command")
+}
+
+// bunClient runs bun
+type bunClient struct{}
+
+func (bunClient) Name() string       { return ClientBun }
+func (bunClient) Binary() string     { return "bun" }
+func (bunClient) ListArgs() []string { return nil }
+func (bunClient) TreeArgs() []string { return []string{"pm", "ls", "--all"} }
+
+func (bunClient) ParseList(output []byte) (map[string]string, error) {
+	return nil, fmt.Errorf("bun has no JSON package listing")
+}
+
+func (bunClient) InstallArgs(name string, isDev bool) []string {
+	if isDev {
+		return []string{"add", name, "--dev"}
+	}
+	return []string{"add", name}
+}
+
+func (bunClient) AddArgs(name string, depType string) []string {
+	switch depType {
+	case TypeDevDependency:
+		return []string{"add", name, "--dev"}
+	case TypePeerDependency:
+		return []string{"add", name, "--peer"}
+	case TypeOptionalDependency:
+		return []string{"add", name, "--optional"}
+	}
+	return []string{"add", name}
+}
+
+func (bunClient) UninstallArgs(name string) []string { return []string{"remove", name} }
+func (bunClient) UpdateArgs(name string) []string    { return []string{"update", name} }
+func (bunClient) OutdatedArgs() []string             { return []string{"outdated"} }
+func (bunClient) RunArgs(script string) []string     { return []string{"run", script} }
+func (bunClient) AuditArgs() []string                { return []string{"audit", "--json"} }
+func (bunClient) AuditFixArgs() []string             { return nil }
+func (bunClient) DedupeArgs() []string               { return nil }
+func (bunClient) OverridesField() []string           { return []string{"overrides"} }
+
+func (bunClient) WorkspaceArgs(workspace string, args []string) []string {
+	// Only bun run takes --filter
+	if len(args) == 0 || args[0] != "run" {
+		return nil
+	}
+	return append([]string{"run", "--filter", workspace}, args[1:]...)
+}
+
+func (bunClient) ParseOutdated(output []byte) (map[string]OutdatedPackage, error) {
+	result := make(map[string]OutdatedPackage)
+
+	// bun prints a box-drawn table: Package | Current | Update | Latest
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.ReplaceAll(line, "│", "|")
+		if !strings.HasPrefix(strings.TrimSpace(line), "|") {
+			continue
+		}
+
+		var columns []string
+		for _, column := range strings.Split(line, "|") {
+			if column = strings.TrimSpace(column); column != "" {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) < 4 || columns[0] == "Package" {
+			continue
+		}
+
+		name := strings.TrimSpace(strings.TrimSuffix(columns[0], "(dev)"))
+		result[name] = OutdatedPackage{
+			Name:    name,
+			Current: columns[1],
+			Wanted:  columns[2],
+			Latest:  columns[3],
+		}
+	}
+
+	return result, nil
+}
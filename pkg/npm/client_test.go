@@ -0,0 +1,115 @@
+package npm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCommandInWorkspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    Client
+		workspace string
+		args      []string
+		dir       string
+	}{
+		{"npm", npmClient{}, "app", []string{"npm", "run", "build", "--workspace", "app"}, "/repo"},
+		{"npm without a name", npmClient{}, "./examples/a", []string{"npm", "run", "build", "--workspace", "./examples/a"}, "/repo"},
+		{"pnpm", pnpmClient{}, "app", []string{"pnpm", "--filter", "app", "run", "build"}, "/repo"},
+		{"yarn", yarnClient{}, "app", []string{"yarn", "workspace", "app", "run", "build"}, "/repo"},
+		// yarn workspace rejects paths: run from the package instead
+		{"yarn without a name", yarnClient{}, "./examples/a", []string{"yarn", "run", "build"}, "/repo/examples/a"},
+		{"yarn berry without a name", yarnBerryClient{}, "./examples/a", []string{"yarn", "run", "build"}, "/repo/examples/a"},
+		{"no workspace", yarnClient{}, "", []string{"yarn", "run", "build"}, "/repo/examples/a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := Command(tc.client, "/repo/examples/a", "/repo", tc.workspace, tc.client.RunArgs("build")...)
+			if !reflect.DeepEqual(cmd.Args, tc.args) {
+				t.Errorf("args = %q, want %q", cmd.Args, tc.args)
+			}
+			if cmd.Dir != tc.dir {
+				t.Errorf("dir = %s, want %s", cmd.Dir, tc.dir)
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		fixture string
+		client  Client
+		want    map[string]string
+	}{
+		{"yarn-classic-list.ndjson", yarnClient{}, map[string]string{
+			"@babel/core": "7.23.2",
+			"lodash":      "4.17.21",
+			"typescript":  "5.2.2",
+		}},
+		// Entries without a Version fall back to the reference
+		{"yarn-berry-info.ndjson", yarnBerryClient{}, map[string]string{
+			"@babel/core": "7.23.2",
+			"lodash":      "4.17.21",
+			"shared":      "packages/shared",
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tc.client.ParseList(output)
+			if err != nil {
+				t.Fatalf("ParseList: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseList = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseListWithoutTree(t *testing.T) {
+	output := []byte(`{"type":"error","data":"An unexpected error occurred"}`)
+	if _, err := (yarnClient{}).ParseList(output); err == nil {
+		t.Error("ParseList without a tree line succeeded")
+	}
+}
+
+func TestParseOutdated(t *testing.T) {
+	want := map[string]OutdatedPackage{
+		"@babel/core": {Name: "@babel/core", Current: "7.23.2", Wanted: "7.23.9", Latest: "7.23.9"},
+		"lodash":      {Name: "lodash", Current: "4.17.20", Wanted: "4.17.21", Latest: "4.17.21"},
+		"typescript":  {Name: "typescript", Current: "4.9.5", Wanted: "4.9.5", Latest: "5.3.3"},
+	}
+
+	tests := []struct {
+		fixture string
+		client  Client
+	}{
+		{"yarn-classic-outdated.ndjson", yarnClient{}},
+		// The (dev) marker is dropped from names and the box borders from columns
+		{"bun-outdated.txt", bunClient{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tc.client.ParseOutdated(output)
+			if err != nil {
+				t.Fatalf("ParseOutdated: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseOutdated = %v, want %v", got, want)
+			}
+		})
+	}
+}
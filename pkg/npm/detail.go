@@ -0,0 +1,164 @@
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/registry"
+)
+
+// InstalledManifest is the package.json of a package in node_modules
+type InstalledManifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Description          string            `json:"description"`
+	License              registry.License  `json:"license"`
+	Homepage             string            `json:"homepage"`
+	Repository           registry.Link     `json:"repository"`
+	Engines              map[string]string `json:"engines"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	PeerDependenciesMeta map[string]struct {
+		Optional bool `json:"optional"`
+	} `json:"peerDependenciesMeta"`
+	Bin registry.Bin `json:"bin"`
+	// Dir is the folder the package is installed in
+	Dir string `json:"-"`
+}
+
+// ReadInstalled reads the package.json of an installed top-level package.
+// Like Node, it looks in the node_modules of the project, then of the
+// directories above it up to the root of its monorepo, where dependencies
+// are usually hoisted.
+func (pm *PackageManager) ReadInstalled(name string) (*InstalledManifest, error) {
+	dir, data, err := pm.readInstalled(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest InstalledManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/package.json: %v", name, err)
+	}
+	manifest.Dir = dir
+
+	return &manifest, nil
+}
+
+// readInstalled finds the folder a package resolves to from the project
+// and reads its package.json. The error is the one of the project's own
+// node_modules.
+func (pm *PackageManager) readInstalled(name string) (string, []byte, error) {
+	current, err := filepath.Abs(pm.projectDir())
+	if err != nil {
+		return "", nil, err
+	}
+	root := current
+	if pm.RootDir != "" {
+		if root, err = filepath.Abs(pm.RootDir); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var firstErr error
+	for {
+		dir := filepath.Join(current, "node_modules", filepath.FromSlash(name))
+		data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+		if err == nil {
+			return dir, data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		parent := filepath.Dir(current)
+		if current == root || parent == current {
+			return "", nil, firstErr
+		}
+		current = parent
+	}
+}
+
+// Readme returns the README shipped with the installed package, or ""
+func (m *InstalledManifest) Readme() string {
+	entries, err := os.ReadDir(m.Dir)
+	if err != nil {
+		return ""
+	}
+
+	// README.md, readme.markdown, README...
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(strings.ToLower(entry.Name()), "readme") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.Dir, entry.Name()))
+		if err == nil {
+			return string(data)
+		}
+	}
+	return ""
+}
+
+// PackageDetail gathers what is known about a dependency: what package.json
+// asks for, what is installed and what the registry publishes
+type PackageDetail struct {
+	Name      string
+	Range     string // Declared in package.json
+	Type      string // Dependency type, e.g. TypeDevDependency
+	Installed *InstalledManifest
+	Packument *registry.Packument
+	// Readme comes from node_modules, or from the registry when not shipped
+	Readme string
+}
+
+// LocalDetail returns the detail available without the network
+func (pm *PackageManager) LocalDetail(name string) *PackageDetail {
+	pkg := pm.Packages[name]
+	detail := &PackageDetail{
+		Name:  name,
+		Range: pkg.Range,
+		Type:  pkg.Type,
+	}
+
+	if installed, err := pm.ReadInstalled(name); err == nil {
+		detail.Installed = installed
+		detail.Readme = installed.Readme()
+	}
+
+	return detail
+}
+
+// RegistryDetail adds the registry metadata to a detail
+func (pm *PackageManager) RegistryDetail(ctx context.Context, detail *PackageDetail) error {
+	packument, err := pm.Registry.Packument(ctx, detail.Name)
+	if err != nil {
+		return err
+	}
+
+	detail.Packument = packument
+	if detail.Readme == "" {
+		detail.Readme = packument.Readme
+	}
+	return nil
+}
+
+// Version returns the registry manifest of the installed version, or of
+// latest when the package isn't installed
+func (d *PackageDetail) Version() (registry.PackageVersion, bool) {
+	if d.Packument == nil {
+		return registry.PackageVersion{}, false
+	}
+
+	version := d.Packument.Latest()
+	if d.Installed != nil {
+		version = d.Installed.Version
+	}
+
+	manifest, ok := d.Packument.Versions[version]
+	return manifest, ok
+}
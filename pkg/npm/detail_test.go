@@ -0,0 +1,72 @@
+package npm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadInstalled(t *testing.T) {
+	// A monorepo with lodash hoisted to the root, react installed at the
+	// root and in the package, and zod above the monorepo
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	app := filepath.Join(root, "packages", "app")
+	for path, content := range map[string]string{
+		"node_modules/zod/package.json":                     `{"name": "zod", "version": "3.0.0"}`,
+		"repo/node_modules/lodash/package.json":             `{"name": "lodash", "version": "4.17.21"}`,
+		"repo/node_modules/lodash/README.md":                "# lodash",
+		"repo/node_modules/react/package.json":              `{"name": "react", "version": "17.0.2"}`,
+		"repo/node_modules/@scope/ui/package.json":          `{"name": "@scope/ui", "version": "1.0.0"}`,
+		"repo/packages/app/package.json":                    `{"name": "app"}`,
+		"repo/packages/app/node_modules/react/package.json": `{"name": "react", "version": "18.2.0"}`,
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rootDir string
+		version string // "" when not found
+		dir     string
+	}{
+		{name: "react", rootDir: root, version: "18.2.0", dir: filepath.Join(app, "node_modules", "react")},
+		{name: "lodash", rootDir: root, version: "4.17.21", dir: filepath.Join(root, "node_modules", "lodash")},
+		{name: "@scope/ui", rootDir: root, version: "1.0.0", dir: filepath.Join(root, "node_modules", "@scope", "ui")},
+		// Nothing above the monorepo
+		{name: "zod", rootDir: root},
+		{name: "missing", rootDir: root},
+		// Outside a monorepo only the project's own node_modules count
+		{name: "lodash"},
+	}
+
+	for _, tc := range tests {
+		pm := &PackageManager{PackageJSONPath: filepath.Join(app, "package.json"), RootDir: tc.rootDir}
+		installed, err := pm.ReadInstalled(tc.name)
+		if tc.version == "" {
+			if err == nil {
+				t.Errorf("ReadInstalled(%s) found %s in %s, want nothing", tc.name, installed.Version, installed.Dir)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ReadInstalled(%s): %v", tc.name, err)
+			continue
+		}
+		if installed.Version != tc.version || installed.Dir != tc.dir {
+			t.Errorf("ReadInstalled(%s) = %s in %s, want %s in %s", tc.name, installed.Version, installed.Dir, tc.version, tc.dir)
+		}
+	}
+
+	// The README of a hoisted package is found next to it
+	pm := &PackageManager{PackageJSONPath: filepath.Join(app, "package.json"), RootDir: root}
+	if detail := pm.LocalDetail("lodash"); detail.Readme != "# lodash" {
+		t.Errorf("lodash README = %q, want the hoisted one", detail.Readme)
+	}
+}
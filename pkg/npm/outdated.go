@@ -0,0 +1,213 @@
+package npm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/registry"
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+)
+
+// Bump types between two versions
+const (
+	BumpPatch = semver.Patch
+	BumpMinor = semver.Minor
+	BumpMajor = semver.Major
+)
+
+// BumpType tells whether going from one version to another is a patch,
+// minor or major bump. It returns "" when the versions can't be compared
+// or the target isn't newer.
+func BumpType(from, to string) string {
+	return semver.Classify(from, to)
+}
+
+// RangeFor keeps the operator of a declared range for a new version:
+// "^1.2.3" and 2.0.0 give "^2.0.0", an exact "1.2.3" gives "2.0.0". Ranges
+// whose bounds can't carry over, such as ">=1.2.3 <2.0.0" or "1.x || 2.x",
+// give "^2.0.0". It returns false for declarations that aren't a range,
+// such as dist-tags, aliases, protocols and URLs, which a version would
+// silently replace.
+func RangeFor(declared, version string) (string, bool) {
+	declared = strings.TrimSpace(declared)
+	switch {
+	case !semver.ValidRange(declared):
+		return "", false
+	case strings.ContainsAny(declared, " |"):
+		return "^" + version, true
+	case strings.HasPrefix(declared, "^"), strings.HasPrefix(declared, "~"):
+		return declared[:1] + version, true
+	}
+
+	if _, err := semver.Parse(strings.TrimPrefix(declared, "=")); err == nil {
+		return version, true
+	}
+	return "^" + version, true
+}
+
+// UpdateResult describes the update of one package
+type UpdateResult struct {
+	Name  string
+	From  string
+	To    string
+	Range string // New range written to package.json, when it changed
+	Err   error
+}
+
+// Changed reports whether the package moved to another version. To is
+// empty when the versions couldn't be read back, which is no change.
+func (r UpdateResult) Changed() bool {
+	return r.Err == nil && r.To != "" && r.From != r.To
+}
+
+// UpdatePackages updates the given outdated packages one by one, either
+// within their declared range (to wanted) or to the latest version, which
+// rewrites the range in package.json. The results follow the input order.
+// The error reports that the new versions couldn't be read back.
+func (pm *PackageManager) UpdatePackages(packages []OutdatedPackage, toLatest bool) ([]UpdateResult, error) {
+	description := fmt.Sprintf("Update %d packages", len(packages))
+	if len(packages) == 1 {
+		description = "Update " + packages[0].Name
+	}
+	if toLatest {
+		description += " to latest"
+	}
+
+	var results []UpdateResult
+	err := pm.History.Record(description, func() error {
+		var err error
+		results, err = pm.updatePackages(packages, toLatest)
+		return err
+	})
+	return results, err
+}
+
+// updatePackages runs the updates of UpdatePackages
+func (pm *PackageManager) updatePackages(packages []OutdatedPackage, toLatest bool) ([]UpdateResult, error) {
+	results := make([]UpdateResult, 0, len(packages))
+
+	for _, pkg := range packages {
+		result := UpdateResult{Name: pkg.Name, From: pkg.Current}
+
+		var args []string
+		if toLatest {
+			declared := pm.Packages[pkg.Name]
+			rng, ok := RangeFor(declared.Range, pkg.Latest)
+			if !ok {
+				// Leave aliases, protocols and tags alone
+				result.Err = fmt.Errorf("declared as %q, not a version range: update it by hand", declared.Range)
+				results = append(results, result)
+				continue
+			}
+			result.Range = rng
+			args = pm.Client.AddArgs(pkg.Name+"@"+result.Range, declared.Type)
+		} else {
+			args = pm.Client.UpdateArgs(pkg.Name)
+		}
+
+		output, err := pm.command(args...).CombinedOutput()
+		if err != nil {
+			result.Err = fmt.Errorf("%v - %s", err, strings.TrimSpace(string(output)))
+		}
+		results = append(results, result)
+	}
+
+	// Read the versions we ended up with
+	if err := pm.LoadPackages(); err != nil {
+		return results, fmt.Errorf("failed to read the updated versions: %v", err)
+	}
+	for i := range results {
+		if pkg, ok := pm.Packages[results[i].Name]; ok {
+			results[i].To = pkg.Version
+		}
+	}
+
+	return results, nil
+}
+
+// SortedOutdated returns the outdated packages ordered by name
+func SortedOutdated(outdated map[string]OutdatedPackage) []OutdatedPackage {
+	packages := make([]OutdatedPackage, 0, len(outdated))
+	for _, pkg := range outdated {
+		packages = append(packages, pkg)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+	return packages
+}
+
+// maxRegistryRequests limits the packuments fetched at the same time
+const maxRegistryRequests = 8
+
+// registryOutdated compares the installed versions with the registry:
+// wanted is the highest version the declared range allows, latest the
+// latest dist-tag. Dependencies that aren't registry ranges, such as git
+// URLs and workspace: or file: protocols, are skipped.
+func (pm *PackageManager) registryOutdated(ctx context.Context) (map[string]OutdatedPackage, error) {
+	var names []string
+	for name, pkg := range pm.Packages {
+		if semver.ValidRange(pkg.Range) {
+			names = append(names, name)
+		}
+	}
+
+	type answer struct {
+		name      string
+		packument *registry.Packument
+		err       error
+	}
+
+	// Fetch the packuments in parallel
+	answers := make(chan answer)
+	limit := make(chan struct{}, maxRegistryRequests)
+	for _, name := range names {
+		go func(name string) {
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			packument, err := pm.Registry.AbbreviatedPackument(ctx, name)
+			answers <- answer{name, packument, err}
+		}(name)
+	}
+
+	result := make(map[string]OutdatedPackage)
+	var firstErr error
+	failed := 0
+	for range names {
+		a := <-answers
+		if a.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = a.err
+			}
+			continue
+		}
+
+		// Without an installed version Version still holds the range
+		pkg := pm.Packages[a.name]
+		current := ""
+		if _, err := semver.Parse(pkg.Version); err == nil {
+			current = pkg.Version
+		}
+
+		info := OutdatedPackage{
+			Name:    a.name,
+			Current: current,
+			Wanted:  a.packument.MaxSatisfying(pkg.Range),
+			Latest:  a.packument.Latest(),
+		}
+		if info.Current == "" || semver.Compare(info.Current, info.Wanted) < 0 || semver.Compare(info.Current, info.Latest) < 0 {
+			result[a.name] = info
+		}
+	}
+
+	// Only give up when the registry can't be reached at all
+	if len(names) > 0 && failed == len(names) {
+		return nil, firstErr
+	}
+	return result, nil
+}
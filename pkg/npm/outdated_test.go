@@ -0,0 +1,76 @@
+package npm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRangeFor(t *testing.T) {
+	tests := []struct {
+		declared string
+		want     string // "" when the declaration can't be updated
+	}{
+		{"^1.2.3", "^2.0.0"},
+		{"~1.2.3", "~2.0.0"},
+		{" ^1.2.3 ", "^2.0.0"},
+		{"1.2.3", "2.0.0"},
+		{"=1.2.3", "2.0.0"},
+		// The upper bound of these can't be kept
+		{">=1.2.3", "^2.0.0"},
+		{">=1.2.3 <2.0.0", "^2.0.0"},
+		{"^1.2.3 || ^2.0.0-beta.1", "^2.0.0"},
+		{"1.2.3 - 1.9.0", "^2.0.0"},
+		{"1.x", "^2.0.0"},
+		{"*", "^2.0.0"},
+		// A version would replace what these point at
+		{"latest", ""},
+		{"next", ""},
+		{"workspace:*", ""},
+		{"workspace:^1.0.0", ""},
+		{"npm:string-width@^4.2.0", ""},
+		{"file:../lib", ""},
+		{"link:../lib", ""},
+		{"git+https://github.com/lodash/lodash.git#4.17.21", ""},
+		{"github:lodash/lodash", ""},
+		{"https://example.com/lodash-4.17.21.tgz", ""},
+	}
+	for _, tc := range tests {
+		got, ok := RangeFor(tc.declared, "2.0.0")
+		if ok != (tc.want != "") || got != tc.want {
+			t.Errorf("RangeFor(%q, 2.0.0) = %q, %v, want %q", tc.declared, got, ok, tc.want)
+		}
+	}
+}
+
+func TestUpdateResultChanged(t *testing.T) {
+	tests := []struct {
+		result UpdateResult
+		want   bool
+	}{
+		{UpdateResult{From: "1.0.0", To: "1.2.0"}, true},
+		{UpdateResult{From: "1.0.0", To: "1.0.0"}, false},
+		// The new version couldn't be read back
+		{UpdateResult{From: "1.0.0"}, false},
+		{UpdateResult{From: "1.0.0", To: "1.2.0", Err: errors.New("failed")}, false},
+	}
+
+	for _, tc := range tests {
+		if got := tc.result.Changed(); got != tc.want {
+			t.Errorf("%+v Changed() = %v, want %v", tc.result, got, tc.want)
+		}
+	}
+}
+
+func TestUpdatePackagesReloadError(t *testing.T) {
+	// The update leaves package.json unreadable
+	pm := newSectionsProject(t, sectionsPackageJSON, `echo '{' > package.json`)
+
+	results, err := pm.UpdatePackages([]OutdatedPackage{{Name: "a", Current: "1.2.3"}}, false)
+	if err == nil || !strings.Contains(err.Error(), "updated versions") {
+		t.Fatalf("UpdatePackages error = %v, want the reload error", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].To != "" || results[0].Changed() {
+		t.Errorf("UpdatePackages = %+v, want a updated to an unknown version, not changed", results)
+	}
+}
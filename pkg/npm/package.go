@@ -1,34 +1,94 @@
 package npm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/audit"
+	"github.com/VesperAkshay/lazynode/pkg/config"
+	"github.com/VesperAkshay/lazynode/pkg/history"
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/peers"
+	"github.com/VesperAkshay/lazynode/pkg/registry"
 )
 
 // Package represents an npm package
 type Package struct {
-	Name            string `json:"name"`
-	Version         string `json:"version"`
-	LatestVersion   string `json:"latestVersion,omitempty"`
-	Description     string `json:"description,omitempty"`
-	Type            string `json:"type"` // "dependency", "devDependency", "peerDependency", etc.
-	PackageJSONPath string `json:"-"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Range         string `json:"range,omitempty"` // Range declared in package.json
+	WantedVersion string `json:"wantedVersion,omitempty"`
+	LatestVersion string `json:"latestVersion,omitempty"`
+	Description   string `json:"description,omitempty"`
+	Type          string `json:"type"` // "dependency", "devDependency", "peerDependency", etc.
+	// Sections lists every type the package is declared as, e.g. a peer
+	// dependency that is also a dev dependency
+	Sections        []string `json:"sections,omitempty"`
+	Bundled         bool     `json:"bundled,omitempty"`
+	OptionalPeer    bool     `json:"optionalPeer,omitempty"` // Marked optional in peerDependenciesMeta
+	PackageJSONPath string   `json:"-"`
+}
+
+// In reports whether the package is declared with the given type
+func (p Package) In(depType string) bool {
+	if depType == TypeBundleDependency {
+		return p.Bundled
+	}
+	for _, section := range p.Sections {
+		if section == depType {
+			return true
+		}
+	}
+	return false
 }
 
-// PackageManager handles npm package operations
+// PackageManager handles package operations through the project's client
 type PackageManager struct {
 	PackageJSONPath string
 	Packages        map[string]Package
+	Overrides       []Override // Declared in overrides, resolutions and pnpm.overrides
+	Client          Client
+	Graph           *lockfile.Graph  // Resolved dependency graph, nil without a lockfile
+	Registry        *registry.Client // Talks to the registry configured in .npmrc
+	LastAudit       *audit.Report    // Result of the last security audit, nil before one ran
+	// LastInstallIssues are the peer dependency problems reported by the last install
+	LastInstallIssues []peers.Issue
+	// History records package.json and the lockfile around every change, for undo
+	History *history.Journal
+	// Workspace names the project within its monorepo, whose root is
+	// RootDir. Commands then run from the root with the workspace flag.
+	// Both are empty outside a monorepo and at its root.
+	Workspace string
+	RootDir   string
 }
 
 // NewPackageManager creates a new package manager for the given project
 func NewPackageManager(packageJSONPath string) (*PackageManager, error) {
+	// Pick npm, pnpm, yarn or bun for this project
+	client, err := DetectClient(packageJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(filepath.Dir(packageJSONPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
 	pm := &PackageManager{
 		PackageJSONPath: packageJSONPath,
 		Packages:        make(map[string]Package),
+		Client:          client,
+		Registry:        registry.NewProjectClient(filepath.Dir(packageJSONPath)),
+		History:         history.Open(filepath.Dir(packageJSONPath)),
+	}
+	if cfg.Offline {
+		pm.Registry.Offline = true
 	}
 
 	// Load the initial packages
@@ -44,66 +104,164 @@ func (pm *PackageManager) LoadPackages() error {
 	// Clear existing packages
 	pm.Packages = make(map[string]Package)
 
-	// First try to use npm list
-	if err := pm.loadPackagesFromNpmList(); err != nil {
-		// If that fails, fall back to reading package.json directly
-		return pm.loadPackagesFromPackageJSON()
+	// Start from the ranges declared in package.json
+	if err := pm.loadPackagesFromPackageJSON(); err != nil {
+		return err
+	}
+
+	// Describe the installed packages with their own package.json
+	for name, pkg := range pm.Packages {
+		if installed, err := pm.ReadInstalled(name); err == nil {
+			pkg.Description = installed.Description
+			pm.Packages[name] = pkg
+		}
+	}
+
+	// Then replace them with the exact versions from the lockfile,
+	// or the installed versions reported by the client without one
+	versions, err := pm.lockedVersions()
+	if err != nil {
+		versions, err = pm.listInstalledVersions()
+	}
+	if err != nil {
+		// Keep the declared ranges if the client can't tell us more
+		return nil
+	}
+
+	for name, pkg := range pm.Packages {
+		if version, ok := versions[name]; ok && version != "" {
+			pkg.Version = version
+			pm.Packages[name] = pkg
+		}
 	}
 
 	return nil
 }
 
-// loadPackagesFromNpmList uses the npm list command to get package information
-func (pm *PackageManager) loadPackagesFromNpmList() error {
-	// Read package.json
-	cmd := exec.Command("npm", "list", "--json", "--depth=0")
-	cmd.Dir = strings.TrimSuffix(pm.PackageJSONPath, "package.json")
+// lockedVersions reads the resolved version of each dependency from the lockfile.
+// Packages missing from the lockfile fall back to node_modules.
+func (pm *PackageManager) lockedVersions() (map[string]string, error) {
+	graph, err := lockfile.Load(pm.projectDir())
+	if err != nil {
+		pm.Graph = nil
+		return nil, err
+	}
+	pm.Graph = graph
 
-	output, err := cmd.Output()
+	importer := graph.Importer(pm.projectDir())
+	if importer == nil {
+		return nil, fmt.Errorf("%s does not list %s", filepath.Base(graph.Path), pm.projectDir())
+	}
+
+	versions := importer.Versions()
+	for name, version := range pm.readNodeModulesVersions() {
+		if _, ok := versions[name]; !ok {
+			versions[name] = version
+		}
+	}
+
+	return versions, nil
+}
+
+// listInstalledVersions asks the client for the installed top-level packages.
+// Clients without a JSON listing fall back to reading node_modules.
+func (pm *PackageManager) listInstalledVersions() (map[string]string, error) {
+	args := pm.Client.ListArgs()
+	if args == nil {
+		return pm.readNodeModulesVersions(), nil
+	}
+
+	output, err := pm.command(args...).Output()
 	if err != nil {
-		// npm list might return non-zero exit code but still provide useful output
+		// list commands may return a non-zero exit code but still provide useful output
 		if len(output) == 0 {
-			return fmt.Errorf("npm list error: %v", err)
+			return nil, fmt.Errorf("%s list error: %v", pm.Client.Name(), err)
 		}
 	}
 
-	// Parse the output
-	var result struct {
-		Dependencies map[string]struct {
-			Version string `json:"version"`
-			From    string `json:"from"`
-		} `json:"dependencies"`
-		DevDependencies map[string]struct {
+	return pm.Client.ParseList(output)
+}
+
+// readNodeModulesVersions reads the installed version of each package from node_modules
+func (pm *PackageManager) readNodeModulesVersions() map[string]string {
+	versions := make(map[string]string)
+
+	for name := range pm.Packages {
+		data, err := os.ReadFile(filepath.Join(pm.projectDir(), "node_modules", name, "package.json"))
+		if err != nil {
+			continue
+		}
+
+		var manifest struct {
 			Version string `json:"version"`
-			From    string `json:"from"`
-		} `json:"devDependencies"`
+		}
+		if json.Unmarshal(data, &manifest) == nil {
+			versions[name] = manifest.Version
+		}
 	}
 
-	if err := json.Unmarshal(output, &result); err != nil {
-		return err
+	return versions
+}
+
+// ReadNodeModules returns the name and version of every package installed
+// at the top level of node_modules, scoped packages included
+func (pm *PackageManager) ReadNodeModules() map[string]string {
+	versions := make(map[string]string)
+	nodeModules := filepath.Join(pm.projectDir(), "node_modules")
+
+	entries, err := os.ReadDir(nodeModules)
+	if err != nil {
+		return versions
 	}
 
-	// Process dependencies
-	for name, info := range result.Dependencies {
-		pm.Packages[name] = Package{
-			Name:            name,
-			Version:         info.Version,
-			Type:            "dependency",
-			PackageJSONPath: pm.PackageJSONPath,
+	// Collect the package folders, looking inside @scope folders
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if strings.HasPrefix(name, "@") {
+			scoped, err := os.ReadDir(filepath.Join(nodeModules, name))
+			if err != nil {
+				continue
+			}
+			for _, s := range scoped {
+				names = append(names, name+"/"+s.Name())
+			}
+			continue
 		}
+
+		names = append(names, name)
 	}
 
-	// Process dev dependencies
-	for name, info := range result.DevDependencies {
-		pm.Packages[name] = Package{
-			Name:            name,
-			Version:         info.Version,
-			Type:            "devDependency",
-			PackageJSONPath: pm.PackageJSONPath,
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(nodeModules, name, "package.json"))
+		if err != nil {
+			continue
+		}
+
+		var manifest struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &manifest) == nil {
+			versions[name] = manifest.Version
 		}
 	}
 
-	return nil
+	return versions
+}
+
+// projectDir returns the directory containing package.json
+func (pm *PackageManager) projectDir() string {
+	return filepath.Dir(pm.PackageJSONPath)
+}
+
+// command creates a client command for the project, run from the root of
+// its monorepo with the workspace flag when it's a workspace package
+func (pm *PackageManager) command(args ...string) *exec.Cmd {
+	return Command(pm.Client, pm.projectDir(), pm.RootDir, pm.Workspace, args...)
 }
 
 // loadPackagesFromPackageJSON reads package.json directly to get package information
@@ -115,146 +273,163 @@ func (pm *PackageManager) loadPackagesFromPackageJSON() error {
 	}
 
 	// Parse the JSON content
-	var packageJSON struct {
-		Dependencies    map[string]string `json:"dependencies"`
-		DevDependencies map[string]string `json:"devDependencies"`
-	}
-
+	var packageJSON packageJSONSections
 	if err := json.Unmarshal(packageJSONContent, &packageJSON); err != nil {
 		return fmt.Errorf("failed to parse package.json: %v", err)
 	}
 
-	// Process dependencies
-	for name, version := range packageJSON.Dependencies {
-		pm.Packages[name] = Package{
-			Name:            name,
-			Version:         version,
-			Type:            "dependency",
-			PackageJSONPath: pm.PackageJSONPath,
+	// Process every section; the first one a package appears in gives its type and range
+	for _, depType := range DependencyTypes {
+		for name, version := range packageJSON.section(depType) {
+			pkg, ok := pm.Packages[name]
+			if !ok {
+				pkg = Package{
+					Name:            name,
+					Version:         version,
+					Range:           version,
+					Type:            depType,
+					PackageJSONPath: pm.PackageJSONPath,
+				}
+			}
+			pkg.Sections = append(pkg.Sections, depType)
+			pm.Packages[name] = pkg
 		}
 	}
 
-	// Process dev dependencies
-	for name, version := range packageJSON.DevDependencies {
-		pm.Packages[name] = Package{
-			Name:            name,
-			Version:         version,
-			Type:            "devDependency",
-			PackageJSONPath: pm.PackageJSONPath,
+	// Mark optional peers and bundled dependencies
+	for name, meta := range packageJSON.PeerDependenciesMeta {
+		if pkg, ok := pm.Packages[name]; ok && meta.Optional {
+			pkg.OptionalPeer = true
+			pm.Packages[name] = pkg
 		}
 	}
+	for name := range packageJSON.bundled() {
+		if pkg, ok := pm.Packages[name]; ok {
+			pkg.Bundled = true
+			pm.Packages[name] = pkg
+		}
+	}
+
+	// Process overrides
+	pm.Overrides = parseOverrides(packageJSONContent)
 
 	return nil
 }
 
 // InstallPackage installs a new package
 func (pm *PackageManager) InstallPackage(name string, isDev bool) error {
-	args := []string{"install", name}
-	if isDev {
-		args = append(args, "--save-dev")
-	} else {
-		args = append(args, "--save")
-	}
-
-	// Set the working directory to the same directory as package.json
-	cmd := exec.Command("npm", args...)
-	cmd.Dir = strings.TrimSuffix(pm.PackageJSONPath, "package.json")
+	return pm.History.Record("Install "+name, func() error {
+		cmd := pm.command(pm.Client.InstallArgs(name, isDev)...)
 
-	// Capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("npm install error: %v - %s", err, string(output))
-	}
+		// Capture both stdout and stderr
+		output, err := cmd.CombinedOutput()
+		if err := pm.installResult(output, err); err != nil {
+			return err
+		}
 
-	// Reload packages after installing
-	return pm.LoadPackages()
+		// Reload packages after installing
+		return pm.LoadPackages()
+	})
 }
 
 // UninstallPackage removes a package
 func (pm *PackageManager) UninstallPackage(name string) error {
-	cmd := exec.Command("npm", "uninstall", name)
-	cmd.Dir = strings.TrimSuffix(pm.PackageJSONPath, "package.json")
+	return pm.History.Record("Uninstall "+name, func() error {
+		cmd := pm.command(pm.Client.UninstallArgs(name)...)
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("npm uninstall error: %v - %s", err, string(output))
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s uninstall error: %v - %s", pm.Client.Name(), err, string(output))
+		}
+
+		// Reload packages after uninstalling
+		return pm.LoadPackages()
+	})
+}
+
+// Reinstall installs what package.json and the lockfile describe, to bring
+// node_modules back in line after they were restored from the history
+func (pm *PackageManager) Reinstall() error {
+	output, err := pm.command("install").CombinedOutput()
+	if err := pm.installResult(output, err); err != nil {
+		return err
 	}
 
-	// Reload packages after uninstalling
 	return pm.LoadPackages()
 }
 
-// CheckOutdatedPackages checks for outdated packages
-func (pm *PackageManager) CheckOutdatedPackages() (map[string]string, error) {
-	cmd := exec.Command("npm", "outdated", "--json")
-	output, err := cmd.Output()
+// CheckOutdatedPackages checks for outdated packages and returns them by name.
+// The registry is asked directly; the client's outdated command is the fallback.
+func (pm *PackageManager) CheckOutdatedPackages() (map[string]OutdatedPackage, error) {
+	outdated, err := pm.registryOutdated(context.Background())
 	if err != nil {
-		// npm outdated returns a non-zero exit code if outdated packages are found
-		// so we need to check if we got any output
-		if len(output) == 0 {
-			return nil, err
-		}
+		outdated, err = pm.clientOutdated()
 	}
-
-	// Parse the output
-	var outdated map[string]struct {
-		Current  string `json:"current"`
-		Latest   string `json:"latest"`
-		Wanted   string `json:"wanted"`
-		Location string `json:"location"`
-	}
-
-	if err := json.Unmarshal(output, &outdated); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	// Create a map of package name to latest version
-	result := make(map[string]string)
 	for name, info := range outdated {
-		result[name] = info.Latest
 		// Update the package in our cache
 		if pkg, ok := pm.Packages[name]; ok {
+			pkg.WantedVersion = info.Wanted
 			pkg.LatestVersion = info.Latest
 			pm.Packages[name] = pkg
+			info.Type = pkg.Type
+		}
+		outdated[name] = info
+	}
+
+	return outdated, nil
+}
+
+// clientOutdated runs the client's outdated command
+func (pm *PackageManager) clientOutdated() (map[string]OutdatedPackage, error) {
+	args := pm.Client.OutdatedArgs()
+	if args == nil {
+		return nil, fmt.Errorf("%s cannot check for outdated packages", pm.Client.Name())
+	}
+
+	output, err := pm.command(args...).Output()
+	if err != nil {
+		// outdated commands return a non-zero exit code if outdated packages are found
+		// so we need to check if we got any output
+		if len(output) == 0 {
+			return nil, err
 		}
 	}
 
-	return result, nil
+	// Parse the output
+	return pm.Client.ParseOutdated(output)
 }
 
-// UpdatePackage updates a package to the latest version
+// UpdatePackage updates a package within its declared range
 func (pm *PackageManager) UpdatePackage(name string) error {
-	cmd := exec.Command("npm", "update", name)
-	cmd.Dir = strings.TrimSuffix(pm.PackageJSONPath, "package.json")
+	return pm.History.Record("Update "+name, func() error {
+		return pm.updatePackage(name)
+	})
+}
+
+// updatePackage runs UpdatePackage, without recording it
+func (pm *PackageManager) updatePackage(name string) error {
+	cmd := pm.command(pm.Client.UpdateArgs(name)...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("npm update error: %v - %s", err, string(output))
+		return fmt.Errorf("%s update error: %v - %s", pm.Client.Name(), err, string(output))
 	}
 
 	// Reload packages after updating
 	return pm.LoadPackages()
 }
 
-// SearchPackage searches for packages on npm registry
-func SearchPackage(query string) ([]Package, error) {
-	cmd := exec.Command("npm", "search", query, "--json")
-	output, err := cmd.Output()
+// SearchPackage searches for packages on the project's registry
+func (pm *PackageManager) SearchPackage(query string) ([]Package, error) {
+	searchResults, err := pm.Registry.Search(context.Background(), query, 20)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the output
-	var searchResults []struct {
-		Name        string `json:"name"`
-		Version     string `json:"version"`
-		Description string `json:"description"`
-	}
-
-	if err := json.Unmarshal(output, &searchResults); err != nil {
-		return nil, err
-	}
-
 	// Convert to our Package type
 	var packages []Package
 	for _, result := range searchResults {
@@ -270,12 +445,11 @@ func SearchPackage(query string) ([]Package, error) {
 
 // GetDependencyTree returns the dependency tree
 func (pm *PackageManager) GetDependencyTree() (string, error) {
-	cmd := exec.Command("npm", "list", "--depth=1")
-	cmd.Dir = strings.TrimSuffix(pm.PackageJSONPath, "package.json")
+	cmd := pm.command(pm.Client.TreeArgs()...)
 
 	output, err := cmd.Output()
 	if err != nil {
-		// npm list may return a non-zero exit code for some issues
+		// list commands may return a non-zero exit code for some issues
 		// but we still want to display the output
 		if len(output) == 0 {
 			return "", err
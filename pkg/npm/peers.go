@@ -0,0 +1,60 @@
+package npm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/VesperAkshay/lazynode/pkg/peers"
+)
+
+// CheckPeers evaluates the peer dependencies of every resolved package and
+// asks the registry for versions that would fix the problems found
+func (pm *PackageManager) CheckPeers(ctx context.Context) ([]peers.Issue, error) {
+	if pm.Graph == nil {
+		return nil, fmt.Errorf("no lockfile found - install dependencies to check peer dependencies")
+	}
+
+	issues := peers.Check(pm.Graph)
+	pm.SuggestPeers(ctx, issues)
+	return issues, nil
+}
+
+// SuggestPeers fills in the suggestions of the issues from the registry.
+// Issues the registry can't help with are left as they are.
+func (pm *PackageManager) SuggestPeers(ctx context.Context, issues []peers.Issue) {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, maxRegistryRequests)
+
+	for i := range issues {
+		// Fill in the installed version when the install output didn't say
+		if issues[i].Installed == "" {
+			if pkg, ok := pm.Packages[issues[i].Name]; ok && pkg.Version != pkg.Range {
+				issues[i].Installed = pkg.Version
+			}
+		}
+
+		wg.Add(1)
+		go func(issue *peers.Issue) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			// Suggestions read from the install output are kept either way
+			peers.Suggest(ctx, pm.Registry, issue)
+		}(&issues[i])
+	}
+
+	wg.Wait()
+	peers.Sort(issues)
+}
+
+// installResult keeps the peer dependency problems described by the output
+// of an install, failed or not, and builds the error of a failed one
+func (pm *PackageManager) installResult(output []byte, err error) error {
+	pm.LastInstallIssues = peers.ParseInstallOutput(string(output))
+	if err != nil {
+		return fmt.Errorf("%s install error: %v - %s", pm.Client.Name(), err, string(output))
+	}
+	return nil
+}
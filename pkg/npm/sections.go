@@ -0,0 +1,404 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+)
+
+// Dependency types. Each of the first four is a section of package.json;
+// bundled dependencies are dependencies also listed in bundleDependencies,
+// and overrides force versions of transitive dependencies.
+const (
+	TypeDependency         = "dependency"
+	TypeDevDependency      = "devDependency"
+	TypePeerDependency     = "peerDependency"
+	TypeOptionalDependency = "optionalDependency"
+	TypeBundleDependency   = "bundleDependency"
+	TypeOverride           = "override"
+)
+
+// DependencyTypes lists the sections in the order a package declared in
+// several of them takes its type from
+var DependencyTypes = []string{TypeDependency, TypeDevDependency, TypeOptionalDependency, TypePeerDependency}
+
+// SectionField returns the package.json field of a dependency type
+func SectionField(depType string) string {
+	switch depType {
+	case TypeDependency:
+		return "dependencies"
+	case TypeDevDependency:
+		return "devDependencies"
+	case TypePeerDependency:
+		return "peerDependencies"
+	case TypeOptionalDependency:
+		return "optionalDependencies"
+	case TypeBundleDependency:
+		return "bundleDependencies"
+	}
+	return ""
+}
+
+// Override forces the version of a package anywhere in the tree
+type Override struct {
+	Name  string // Package the override applies to; nested npm overrides read "parent > name"
+	Range string
+	Field string // package.json field declaring it, e.g. "pnpm.overrides"
+}
+
+// overrideFields are the package.json fields holding overrides, for every client
+var overrideFields = [][]string{{"overrides"}, {"resolutions"}, {"pnpm", "overrides"}}
+
+// packageJSONSections is the part of package.json describing dependencies
+type packageJSONSections struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependenciesMeta map[string]struct {
+		Optional bool `json:"optional"`
+	} `json:"peerDependenciesMeta"`
+	BundleDependencies  json.RawMessage `json:"bundleDependencies"`
+	BundledDependencies json.RawMessage `json:"bundledDependencies"`
+}
+
+// section returns the declared ranges of a dependency type
+func (s *packageJSONSections) section(depType string) map[string]string {
+	switch depType {
+	case TypeDependency:
+		return s.Dependencies
+	case TypeDevDependency:
+		return s.DevDependencies
+	case TypePeerDependency:
+		return s.PeerDependencies
+	case TypeOptionalDependency:
+		return s.OptionalDependencies
+	}
+	return nil
+}
+
+// bundled returns the names listed in bundleDependencies (or its
+// bundledDependencies spelling). true bundles every dependency.
+func (s *packageJSONSections) bundled() map[string]bool {
+	names := make(map[string]bool)
+
+	for _, raw := range []json.RawMessage{s.BundleDependencies, s.BundledDependencies} {
+		var all bool
+		if json.Unmarshal(raw, &all) == nil && all {
+			for name := range s.Dependencies {
+				names[name] = true
+			}
+			continue
+		}
+
+		var list []string
+		if json.Unmarshal(raw, &list) == nil {
+			for _, name := range list {
+				names[name] = true
+			}
+		}
+	}
+
+	return names
+}
+
+// parseOverrides reads the overrides, resolutions and pnpm.overrides fields
+func parseOverrides(data []byte) []Override {
+	var packageJSON map[string]interface{}
+	if json.Unmarshal(data, &packageJSON) != nil {
+		return nil
+	}
+
+	var overrides []Override
+	for _, field := range overrideFields {
+		value := lookupField(packageJSON, field)
+		if value == nil {
+			continue
+		}
+		overrides = flattenOverrides(overrides, value, "", strings.Join(field, "."))
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Name != overrides[j].Name {
+			return overrides[i].Name < overrides[j].Name
+		}
+		return overrides[i].Field < overrides[j].Field
+	})
+	return overrides
+}
+
+// flattenOverrides adds the overrides of an object. npm nests overrides
+// under the package they apply within, "." being the parent itself.
+func flattenOverrides(overrides []Override, value map[string]interface{}, parent, field string) []Override {
+	for key, child := range value {
+		name := key
+		if parent != "" {
+			name = parent + " > " + key
+		}
+		if key == "." {
+			name = parent
+		}
+
+		switch child := child.(type) {
+		case string:
+			overrides = append(overrides, Override{Name: name, Range: child, Field: field})
+		case map[string]interface{}:
+			overrides = flattenOverrides(overrides, child, name, field)
+		}
+	}
+	return overrides
+}
+
+// lookupField walks down nested objects, returning nil when a key is missing
+func lookupField(object map[string]interface{}, path []string) map[string]interface{} {
+	for _, key := range path {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = child
+	}
+	return object
+}
+
+// editPackageJSON loads package.json, lets edit change it in place and
+// writes it back with its formatting intact
+func (pm *PackageManager) editPackageJSON(edit func(doc *packagejson.Document) error) error {
+	doc, err := packagejson.Load(pm.PackageJSONPath)
+	if err != nil {
+		return err
+	}
+
+	if err := edit(doc); err != nil {
+		return err
+	}
+
+	return doc.Save(pm.PackageJSONPath)
+}
+
+// AddToSection adds a package ("name" or "name@range") to the section of a
+// dependency type. Bundling installs it as a dependency first; an override
+// needs a range and is only written to package.json.
+func (pm *PackageManager) AddToSection(spec, depType string) error {
+	return pm.History.Record(fmt.Sprintf("Add %s as %s", spec, depType), func() error {
+		return pm.addToSection(spec, depType)
+	})
+}
+
+// addToSection runs AddToSection, without recording it
+func (pm *PackageManager) addToSection(spec, depType string) error {
+	switch depType {
+	case TypeOverride:
+		name, rng := splitNameVersion(spec)
+		if rng == "" {
+			return fmt.Errorf("an override needs a version, e.g. %s@1.2.3", name)
+		}
+		if err := pm.setOverride(name, rng); err != nil {
+			return err
+		}
+		return pm.LoadPackages()
+
+	case TypeBundleDependency:
+		if err := pm.addToSection(spec, TypeDependency); err != nil {
+			return err
+		}
+		name, _ := splitNameVersion(spec)
+		return pm.setBundled(name, true)
+	}
+
+	output, err := pm.command(pm.Client.AddArgs(spec, depType)...).CombinedOutput()
+	if err := pm.installResult(output, err); err != nil {
+		return err
+	}
+
+	// Reload packages after installing
+	return pm.LoadPackages()
+}
+
+// MoveToSection moves a dependency to the section of another type. Only
+// this package is installed again, with its range, so that the lockfile
+// follows; the rest of node_modules is left alone. The client writes the new
+// entry with its save prefix, so ^1.2.3 resolved to 1.4.0 may become ^1.4.0.
+// Moving to TypeBundleDependency toggles bundling instead.
+func (pm *PackageManager) MoveToSection(name, depType string) error {
+	return pm.History.Record(fmt.Sprintf("Move %s to %s", name, depType), func() error {
+		pkg, ok := pm.Packages[name]
+		if !ok {
+			return fmt.Errorf("%s is not a dependency", name)
+		}
+		if depType == TypeBundleDependency {
+			return pm.setBundled(name, !pkg.Bundled)
+		}
+		if pkg.Type == depType {
+			return fmt.Errorf("%s is already a %s", name, depType)
+		}
+
+		// Keep the original to put it back if the client fails
+		original, err := os.ReadFile(pm.PackageJSONPath)
+		if err != nil {
+			return fmt.Errorf("failed to read package.json: %v", err)
+		}
+
+		// Take it out of the old section, and out of the bundle when it can't stay bundled
+		err = pm.editPackageJSON(func(doc *packagejson.Document) error {
+			if err := removeFromSection(doc, SectionField(pkg.Type), name); err != nil {
+				return err
+			}
+			if depType != TypeDependency && depType != TypeOptionalDependency {
+				return removeFromBundle(doc, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Let the client add it to the new section and update the lockfile
+		spec := name
+		if pkg.Range != "" {
+			spec += "@" + pkg.Range
+		}
+		output, err := pm.command(pm.Client.AddArgs(spec, depType)...).CombinedOutput()
+		if err := pm.installResult(output, err); err != nil {
+			if restoreErr := packagejson.WriteFile(pm.PackageJSONPath, original); restoreErr != nil {
+				return fmt.Errorf("%v - and package.json could not be restored, %s is missing from it: %v", err, name, restoreErr)
+			}
+			return err
+		}
+
+		return pm.LoadPackages()
+	})
+}
+
+// SetBundled adds or removes a dependency from bundleDependencies
+func (pm *PackageManager) SetBundled(name string, bundled bool) error {
+	description := "Bundle " + name
+	if !bundled {
+		description = "Unbundle " + name
+	}
+	return pm.History.Record(description, func() error {
+		return pm.setBundled(name, bundled)
+	})
+}
+
+// setBundled edits bundleDependencies
+func (pm *PackageManager) setBundled(name string, bundled bool) error {
+	pkg, ok := pm.Packages[name]
+	if !ok {
+		return fmt.Errorf("%s is not a dependency", name)
+	}
+	if bundled && pkg.Type != TypeDependency && pkg.Type != TypeOptionalDependency {
+		return fmt.Errorf("only dependencies and optional dependencies can be bundled")
+	}
+
+	err := pm.editPackageJSON(func(doc *packagejson.Document) error {
+		if !bundled {
+			return removeFromBundle(doc, name)
+		}
+
+		// Keep the spelling already used
+		field := "bundleDependencies"
+		if _, ok := doc.Get("bundledDependencies"); ok {
+			field = "bundledDependencies"
+		}
+
+		var list []interface{}
+		if raw, ok := doc.Get(field); ok {
+			json.Unmarshal(raw, &list)
+		}
+		return doc.Set([]string{field}, append(list, name))
+	})
+	if err != nil {
+		return err
+	}
+
+	return pm.LoadPackages()
+}
+
+// RemoveOverride deletes an override from the field declaring it
+func (pm *PackageManager) RemoveOverride(override Override) error {
+	return pm.History.Record("Remove override "+override.Name, func() error {
+		err := pm.editPackageJSON(func(doc *packagejson.Document) error {
+			// Walk down nested npm overrides
+			path := append(strings.Split(override.Field, "."), strings.Split(override.Name, " > ")...)
+			raw, ok := doc.Get(path...)
+			if !ok {
+				return fmt.Errorf("%s has no override for %s", override.Field, override.Name)
+			}
+
+			if strings.HasPrefix(string(raw), "{") {
+				path = append(path, ".") // The parent's own version
+			}
+			return doc.Delete(path...)
+		})
+		if err != nil {
+			return err
+		}
+
+		return pm.LoadPackages()
+	})
+}
+
+// removeFromSection deletes a name from a dependency section
+func removeFromSection(doc *packagejson.Document, field, name string) error {
+	if err := doc.Delete(field, name); err != nil {
+		return err
+	}
+	if field == "peerDependencies" {
+		return doc.Delete("peerDependenciesMeta", name)
+	}
+	return nil
+}
+
+// removeFromBundle deletes a name from bundleDependencies. A true value
+// becomes the list of the other dependencies.
+func removeFromBundle(doc *packagejson.Document, name string) error {
+	for _, field := range []string{"bundleDependencies", "bundledDependencies"} {
+		raw, ok := doc.Get(field)
+		if !ok {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+
+		if all, ok := value.(bool); ok {
+			if !all {
+				continue
+			}
+			var dependencies map[string]interface{}
+			if raw, ok := doc.Get("dependencies"); ok {
+				json.Unmarshal(raw, &dependencies)
+			}
+			list := []string{}
+			for other := range dependencies {
+				if other != name {
+					list = append(list, other)
+				}
+			}
+			sort.Strings(list)
+			if err := doc.Set([]string{field}, list); err != nil {
+				return err
+			}
+			continue
+		}
+
+		list, _ := value.([]interface{})
+		kept := []interface{}{}
+		for _, other := range list {
+			if other != name {
+				kept = append(kept, other)
+			}
+		}
+		if err := doc.Set([]string{field}, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
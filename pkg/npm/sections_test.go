@@ -0,0 +1,354 @@
+package npm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBundled(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		want        []string
+	}{
+		{"list", `{"bundleDependencies": ["a"]}`, []string{"a"}},
+		{"other spelling", `{"bundledDependencies": ["b"]}`, []string{"b"}},
+		{"both spellings", `{"bundleDependencies": ["a"], "bundledDependencies": ["b"]}`, []string{"a", "b"}},
+		{"true bundles every dependency", `{"dependencies": {"a": "^1.0.0", "b": "^2.0.0"}, "devDependencies": {"c": "^3.0.0"}, "bundleDependencies": true}`, []string{"a", "b"}},
+		{"true and a list", `{"dependencies": {"a": "^1.0.0"}, "bundleDependencies": true, "bundledDependencies": ["b"]}`, []string{"a", "b"}},
+		{"false", `{"dependencies": {"a": "^1.0.0"}, "bundleDependencies": false}`, nil},
+		{"missing", `{"dependencies": {"a": "^1.0.0"}}`, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var sections packageJSONSections
+			if err := json.Unmarshal([]byte(tc.packageJSON), &sections); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for name := range sections.bundled() {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("bundled() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		want        []Override
+	}{
+		{
+			name:        "npm",
+			packageJSON: `{"overrides": {"foo": "1.0.0", "@scope/pkg": "^2.0.0"}}`,
+			want: []Override{
+				{Name: "@scope/pkg", Range: "^2.0.0", Field: "overrides"},
+				{Name: "foo", Range: "1.0.0", Field: "overrides"},
+			},
+		},
+		{
+			// "." is the version of the parent itself
+			name:        "nested npm",
+			packageJSON: `{"overrides": {"bar": {".": "2.0.0", "baz": "3.0.0"}, "a": {"b": {"c": "4.0.0"}}}}`,
+			want: []Override{
+				{Name: "a > b > c", Range: "4.0.0", Field: "overrides"},
+				{Name: "bar", Range: "2.0.0", Field: "overrides"},
+				{Name: "bar > baz", Range: "3.0.0", Field: "overrides"},
+			},
+		},
+		{
+			name:        "every client",
+			packageJSON: `{"overrides": {"lodash": "4.17.21"}, "resolutions": {"lodash": "4.17.20"}, "pnpm": {"overrides": {"qux": "5.0.0"}}}`,
+			want: []Override{
+				{Name: "lodash", Range: "4.17.21", Field: "overrides"},
+				{Name: "lodash", Range: "4.17.20", Field: "resolutions"},
+				{Name: "qux", Range: "5.0.0", Field: "pnpm.overrides"},
+			},
+		},
+		{
+			name:        "pnpm field without overrides",
+			packageJSON: `{"pnpm": {"neverBuiltDependencies": ["fsevents"]}}`,
+		},
+		{
+			name:        "invalid",
+			packageJSON: `{"overrides": `,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseOverrides([]byte(tc.packageJSON)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseOverrides = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// scriptClient is npm with its add and update commands replaced by a shell
+// script. The script gets the npm arguments and runs in the project directory.
+type scriptClient struct {
+	npmClient
+	script string
+}
+
+func (scriptClient) Binary() string     { return "sh" }
+func (scriptClient) ListArgs() []string { return nil }
+
+func (c scriptClient) AddArgs(name string, depType string) []string {
+	return append([]string{"-c", c.script, "sh"}, c.npmClient.AddArgs(name, depType)...)
+}
+
+func (c scriptClient) UpdateArgs(name string) []string {
+	return append([]string{"-c", c.script, "sh"}, c.npmClient.UpdateArgs(name)...)
+}
+
+// newSectionsProject writes packageJSON to a new project and loads it with
+// a client running script for every add
+func newSectionsProject(t *testing.T, packageJSON, script string) *PackageManager {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	path := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(path, []byte(packageJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := &PackageManager{PackageJSONPath: path, Client: scriptClient{script: script}}
+	if err := pm.LoadPackages(); err != nil {
+		t.Fatal(err)
+	}
+	return pm
+}
+
+// readSections reads the dependency sections of the project's package.json
+func readSections(t *testing.T, pm *PackageManager) map[string]interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(pm.PackageJSONPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packageJSON map[string]interface{}
+	if err := json.Unmarshal(data, &packageJSON); err != nil {
+		t.Fatalf("package.json is no longer valid: %v\n%s", err, data)
+	}
+	return packageJSON
+}
+
+const sectionsPackageJSON = `{
+  "name": "app",
+  "dependencies": {
+    "a": "^1.2.3",
+    "b": "^2.0.0"
+  },
+  "devDependencies": {
+    "d": "^4.0.0"
+  },
+  "peerDependencies": {
+    "p": "^5.0.0"
+  },
+  "peerDependenciesMeta": {
+    "p": {
+      "optional": true
+    }
+  },
+  "bundleDependencies": [
+    "a"
+  ]
+}
+`
+
+func TestMoveToSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkg     string
+		depType string
+		args    string
+		// removed lists the fields that no longer mention the package
+		removed []string
+	}{
+		{
+			name:    "dependency to dev",
+			pkg:     "a",
+			depType: TypeDevDependency,
+			args:    "install a@^1.2.3 --save-dev",
+			removed: []string{"dependencies", "bundleDependencies"},
+		},
+		{
+			// Optional dependencies can stay bundled
+			name:    "dependency to optional",
+			pkg:     "a",
+			depType: TypeOptionalDependency,
+			args:    "install a@^1.2.3 --save-optional",
+			removed: []string{"dependencies"},
+		},
+		{
+			name:    "peer to dependency",
+			pkg:     "p",
+			depType: TypeDependency,
+			args:    "install p@^5.0.0 --save-prod",
+			removed: []string{"peerDependencies", "peerDependenciesMeta"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pm := newSectionsProject(t, sectionsPackageJSON, `echo "$@" > args`)
+
+			if err := pm.MoveToSection(tc.pkg, tc.depType); err != nil {
+				t.Fatalf("MoveToSection: %v", err)
+			}
+
+			args, err := os.ReadFile(filepath.Join(filepath.Dir(pm.PackageJSONPath), "args"))
+			if err != nil {
+				t.Fatalf("client was not run: %v", err)
+			}
+			if got := strings.TrimSpace(string(args)); got != tc.args {
+				t.Errorf("client ran with %q, want %q", got, tc.args)
+			}
+
+			packageJSON := readSections(t, pm)
+			for _, field := range tc.removed {
+				if strings.Contains(mustMarshal(t, packageJSON[field]), `"`+tc.pkg+`"`) {
+					t.Errorf("%s still lists %s: %v", field, tc.pkg, packageJSON[field])
+				}
+			}
+			if bundle := mustMarshal(t, packageJSON["bundleDependencies"]); tc.pkg == "a" && tc.depType == TypeOptionalDependency && bundle != `["a"]` {
+				t.Errorf("bundleDependencies = %s, want a kept", bundle)
+			}
+		})
+	}
+}
+
+func TestMoveToSectionRestoresOnFailure(t *testing.T) {
+	pm := newSectionsProject(t, sectionsPackageJSON, `echo "npm ERR! network" >&2; exit 1`)
+
+	err := pm.MoveToSection("a", TypeDevDependency)
+	if err == nil || !strings.Contains(err.Error(), "npm ERR! network") {
+		t.Fatalf("MoveToSection error = %v, want the client output", err)
+	}
+
+	data, _ := os.ReadFile(pm.PackageJSONPath)
+	if string(data) != sectionsPackageJSON {
+		t.Errorf("package.json was not restored:\n%s", data)
+	}
+}
+
+func TestMoveToSectionReportsFailedRestore(t *testing.T) {
+	// The client fails and leaves a directory in place of package.json
+	pm := newSectionsProject(t, sectionsPackageJSON, `rm package.json && mkdir -p package.json/x; echo "npm ERR! network" >&2; exit 1`)
+
+	err := pm.MoveToSection("a", TypeDevDependency)
+	if err == nil || !strings.Contains(err.Error(), "npm ERR! network") || !strings.Contains(err.Error(), "could not be restored") {
+		t.Fatalf("MoveToSection error = %v, want the client output and the failed restore", err)
+	}
+}
+
+func TestMoveToSectionRefuses(t *testing.T) {
+	pm := newSectionsProject(t, sectionsPackageJSON, "exit 1")
+
+	if err := pm.MoveToSection("missing", TypeDevDependency); err == nil {
+		t.Error("moving a package that is not a dependency succeeded")
+	}
+	if err := pm.MoveToSection("d", TypeDevDependency); err == nil {
+		t.Error("moving a package to its own section succeeded")
+	}
+}
+
+func TestSetBundled(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		pkg         string
+		bundled     bool
+		field       string
+		want        string
+	}{
+		{
+			name:        "added to the list",
+			packageJSON: `{"dependencies": {"a": "^1.0.0", "b": "^2.0.0"}, "bundleDependencies": ["a"]}`,
+			pkg:         "b",
+			bundled:     true,
+			field:       "bundleDependencies",
+			want:        `["a","b"]`,
+		},
+		{
+			name:        "new list",
+			packageJSON: `{"dependencies": {"a": "^1.0.0"}}`,
+			pkg:         "a",
+			bundled:     true,
+			field:       "bundleDependencies",
+			want:        `["a"]`,
+		},
+		{
+			name:        "spelling is kept",
+			packageJSON: `{"dependencies": {"a": "^1.0.0", "b": "^2.0.0"}, "bundledDependencies": ["a"]}`,
+			pkg:         "b",
+			bundled:     true,
+			field:       "bundledDependencies",
+			want:        `["a","b"]`,
+		},
+		{
+			name:        "removed from the list",
+			packageJSON: `{"dependencies": {"a": "^1.0.0", "b": "^2.0.0"}, "bundleDependencies": ["a", "b"]}`,
+			pkg:         "a",
+			field:       "bundleDependencies",
+			want:        `["b"]`,
+		},
+		{
+			// true becomes the list of the other dependencies
+			name:        "removed from true",
+			packageJSON: `{"dependencies": {"c": "^3.0.0", "a": "^1.0.0", "b": "^2.0.0"}, "bundleDependencies": true}`,
+			pkg:         "a",
+			field:       "bundleDependencies",
+			want:        `["b","c"]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pm := newSectionsProject(t, tc.packageJSON, "exit 1")
+
+			if err := pm.SetBundled(tc.pkg, tc.bundled); err != nil {
+				t.Fatalf("SetBundled: %v", err)
+			}
+			if got := mustMarshal(t, readSections(t, pm)[tc.field]); got != tc.want {
+				t.Errorf("%s = %s, want %s", tc.field, got, tc.want)
+			}
+			if pm.Packages[tc.pkg].Bundled != tc.bundled {
+				t.Errorf("%s bundled = %v after reloading, want %v", tc.pkg, pm.Packages[tc.pkg].Bundled, tc.bundled)
+			}
+		})
+	}
+
+	// Only dependencies and optional dependencies can be bundled
+	pm := newSectionsProject(t, `{"devDependencies": {"d": "^4.0.0"}}`, "exit 1")
+	if err := pm.SetBundled("d", true); err == nil {
+		t.Error("bundling a dev dependency succeeded")
+	}
+}
+
+// mustMarshal encodes a value as compact JSON
+func mustMarshal(t *testing.T, value interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,84 @@
+package npm
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+)
+
+// AlignRange rewrites every declaration of a drifting dependency across the
+// monorepo to one range, then installs from the root. The package.json of
+// every package it touches is recorded in the history.
+func (pm *PackageManager) AlignRange(w *workspace.Workspace, drift workspace.Drift, rng string) error {
+	description := fmt.Sprintf("Align %s to %s", drift.Name, rng)
+	return pm.History.RecordFiles(description, drift.Paths(), func() error {
+		for _, usage := range drift.Usages {
+			if usage.Range == rng {
+				continue
+			}
+
+			doc, err := packagejson.Load(usage.Package.PackageJSONPath)
+			if err != nil {
+				return err
+			}
+			if err := doc.Set([]string{usage.Section, drift.Name}, rng); err != nil {
+				return err
+			}
+			if err := doc.Save(usage.Package.PackageJSONPath); err != nil {
+				return err
+			}
+		}
+
+		cmd := exec.Command(pm.Client.Binary(), "install")
+		cmd.Dir = w.RootDir
+		output, err := cmd.CombinedOutput()
+		if err := pm.installResult(output, err); err != nil {
+			return err
+		}
+
+		return pm.LoadPackages()
+	})
+}
+
+// Dedupe runs the client's dedupe where the lockfile lives and returns how
+// many packages the lockfile had at several versions before and after
+func (pm *PackageManager) Dedupe() (int, int, error) {
+	args := pm.Client.DedupeArgs()
+	if args == nil {
+		return 0, 0, fmt.Errorf("%s cannot dedupe", pm.Client.Name())
+	}
+
+	path, _, err := lockfile.Find(pm.projectDir())
+	if err != nil {
+		return 0, 0, fmt.Errorf("dedupe needs a lockfile: %v", err)
+	}
+	graph, err := lockfile.Load(pm.projectDir())
+	if err != nil {
+		return 0, 0, err
+	}
+	before := len(lockfile.Duplicates(graph))
+
+	err = pm.History.Record("Dedupe", func() error {
+		cmd := exec.Command(pm.Client.Binary(), args...)
+		cmd.Dir = filepath.Dir(path)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s dedupe error: %v - %s", pm.Client.Name(), err, string(output))
+		}
+
+		return pm.LoadPackages()
+	})
+	if err != nil {
+		return before, before, err
+	}
+
+	graph, err = lockfile.Load(pm.projectDir())
+	if err != nil {
+		return before, before, err
+	}
+	return before, len(lockfile.Duplicates(graph)), nil
+}
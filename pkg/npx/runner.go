@@ -5,6 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/config"
+	"github.com/VesperAkshay/lazynode/pkg/process"
 )
 
 // NpxCommand represents an npx command
@@ -19,6 +23,8 @@ type Runner struct {
 	ProjectDir     string
 	CacheFile      string
 	RecentCommands []NpxCommand
+	UsePTY         bool                // Run commands under a pseudo-terminal so prompts can be answered
+	Supervisor     *process.Supervisor // Owns the running npx processes
 }
 
 // NewRunner creates a new npx runner
@@ -31,9 +37,16 @@ func NewRunner(projectDir string) (*Runner, error) {
 		return nil, err
 	}
 
+	cfg, err := config.Load(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
 	runner := &Runner{
 		ProjectDir: projectDir,
 		CacheFile:  cacheFile,
+		UsePTY:     cfg.UsePTY() && process.PTYSupported(),
+		Supervisor: process.NewSupervisor(cfg.GracePeriod()),
 	}
 
 	// Load the cache file if it exists
@@ -46,40 +59,29 @@ func NewRunner(projectDir string) (*Runner, error) {
 	return runner, nil
 }
 
-// RunCommand runs an npx command
-func (r *Runner) RunCommand(command string) (*exec.Cmd, error) {
-	// Split the command into parts
-	args := []string{}
-
-	// Add the command and any arguments
-	args = append(args, command)
-
-	// Create the command
-	cmd := exec.Command("npx", args...)
-
-	// Set up the working directory
-	cmd.Dir = r.ProjectDir
-
-	// Redirect output to pipes
-	_, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = cmd.StderrPipe()
+// RunCommand runs an npx command, streaming its output into the process buffer
+func (r *Runner) RunCommand(command string) (*process.Process, error) {
+	// Start the command with its output read into a buffer
+	proc, err := r.Supervisor.Start(process.Spec{
+		Name: "npx " + command,
+		Command: func() *exec.Cmd {
+			// Split the command into the package and its arguments
+			cmd := exec.Command("npx", strings.Fields(command)...)
+
+			// Set up the working directory
+			cmd.Dir = r.ProjectDir
+			return cmd
+		},
+		Options: process.Options{PTY: r.UsePTY},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
-
 	// Cache the command
 	r.CacheCommand(command, "")
 
-	return cmd, nil
+	return proc, nil
 }
 
 // GetRecentCommands returns the recent commands
@@ -0,0 +1,428 @@
+package packagejson
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Document is the text of a package.json with just enough structure to
+// edit it in place. Set and Delete only rewrite the bytes of the value they
+// change, so the key order, indentation, line endings and final newline of
+// the file survive and a one-field edit is a one-line diff.
+type Document struct {
+	data   []byte
+	indent string // One level of indentation, "" for a single-line file
+	eol    string // "\n" or "\r\n"
+	colon  string // Between keys and values, e.g. ": "
+
+	// The file the document was loaded from, to detect outside edits
+	base    []byte
+	sum     [sha256.Size]byte
+	modTime time.Time
+	size    int64
+}
+
+// member is a key and its value inside an object, as byte offsets
+type member struct {
+	key        string
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// object is an object of the document, as byte offsets
+type object struct {
+	start   int // Offset of {
+	end     int // Offset of }
+	members []member
+}
+
+// Parse reads a document and detects its formatting
+func Parse(data []byte) (*Document, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("package.json is not valid JSON")
+	}
+
+	d := &Document{data: data, eol: "\n", colon: ": "}
+	if bytes.Contains(data, []byte("\r\n")) {
+		d.eol = "\r\n"
+	}
+
+	root, err := d.root()
+	if err != nil {
+		return nil, err
+	}
+
+	// Take the style of the first member of the root object
+	if len(root.members) > 0 {
+		first := root.members[0]
+		before := string(data[root.start+1 : first.keyStart])
+		if i := strings.LastIndex(before, "\n"); i >= 0 {
+			d.indent = before[i+1:]
+		}
+		d.colon = string(data[first.keyEnd:first.valueStart])
+	} else if bytes.Contains(data[root.start:root.end], []byte("\n")) {
+		// An empty object laid out on several lines, as npm init leaves it
+		d.indent = "  "
+	}
+
+	return d, nil
+}
+
+// Load reads the document at path. Save refuses to write over the file if
+// it changes on disk in the meantime.
+func Load(path string) (*Document, error) {
+	// Stamp before reading, so a write in between shows up as a change
+	modTime, size := fileStamp(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %v", err)
+	}
+
+	d, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	d.base, d.sum, d.modTime, d.size = data, sha256.Sum256(data), modTime, size
+	return d, nil
+}
+
+// Bytes returns the text of the document
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Save writes the document to path atomically. It returns a ConflictError
+// instead when the file was changed on disk since it was loaded.
+func (d *Document) Save(path string) error {
+	changed, err := d.ChangedOnDisk(path)
+	if err != nil {
+		return err
+	}
+	if changed {
+		return &ConflictError{Path: path}
+	}
+	return d.Overwrite(path)
+}
+
+// Unmarshal decodes the whole document into v
+func (d *Document) Unmarshal(v interface{}) error {
+	return json.Unmarshal(d.data, v)
+}
+
+// Get returns the raw value at a path of object keys
+func (d *Document) Get(path ...string) (json.RawMessage, bool) {
+	obj, index, _, err := d.find(path)
+	if err != nil || index < 0 {
+		return nil, false
+	}
+
+	m := obj.members[index]
+	return json.RawMessage(d.data[m.valueStart:m.valueEnd]), true
+}
+
+// Set writes a value at a path of object keys. Missing objects along the
+// path are created and a new key goes after the existing ones. A value
+// equal to the current one leaves the document untouched.
+func (d *Document) Set(path []string, value interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty path")
+	}
+
+	obj, err := d.root()
+	if err != nil {
+		return err
+	}
+
+	for depth, key := range path {
+		index := obj.find(key)
+
+		if index < 0 {
+			// Wrap the value in the objects still missing
+			for i := len(path) - 1; i > depth; i-- {
+				value = map[string]interface{}{path[i]: value}
+			}
+			return d.insert(obj, depth+1, key, value)
+		}
+
+		m := obj.members[index]
+		if depth == len(path)-1 {
+			return d.replace(m, depth+1, value)
+		}
+
+		if d.data[m.valueStart] != '{' {
+			return fmt.Errorf("%s is not an object", strings.Join(path[:depth+1], "."))
+		}
+		if obj, err = d.parseObject(m.valueStart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the key at a path of object keys, with its separator.
+// Missing keys are ignored.
+func (d *Document) Delete(path ...string) error {
+	obj, index, _, err := d.find(path)
+	if err != nil || index < 0 {
+		return err
+	}
+
+	m := obj.members[index]
+	switch {
+	case len(obj.members) == 1:
+		// The object becomes {}
+		d.splice(obj.start+1, obj.end, nil)
+	case index < len(obj.members)-1:
+		// Up to the next key, which takes over the indentation
+		d.splice(m.keyStart, obj.members[index+1].keyStart, nil)
+	default:
+		// From the end of the previous value, taking its comma
+		d.splice(obj.members[index-1].valueEnd, m.valueEnd, nil)
+	}
+	return nil
+}
+
+// replace writes a new value over a member's value
+func (d *Document) replace(m member, depth int, value interface{}) error {
+	old := d.data[m.valueStart:m.valueEnd]
+
+	// Nothing to do for the same value
+	var current interface{}
+	if json.Unmarshal(old, &current) == nil {
+		if encoded, err := d.encode(value, 0, true); err == nil {
+			var next interface{}
+			if json.Unmarshal(encoded, &next) == nil && reflect.DeepEqual(current, next) {
+				return nil
+			}
+		}
+	}
+
+	// Values written on one line stay on one line
+	encoded, err := d.encode(value, depth, !bytes.Contains(old, []byte("\n")))
+	if err != nil {
+		return err
+	}
+
+	d.splice(m.valueStart, m.valueEnd, encoded)
+	return nil
+}
+
+// insert adds a key after the last member of an object
+func (d *Document) insert(obj *object, depth int, key string, value interface{}) error {
+	encodedKey, err := d.encode(key, 0, true)
+	if err != nil {
+		return err
+	}
+	encodedValue, err := d.encode(value, depth, false)
+	if err != nil {
+		return err
+	}
+	text := string(encodedKey) + d.colon + string(encodedValue)
+
+	if len(obj.members) == 0 {
+		// Open the empty object on its own lines
+		if d.indent == "" {
+			d.splice(obj.start, obj.end+1, []byte("{"+text+"}"))
+		} else {
+			d.splice(obj.start, obj.end+1, []byte("{"+d.eol+strings.Repeat(d.indent, depth)+text+d.eol+strings.Repeat(d.indent, depth-1)+"}"))
+		}
+		return nil
+	}
+
+	// Separate it like the first member is separated from the {
+	separator := string(d.data[obj.start+1 : obj.members[0].keyStart])
+	last := obj.members[len(obj.members)-1]
+	d.splice(last.valueEnd, last.valueEnd, []byte(","+separator+text))
+	return nil
+}
+
+// encode formats a value for the given depth in the document's style
+func (d *Document) encode(value interface{}, depth int, compact bool) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false) // Keep ranges such as ">=1" readable
+	if !compact && d.indent != "" {
+		encoder.SetIndent(strings.Repeat(d.indent, depth), d.indent)
+	}
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	encoded := bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+	if d.eol != "\n" {
+		encoded = bytes.ReplaceAll(encoded, []byte("\n"), []byte(d.eol))
+	}
+	return encoded, nil
+}
+
+// splice replaces data[start:end] with text
+func (d *Document) splice(start, end int, text []byte) {
+	data := make([]byte, 0, len(d.data)-(end-start)+len(text))
+	data = append(data, d.data[:start]...)
+	data = append(data, text...)
+	data = append(data, d.data[end:]...)
+	d.data = data
+}
+
+// root parses the top-level object
+func (d *Document) root() (*object, error) {
+	start := d.skipSpace(0)
+	if start >= len(d.data) || d.data[start] != '{' {
+		return nil, fmt.Errorf("package.json is not an object")
+	}
+	return d.parseObject(start)
+}
+
+// find returns the object holding the last key of path and the index of
+// that key in it, -1 when missing. depth is the number of keys found.
+func (d *Document) find(path []string) (*object, int, int, error) {
+	obj, err := d.root()
+	if err != nil {
+		return nil, -1, 0, err
+	}
+
+	for depth, key := range path {
+		index := obj.find(key)
+		if index < 0 || depth == len(path)-1 {
+			return obj, index, depth, nil
+		}
+
+		m := obj.members[index]
+		if d.data[m.valueStart] != '{' {
+			return nil, -1, depth, nil
+		}
+		if obj, err = d.parseObject(m.valueStart); err != nil {
+			return nil, -1, depth, err
+		}
+	}
+
+	return obj, -1, 0, nil
+}
+
+// find returns the index of a key, -1 when missing
+func (o *object) find(key string) int {
+	for i, m := range o.members {
+		if m.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseObject reads the members of the object starting at start
+func (d *Document) parseObject(start int) (*object, error) {
+	obj := &object{start: start}
+
+	i := d.skipSpace(start + 1)
+	if i < len(d.data) && d.data[i] == '}' {
+		obj.end = i
+		return obj, nil
+	}
+
+	for i < len(d.data) {
+		var m member
+		m.keyStart = i
+		m.keyEnd = d.skipString(i)
+		if err := json.Unmarshal(d.data[m.keyStart:m.keyEnd], &m.key); err != nil {
+			return nil, fmt.Errorf("invalid key at offset %d", i)
+		}
+
+		i = d.skipSpace(m.keyEnd)
+		if i >= len(d.data) || d.data[i] != ':' {
+			return nil, fmt.Errorf("expected : at offset %d", i)
+		}
+		m.valueStart = d.skipSpace(i + 1)
+		m.valueEnd = d.skipValue(m.valueStart)
+		obj.members = append(obj.members, m)
+
+		i = d.skipSpace(m.valueEnd)
+		if i < len(d.data) && d.data[i] == ',' {
+			i = d.skipSpace(i + 1)
+			continue
+		}
+		if i < len(d.data) && d.data[i] == '}' {
+			obj.end = i
+			return obj, nil
+		}
+		return nil, fmt.Errorf("expected , or } at offset %d", i)
+	}
+
+	return nil, fmt.Errorf("unterminated object at offset %d", start)
+}
+
+// skipSpace returns the offset of the next non-whitespace byte
+func (d *Document) skipSpace(i int) int {
+	for i < len(d.data) {
+		switch d.data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipString returns the offset after the string starting at i
+func (d *Document) skipString(i int) int {
+	for i++; i < len(d.data); i++ {
+		switch d.data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipValue returns the offset after the value starting at i. The document
+// is valid JSON, so containers only need their strings and nesting tracked.
+func (d *Document) skipValue(i int) int {
+	if i >= len(d.data) {
+		return i
+	}
+
+	switch d.data[i] {
+	case '"':
+		return d.skipString(i)
+	case '{', '[':
+		nesting := 0
+		for i < len(d.data) {
+			switch d.data[i] {
+			case '"':
+				i = d.skipString(i)
+				continue
+			case '{', '[':
+				nesting++
+			case '}', ']':
+				nesting--
+				if nesting == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+
+	// Numbers, true, false and null
+	for i < len(d.data) {
+		switch d.data[i] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
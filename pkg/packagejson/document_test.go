@@ -0,0 +1,190 @@
+package packagejson
+
+import (
+	"strings"
+	"testing"
+
+	textdiff "github.com/VesperAkshay/lazynode/pkg/diff"
+)
+
+// manifest is the package.json the edits start from, written with tabs
+const manifest = `{
+	"name": "app",
+	"version": "1.0.0",
+	"scripts": {
+		"test": "jest"
+	},
+	"private": true
+}
+`
+
+// styled rewrites a text written with tabs and "\n" in another style
+func styled(text, indent, eol string) string {
+	text = strings.ReplaceAll(text, "\t", indent)
+	return strings.ReplaceAll(text, "\n", eol)
+}
+
+func TestEdits(t *testing.T) {
+	setVersion := func(d *Document) error { return d.Set([]string{"version"}, "1.1.0") }
+	withVersion := strings.Replace(manifest, `"1.0.0"`, `"1.1.0"`, 1)
+
+	tests := []struct {
+		name           string
+		input          string
+		edit           func(d *Document) error
+		want           string
+		added, removed int // Lines of the diff
+	}{
+		{
+			name:  "tabs",
+			input: manifest,
+			edit:  setVersion,
+			want:  withVersion,
+			added: 1, removed: 1,
+		},
+		{
+			name:  "two spaces",
+			input: styled(manifest, "  ", "\n"),
+			edit:  setVersion,
+			want:  styled(withVersion, "  ", "\n"),
+			added: 1, removed: 1,
+		},
+		{
+			name:  "four spaces",
+			input: styled(manifest, "    ", "\n"),
+			edit:  setVersion,
+			want:  styled(withVersion, "    ", "\n"),
+			added: 1, removed: 1,
+		},
+		{
+			name:  "CRLF",
+			input: styled(manifest, "  ", "\r\n"),
+			edit:  setVersion,
+			want:  styled(withVersion, "  ", "\r\n"),
+			added: 1, removed: 1,
+		},
+		{
+			name:  "no final newline",
+			input: strings.TrimSuffix(manifest, "\n"),
+			edit:  setVersion,
+			want:  strings.TrimSuffix(withVersion, "\n"),
+			added: 1, removed: 1,
+		},
+		{
+			name:  "same value",
+			input: manifest,
+			edit:  func(d *Document) error { return d.Set([]string{"version"}, "1.0.0") },
+			want:  manifest,
+		},
+		{
+			name:  "new key in a nested object",
+			input: styled(manifest, "  ", "\r\n"),
+			edit:  func(d *Document) error { return d.Set([]string{"scripts", "build"}, "tsc") },
+			want: styled(`{
+	"name": "app",
+	"version": "1.0.0",
+	"scripts": {
+		"test": "jest",
+		"build": "tsc"
+	},
+	"private": true
+}
+`, "  ", "\r\n"),
+			added: 2, removed: 1,
+		},
+		{
+			name:  "nested key in a missing object",
+			input: styled(manifest, "    ", "\n"),
+			edit:  func(d *Document) error { return d.Set([]string{"engines", "node"}, ">=18") },
+			want: styled(`{
+	"name": "app",
+	"version": "1.0.0",
+	"scripts": {
+		"test": "jest"
+	},
+	"private": true,
+	"engines": {
+		"node": ">=18"
+	}
+}
+`, "    ", "\n"),
+			added: 4, removed: 1,
+		},
+		{
+			name:  "key in an empty nested object",
+			input: strings.Replace(manifest, "{\n\t\t\"test\": \"jest\"\n\t}", "{}", 1),
+			edit:  func(d *Document) error { return d.Set([]string{"scripts", "test"}, "jest") },
+			want:  manifest,
+			added: 3, removed: 1,
+		},
+		{
+			name:  "key in an empty multi-line file",
+			input: "{\n}\n",
+			edit:  func(d *Document) error { return d.Set([]string{"name"}, "app") },
+			want:  "{\n  \"name\": \"app\"\n}\n",
+			added: 1, removed: 0,
+		},
+		{
+			name:  "key in an empty one-line file",
+			input: "{}",
+			edit:  func(d *Document) error { return d.Set([]string{"name"}, "app") },
+			want:  `{"name": "app"}`,
+			added: 1, removed: 1,
+		},
+		{
+			name:  "delete the first key",
+			input: manifest,
+			edit:  func(d *Document) error { return d.Delete("name") },
+			want:  strings.Replace(manifest, "\t\"name\": \"app\",\n", "", 1),
+			added: 0, removed: 1,
+		},
+		{
+			name:  "delete a middle key",
+			input: styled(manifest, "  ", "\r\n"),
+			edit:  func(d *Document) error { return d.Delete("version") },
+			want:  styled(strings.Replace(manifest, "\t\"version\": \"1.0.0\",\n", "", 1), "  ", "\r\n"),
+			added: 0, removed: 1,
+		},
+		{
+			name:  "delete the last key",
+			input: manifest,
+			edit:  func(d *Document) error { return d.Delete("private") },
+			want:  strings.Replace(manifest, "\t},\n\t\"private\": true\n", "\t}\n", 1),
+			added: 1, removed: 2,
+		},
+		{
+			name:  "delete the only nested key",
+			input: manifest,
+			edit:  func(d *Document) error { return d.Delete("scripts", "test") },
+			want:  strings.Replace(manifest, "{\n\t\t\"test\": \"jest\"\n\t}", "{}", 1),
+			added: 1, removed: 3,
+		},
+		{
+			name:  "delete a missing key",
+			input: manifest,
+			edit:  func(d *Document) error { return d.Delete("scripts", "build") },
+			want:  manifest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := Parse([]byte(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.edit(d); err != nil {
+				t.Fatal(err)
+			}
+
+			got := string(d.Bytes())
+			if got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+			added, removed := textdiff.Stats(textdiff.Unified([]byte(tc.input), d.Bytes(), 0))
+			if added != tc.added || removed != tc.removed {
+				t.Errorf("diff is +%d -%d, want +%d -%d", added, removed, tc.added, tc.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package packagejson
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConflictError is returned by Save when the file was changed on disk since
+// the document was loaded. The caller can reload, Overwrite or Merge.
+type ConflictError struct {
+	Path string
+}
+
+// Error describes the conflict
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s was changed on disk since it was loaded", filepath.Base(e.Path))
+}
+
+// remember records the file the document now matches, to detect outside edits
+func (d *Document) remember(path string, data []byte) {
+	d.base = data
+	d.sum = sha256.Sum256(data)
+	d.modTime, d.size = fileStamp(path)
+}
+
+// ChangedOnDisk reports whether the file at path differs from what the
+// document was loaded from. The modification time and size are checked
+// first; the content hash only when they moved, so touching the file
+// isn't a change.
+func (d *Document) ChangedOnDisk(path string) (bool, error) {
+	if d.base == nil {
+		return false, nil // Not loaded from a file
+	}
+
+	modTime, size := fileStamp(path)
+	if modTime.Equal(d.modTime) && size == d.size {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil // Saving creates it again
+	}
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(data) != d.sum, nil
+}
+
+// Overwrite writes the document to path whatever happened to the file
+func (d *Document) Overwrite(path string) error {
+	if err := WriteFile(path, d.data); err != nil {
+		return err
+	}
+	d.remember(path, d.data)
+	return nil
+}
+
+// Merge replays the edits made to the document since it was loaded onto the
+// file as it is now on disk, keeping the formatting of the file. Keys both
+// sides changed to different values are returned as conflicts, leaving the
+// document as it was. The merged document still has to be saved.
+func (d *Document) Merge(path string) ([]string, error) {
+	modTime, size := fileStamp(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %v", err)
+	}
+	theirs, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := decode(d.base)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := decode(d.data)
+	if err != nil {
+		return nil, err
+	}
+	current, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every edit of ours against what happened on disk
+	changes := diff(nil, base, ours)
+	var conflicts []string
+	var apply []change
+	for _, c := range changes {
+		was, wasFound := lookup(base, c.path)
+		now, found := lookup(current, c.path)
+		switch {
+		case found == wasFound && reflect.DeepEqual(now, was):
+			apply = append(apply, c) // Untouched on disk
+		case found == c.set && (!found || reflect.DeepEqual(now, c.value)):
+			// Same edit on both sides
+		default:
+			conflicts = append(conflicts, strings.Join(c.path, "."))
+		}
+	}
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	for _, c := range apply {
+		if c.set {
+			err = theirs.Set(c.path, c.value)
+		} else {
+			err = theirs.Delete(c.path...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The file on disk is the new base
+	*d = *theirs
+	d.base, d.sum, d.modTime, d.size = data, sha256.Sum256(data), modTime, size
+	return nil, nil
+}
+
+// change is a key set or deleted between two versions of a document
+type change struct {
+	path  []string
+	value interface{}
+	set   bool // false for a deletion
+}
+
+// diff lists the keys changed from base to ours, descending into objects
+// present on both sides
+func diff(path []string, base, ours interface{}) []change {
+	baseObject, _ := base.(map[string]interface{})
+	oursObject, _ := ours.(map[string]interface{})
+
+	keys := make([]string, 0, len(baseObject)+len(oursObject))
+	for key := range oursObject {
+		keys = append(keys, key)
+	}
+	for key := range baseObject {
+		if _, ok := oursObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []change
+	for _, key := range keys {
+		keyPath := append(append([]string{}, path...), key)
+		was, wasFound := baseObject[key]
+		now, found := oursObject[key]
+
+		_, wasObject := was.(map[string]interface{})
+		_, isObject := now.(map[string]interface{})
+		switch {
+		case !found:
+			changes = append(changes, change{path: keyPath})
+		case wasFound && wasObject && isObject:
+			changes = append(changes, diff(keyPath, was, now)...)
+		case !wasFound || !reflect.DeepEqual(was, now):
+			changes = append(changes, change{path: keyPath, value: now, set: true})
+		}
+	}
+	return changes
+}
+
+// lookup returns the value at a path of object keys
+func lookup(value interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// decode parses JSON keeping numbers as written
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %v", err)
+	}
+	return value, nil
+}
+
+// fileStamp returns the modification time and size of a file, zero when
+// it can't be read
+func fileStamp(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, -1
+	}
+	return info.ModTime(), info.Size()
+}
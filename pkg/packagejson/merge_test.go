@@ -0,0 +1,97 @@
+package packagejson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	setVersion := func(version string) func(d *Document) error {
+		return func(d *Document) error { return d.Set([]string{"version"}, version) }
+	}
+	withVersion := func(version string) string {
+		return strings.Replace(manifest, `"1.0.0"`, `"`+version+`"`, 1)
+	}
+	withBuild := strings.Replace(manifest, "\"jest\"\n", "\"jest\",\n\t\t\"build\": \"tsc\"\n", 1)
+
+	tests := []struct {
+		name      string
+		edit      func(d *Document) error // Ours, on the loaded document
+		onDisk    string                  // Theirs, written after loading
+		want      string
+		conflicts []string
+	}{
+		{
+			name:   "edit untouched on disk",
+			edit:   setVersion("1.1.0"),
+			onDisk: withBuild,
+			want:   strings.Replace(withBuild, `"1.0.0"`, `"1.1.0"`, 1),
+		},
+		{
+			name:   "same edit on both sides",
+			edit:   setVersion("1.1.0"),
+			onDisk: withVersion("1.1.0"),
+			want:   withVersion("1.1.0"),
+		},
+		{
+			name:      "different values",
+			edit:      setVersion("1.1.0"),
+			onDisk:    withVersion("1.2.0"),
+			want:      withVersion("1.1.0"),
+			conflicts: []string{"version"},
+		},
+		{
+			name:      "delete against an edit",
+			edit:      func(d *Document) error { return d.Delete("scripts", "test") },
+			onDisk:    strings.Replace(manifest, `"jest"`, `"vitest"`, 1),
+			want:      strings.Replace(manifest, "{\n\t\t\"test\": \"jest\"\n\t}", "{}", 1),
+			conflicts: []string{"scripts.test"},
+		},
+		{
+			name:   "same delete on both sides",
+			edit:   func(d *Document) error { return d.Delete("private") },
+			onDisk: strings.Replace(manifest, "\t},\n\t\"private\": true\n", "\t}\n", 1),
+			want:   strings.Replace(manifest, "\t},\n\t\"private\": true\n", "\t}\n", 1),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "package.json")
+			if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			d, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.edit(d); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tc.onDisk), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			conflicts, err := d.Merge(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(conflicts, tc.conflicts) {
+				t.Errorf("conflicts = %q, want %q", conflicts, tc.conflicts)
+			}
+			if got := string(d.Bytes()); got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+
+			// A merged document is saved over the file without a conflict
+			if tc.conflicts == nil {
+				if err := d.Save(path); err != nil {
+					t.Errorf("Save after merging: %v", err)
+				}
+			}
+		})
+	}
+}
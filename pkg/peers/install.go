@@ -0,0 +1,211 @@
+package peers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Patterns of the peer dependency messages printed by failed or warning installs
+var (
+	// npm: Found: react@18.2.0
+	npmFound = regexp.MustCompile(`^Found: (\S+)$`)
+	// npm: react@"^18.2.0" from the root project, peer react@"^17.0.0" from react-plugin@1.0.0
+	npmRequirement = regexp.MustCompile(`^(peer |peerOptional )?(\S+?)@"([^"]*)" from (.+)$`)
+	// npm: Conflicting peer dependency: react@17.0.2
+	npmConflicting = regexp.MustCompile(`^Conflicting peer dependency: (\S+)$`)
+	// pnpm: └─┬ react-plugin 1.0.0
+	pnpmDependent = regexp.MustCompile(`[┬─] (\S+) (\d\S*)$`)
+	// pnpm: └── ✕ unmet peer react@^17.0.0: found 18.2.0, or ✕ missing peer react@"^17"
+	pnpmPeer = regexp.MustCompile(`✕ (unmet|missing) peer (\S+?)@"?([^":]+?)"?(?:: found (\S+))?$`)
+	// yarn: warning " > react-plugin@1.0.0" has incorrect peer dependency "react@^17.0.0".
+	yarnPeer = regexp.MustCompile(`"(?:[^"]*> )?(\S+)" has (incorrect|unmet) peer dependency "(\S+?)@([^"]+)"`)
+	// yarn berry: YN0060: │ react is listed by your project with version 18.2.0, which doesn't satisfy what react-plugin (p1a2b3) requests (^17.0.0).
+	berryUnmet = regexp.MustCompile(`YN0060: .*?(\S+) is listed by your project with version (\S+?)(?: \(\w+\))?, which doesn't satisfy what (\S+)(?: \(\w+\))?(?: and other dependencies)? requests? \(([^)]+)\)`)
+	// yarn berry: YN0002: │ app@workspace:. doesn't provide react (p1a2b3), requested by react-plugin
+	berryMissing = regexp.MustCompile(`YN0002: .*?(\S+) doesn't provide (\S+) \(\w+\), requested by (\S+)`)
+)
+
+// ParseInstallOutput reads the peer dependency problems out of the output
+// of an install: npm's ERESOLVE report, pnpm's peer issues and yarn's peer
+// warnings. It returns nil when the output mentions none.
+func ParseInstallOutput(output string) []Issue {
+	collected := &collector{byName: make(map[string]*Issue)}
+
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	collected.parseNpm(lines)
+	collected.parseOthers(lines)
+
+	var issues []Issue
+	for _, name := range collected.names {
+		issue := collected.byName[name]
+		issue.classify()
+		issues = append(issues, *issue)
+	}
+
+	Sort(issues)
+	return issues
+}
+
+// collector gathers the requirements of every peer
+type collector struct {
+	byName map[string]*Issue
+	names  []string
+}
+
+// issue returns the issue of a peer, creating it if needed
+func (c *collector) issue(name string) *Issue {
+	issue, ok := c.byName[name]
+	if !ok {
+		issue = &Issue{Name: name}
+		c.byName[name] = issue
+		c.names = append(c.names, name)
+	}
+	return issue
+}
+
+// parseNpm reads the ERESOLVE blocks of npm, both the errors and the
+// "overriding peer dependency" warnings
+func (c *collector) parseNpm(lines []string) {
+	var current *Issue
+	section := ""
+
+	for _, line := range lines {
+		// Strip "npm ERR! ", "npm error " and "npm WARN "
+		for _, prefix := range []string{"npm ERR!", "npm error", "npm WARN", "npm warn"} {
+			line = strings.TrimPrefix(line, prefix)
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "ERESOLVE"):
+			// A new report
+			current = nil
+			section = ""
+			continue
+		case strings.HasPrefix(line, "code ") || line == "":
+			continue
+		}
+
+		if match := npmFound.FindStringSubmatch(line); match != nil {
+			name, version := SplitID(match[1])
+			current = c.issue(name)
+			current.Installed = version
+			section = "found"
+			continue
+		}
+
+		if strings.HasPrefix(line, "Could not resolve dependency:") {
+			section = "unresolved"
+			continue
+		}
+
+		if match := npmConflicting.FindStringSubmatch(line); match != nil && current != nil {
+			name, version := SplitID(match[1])
+			if name == current.Name {
+				current.Suggestions = append(current.Suggestions, Suggestion{
+					Package: name,
+					Version: version,
+					Reason:  "the version the conflicting dependent needs",
+				})
+			}
+			continue
+		}
+
+		match := npmRequirement.FindStringSubmatch(line)
+		if match == nil || current == nil || match[2] != current.Name {
+			continue // node_modules paths and the chains leading to the dependents
+		}
+
+		requirement := Requirement{
+			Dependent: match[4],
+			Range:     match[3],
+			Peer:      match[1] != "",
+			Optional:  match[1] == "peerOptional ",
+			Resolved:  current.Installed,
+			Satisfied: section == "found",
+		}
+		if !hasRequirement(current, requirement) {
+			current.Requirements = append(current.Requirements, requirement)
+		}
+	}
+}
+
+// parseOthers reads the peer messages of pnpm, yarn and yarn berry
+func (c *collector) parseOthers(lines []string) {
+	dependent := ""
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if match := pnpmPeer.FindStringSubmatch(line); match != nil {
+			issue := c.issue(match[2])
+			if match[4] != "" {
+				issue.Installed = match[4]
+			}
+			c.add(issue, Requirement{
+				Dependent: dependent,
+				Range:     match[3],
+				Peer:      true,
+				Resolved:  match[4],
+			})
+			continue
+		}
+		if match := pnpmDependent.FindStringSubmatch(line); match != nil {
+			dependent = match[1] + "@" + match[2]
+			continue
+		}
+
+		if match := yarnPeer.FindStringSubmatch(line); match != nil {
+			issue := c.issue(match[3])
+			resolved := ""
+			if match[2] == "incorrect" {
+				resolved = "?" // Installed, but yarn doesn't say which version
+			}
+			c.add(issue, Requirement{
+				Dependent: match[1],
+				Range:     match[4],
+				Peer:      true,
+				Resolved:  resolved,
+			})
+			continue
+		}
+
+		if match := berryUnmet.FindStringSubmatch(line); match != nil {
+			issue := c.issue(match[1])
+			issue.Installed = match[2]
+			c.add(issue, Requirement{
+				Dependent: match[3],
+				Range:     match[4],
+				Peer:      true,
+				Resolved:  match[2],
+			})
+			continue
+		}
+
+		if match := berryMissing.FindStringSubmatch(line); match != nil {
+			// The package asking for the peer, not the one failing to provide it
+			c.add(c.issue(match[2]), Requirement{
+				Dependent: match[3],
+				Range:     "*",
+				Peer:      true,
+			})
+		}
+	}
+}
+
+// add adds a requirement to an issue unless it is already listed
+func (c *collector) add(issue *Issue, requirement Requirement) {
+	if !hasRequirement(issue, requirement) {
+		issue.Requirements = append(issue.Requirements, requirement)
+	}
+}
+
+// hasRequirement reports whether the issue lists the same requirement
+func hasRequirement(issue *Issue, requirement Requirement) bool {
+	for _, existing := range issue.Requirements {
+		if existing.Dependent == requirement.Dependent && existing.Range == requirement.Range {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package peers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseInstallOutput(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Issue
+	}{
+		{
+			fixture: "npm-eresolve.txt",
+			want: []Issue{
+				{
+					Kind:      Conflict,
+					Name:      "react",
+					Installed: "18.2.0",
+					Requirements: []Requirement{
+						{Dependent: RootProject, Range: "^18.2.0", Resolved: "18.2.0", Satisfied: true},
+						{Dependent: "react-plugin@1.0.0", Range: "^17.0.0", Peer: true, Resolved: "18.2.0"},
+					},
+					Suggestions: []Suggestion{
+						{Package: "react", Version: "17.0.2", Reason: "the version the conflicting dependent needs"},
+					},
+				},
+			},
+		},
+		{
+			fixture: "pnpm.txt",
+			want: []Issue{
+				{
+					Kind: Missing,
+					Name: "react-dom",
+					Requirements: []Requirement{
+						{Dependent: "react-plugin@1.0.0", Range: "^17.0.0", Peer: true},
+					},
+				},
+				{
+					Kind:      Unmet,
+					Name:      "react",
+					Installed: "18.2.0",
+					Requirements: []Requirement{
+						{Dependent: "react-plugin@1.0.0", Range: "^17.0.0", Peer: true, Resolved: "18.2.0"},
+					},
+				},
+			},
+		},
+		{
+			fixture: "yarn-classic.txt",
+			want: []Issue{
+				{
+					Kind: Missing,
+					Name: "scheduler",
+					Requirements: []Requirement{
+						{Dependent: "react-dom@17.0.2", Range: "^0.20.0", Peer: true},
+					},
+				},
+				{
+					// yarn doesn't say which version is installed
+					Kind: Unmet,
+					Name: "react",
+					Requirements: []Requirement{
+						{Dependent: "react-plugin@1.0.0", Range: "^17.0.0", Peer: true, Resolved: "?"},
+					},
+				},
+			},
+		},
+		{
+			fixture: "yarn-berry.txt",
+			want: []Issue{
+				{
+					Kind: Missing,
+					Name: "react-dom",
+					Requirements: []Requirement{
+						{Dependent: "react-plugin", Range: "*", Peer: true},
+					},
+				},
+				{
+					Kind:      Unmet,
+					Name:      "react",
+					Installed: "18.2.0",
+					Requirements: []Requirement{
+						{Dependent: "react-plugin", Range: "^17.0.0", Peer: true, Resolved: "18.2.0"},
+					},
+				},
+				{
+					Kind:      Unmet,
+					Name:      "typescript",
+					Installed: "5.4.5",
+					Requirements: []Requirement{
+						{Dependent: "ts-plugin", Range: "^4.0.0", Peer: true, Resolved: "5.4.5"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fixture, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issues := ParseInstallOutput(string(output))
+			if len(issues) != len(tc.want) {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), len(tc.want), issues)
+			}
+			for i, want := range tc.want {
+				if got := issues[i]; !reflect.DeepEqual(got, want) {
+					t.Errorf("issue %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInstallOutputClean(t *testing.T) {
+	output := "added 3 packages, and audited 4 packages in 1s\n\nfound 0 vulnerabilities\n"
+	if issues := ParseInstallOutput(output); issues != nil {
+		t.Errorf("ParseInstallOutput of a clean install = %+v, want nil", issues)
+	}
+}
@@ -0,0 +1,315 @@
+package peers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/registry"
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+)
+
+// Kind is the kind of peer dependency problem
+type Kind int
+
+// Kinds, least serious first
+const (
+	MissingOptional Kind = iota // An optional peer isn't installed
+	Unmet                       // The installed version is outside the requested range
+	Missing                     // A required peer isn't installed
+	Conflict                    // Dependents request ranges that don't overlap
+)
+
+// String returns the label of a kind
+func (k Kind) String() string {
+	switch k {
+	case MissingOptional:
+		return "missing optional"
+	case Unmet:
+		return "unmet"
+	case Missing:
+		return "missing"
+	case Conflict:
+		return "conflict"
+	}
+	return "unknown"
+}
+
+// RootProject names the project itself as a dependent
+const RootProject = "the root project"
+
+// Requirement is the range a dependent asks for
+type Requirement struct {
+	Dependent string // "name@version", or RootProject
+	Range     string
+	Peer      bool // false for the project's own dependency on the package
+	Optional  bool // Marked optional in peerDependenciesMeta
+	Resolved  string
+	Satisfied bool
+}
+
+// Suggestion is a version that would satisfy a requirement
+type Suggestion struct {
+	Package string
+	Version string
+	Reason  string
+}
+
+// Issue is a problem with the peers of one package
+type Issue struct {
+	Kind         Kind
+	Name         string // The peer package
+	Installed    string // Version the project resolves, "" when missing
+	Requirements []Requirement
+	Suggestions  []Suggestion
+}
+
+// Unsatisfied returns the requirements the installed version misses
+func (i *Issue) Unsatisfied() []Requirement {
+	var unsatisfied []Requirement
+	for _, requirement := range i.Requirements {
+		if !requirement.Satisfied {
+			unsatisfied = append(unsatisfied, requirement)
+		}
+	}
+	return unsatisfied
+}
+
+// classify sets the kind from the requirements
+func (i *Issue) classify() {
+	satisfied, unsatisfied, missing, optional := 0, 0, 0, true
+	for _, requirement := range i.Requirements {
+		switch {
+		case requirement.Satisfied:
+			satisfied++
+		case requirement.Resolved == "":
+			missing++
+			optional = optional && requirement.Optional
+		default:
+			unsatisfied++
+		}
+	}
+
+	switch {
+	case satisfied > 0 && unsatisfied > 0:
+		i.Kind = Conflict
+	case missing > 0 && optional && unsatisfied == 0:
+		i.Kind = MissingOptional
+	case missing > 0:
+		i.Kind = Missing
+	default:
+		i.Kind = Unmet
+	}
+}
+
+// Check evaluates every peerDependencies range in the resolved graph against
+// the version the dependent actually gets. The peers of workspace packages
+// are left out: they are for the consumers of the package.
+func Check(graph *lockfile.Graph) []Issue {
+	ids := make([]string, 0, len(graph.Nodes))
+	for id := range graph.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	byName := make(map[string]*Issue)
+	var names []string
+	for _, id := range ids {
+		node := graph.Nodes[id]
+		for _, edge := range node.Dependencies {
+			if edge.Type != lockfile.Peer {
+				continue
+			}
+
+			requirement := Requirement{
+				Dependent: node.ID(),
+				Range:     edge.Range,
+				Peer:      true,
+				Optional:  edge.OptionalPeer,
+			}
+			if edge.To != nil {
+				requirement.Resolved = edge.To.Version
+				requirement.Satisfied = satisfies(edge.To.Version, edge.Range)
+			}
+
+			issue, ok := byName[edge.Name]
+			if !ok {
+				issue = &Issue{Name: edge.Name}
+				byName[edge.Name] = issue
+				names = append(names, edge.Name)
+			}
+			issue.Requirements = append(issue.Requirements, requirement)
+		}
+	}
+
+	var issues []Issue
+	for _, name := range names {
+		issue := byName[name]
+		if len(issue.Unsatisfied()) == 0 {
+			continue
+		}
+
+		// What the project itself asks for, and gets
+		if edge := graph.Root.Dependency(name); edge != nil && edge.To != nil {
+			issue.Installed = edge.To.Version
+			issue.Requirements = append([]Requirement{{
+				Dependent: RootProject,
+				Range:     edge.Range,
+				Resolved:  edge.To.Version,
+				Satisfied: satisfies(edge.To.Version, edge.Range),
+			}}, issue.Requirements...)
+		} else {
+			for _, requirement := range issue.Requirements {
+				if requirement.Resolved != "" {
+					issue.Installed = requirement.Resolved
+					break
+				}
+			}
+		}
+
+		issue.classify()
+		issues = append(issues, *issue)
+	}
+
+	Sort(issues)
+	return issues
+}
+
+// Sort orders issues by kind, most serious first, then by name
+func Sort(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind > issues[j].Kind
+		}
+		return issues[i].Name < issues[j].Name
+	})
+}
+
+// Suggest looks up the registry for versions that would fix an issue: the
+// newest version of the peer that every dependent accepts, and for each
+// unhappy dependent the newest version whose peer range accepts what is
+// installed. An issue no peer version can fix becomes a Conflict.
+// Suggestions the issue already has, e.g. from the install output, are kept
+// first.
+func Suggest(ctx context.Context, client *registry.Client, issue *Issue) error {
+	packument, err := client.AbbreviatedPackument(ctx, issue.Name)
+	if err != nil {
+		return err
+	}
+
+	// The peer version satisfying everyone
+	var ranges []string
+	for _, requirement := range issue.Requirements {
+		if !requirement.Optional || requirement.Resolved != "" {
+			ranges = append(ranges, requirement.Range)
+		}
+	}
+	common := newestMatching(packument.VersionList(), func(version string) bool {
+		for _, rng := range ranges {
+			if !satisfies(version, rng) {
+				return false
+			}
+		}
+		return true
+	})
+
+	var suggestions []Suggestion
+	switch {
+	case common != "" && common != issue.Installed:
+		suggestions = append(suggestions, Suggestion{
+			Package: issue.Name,
+			Version: common,
+			Reason:  "accepted by every dependent",
+		})
+	case common == "" && len(ranges) > 1:
+		issue.Kind = Conflict
+	}
+
+	// Dependents accepting the installed version in a newer release
+	if issue.Installed != "" {
+		for _, requirement := range issue.Unsatisfied() {
+			if !requirement.Peer {
+				continue
+			}
+
+			name, current := SplitID(requirement.Dependent)
+			dependent, err := client.AbbreviatedPackument(ctx, name)
+			if err != nil {
+				continue
+			}
+
+			version := newestMatching(dependent.VersionList(), func(version string) bool {
+				rng, ok := dependent.Versions[version].PeerDependencies[issue.Name]
+				return ok && satisfies(issue.Installed, rng)
+			})
+			if version != "" && version != current {
+				suggestions = append(suggestions, Suggestion{
+					Package: name,
+					Version: version,
+					Reason: fmt.Sprintf("peer %s %q accepts %s",
+						issue.Name, dependent.Versions[version].PeerDependencies[issue.Name], issue.Installed),
+				})
+			}
+		}
+	}
+
+	issue.Suggestions = appendSuggestions(issue.Suggestions, suggestions...)
+	return nil
+}
+
+// appendSuggestions adds the suggestions of versions not suggested yet
+func appendSuggestions(suggestions []Suggestion, more ...Suggestion) []Suggestion {
+	for _, suggestion := range more {
+		found := false
+		for _, existing := range suggestions {
+			if existing.Package == suggestion.Package && existing.Version == suggestion.Version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// newestMatching returns the newest stable version accepted by match
+func newestMatching(versions []string, match func(string) bool) string {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if v, err := semver.Parse(versions[i]); err != nil || v.IsPrerelease() {
+			continue
+		}
+		if match(versions[i]) {
+			return versions[i]
+		}
+	}
+	return ""
+}
+
+// SplitID splits "name@version", keeping the @ of scoped names
+func SplitID(id string) (string, string) {
+	index := strings.LastIndex(id, "@")
+	if index <= 0 {
+		return id, ""
+	}
+	return id[:index], id[index+1:]
+}
+
+// satisfies checks a version against a range. Ranges that aren't semver,
+// such as workspace: or git URLs, are taken as satisfied.
+func satisfies(version, rng string) bool {
+	// An alias like "npm:react@^18" is checked against its range
+	if strings.HasPrefix(rng, "npm:") {
+		if i := strings.LastIndex(rng, "@"); i > len("npm:") {
+			rng = rng[i+1:]
+		}
+	}
+
+	if !semver.ValidRange(rng) {
+		return true
+	}
+	return semver.Satisfies(version, rng)
+}
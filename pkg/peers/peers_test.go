@@ -0,0 +1,147 @@
+package peers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/registry"
+)
+
+// graph is a small project where react-plugin wants an older react than
+// the project uses, and an older scheduler than react-dom brings, and
+// react-dom has an optional peer nobody installed
+func graph() *lockfile.Graph {
+	react := &lockfile.Node{Name: "react", Version: "18.2.0"}
+	scheduler := &lockfile.Node{Name: "scheduler", Version: "0.23.0"}
+	reactDOM := &lockfile.Node{Name: "react-dom", Version: "18.2.0", Dependencies: []*lockfile.Edge{
+		{Name: "scheduler", Range: "^0.23.0", Type: lockfile.Prod, To: scheduler},
+		{Name: "react", Range: "^18.2.0", Type: lockfile.Peer, To: react},
+		{Name: "react-devtools", Range: "^4.0.0", Type: lockfile.Peer, OptionalPeer: true},
+	}}
+	plugin := &lockfile.Node{Name: "react-plugin", Version: "1.0.0", Dependencies: []*lockfile.Edge{
+		{Name: "react", Range: "^17.0.0", Type: lockfile.Peer, To: react},
+		{Name: "scheduler", Range: "^0.20.0", Type: lockfile.Peer, To: scheduler},
+	}}
+	kit := &lockfile.Node{Name: "ui-kit", Version: "2.0.0", Dependencies: []*lockfile.Edge{
+		{Name: "react-dom", Range: "^18.0.0", Type: lockfile.Peer, To: reactDOM},
+	}}
+
+	root := &lockfile.Node{Name: "app", Version: "1.0.0", Workspace: true, Dependencies: []*lockfile.Edge{
+		{Name: "react", Range: "^18.2.0", Type: lockfile.Prod, To: react},
+		{Name: "react-dom", Range: "^18.2.0", Type: lockfile.Prod, To: reactDOM},
+		{Name: "react-plugin", Range: "^1.0.0", Type: lockfile.Prod, To: plugin},
+		{Name: "ui-kit", Range: "^2.0.0", Type: lockfile.Prod, To: kit},
+	}}
+
+	nodes := make(map[string]*lockfile.Node)
+	for _, node := range []*lockfile.Node{react, scheduler, reactDOM, plugin, kit} {
+		nodes[node.ID()] = node
+	}
+	return &lockfile.Graph{
+		Kind:      lockfile.KindNpm,
+		Root:      root,
+		Importers: map[string]*lockfile.Node{"": root},
+		Nodes:     nodes,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	want := []Issue{
+		{
+			// The project and react-dom are happy with react 18, react-plugin is not
+			Kind:      Conflict,
+			Name:      "react",
+			Installed: "18.2.0",
+			Requirements: []Requirement{
+				{Dependent: RootProject, Range: "^18.2.0", Resolved: "18.2.0", Satisfied: true},
+				{Dependent: "react-dom@18.2.0", Range: "^18.2.0", Peer: true, Resolved: "18.2.0", Satisfied: true},
+				{Dependent: "react-plugin@1.0.0", Range: "^17.0.0", Peer: true, Resolved: "18.2.0"},
+			},
+		},
+		{
+			Kind:      Unmet,
+			Name:      "scheduler",
+			Installed: "0.23.0",
+			Requirements: []Requirement{
+				{Dependent: "react-plugin@1.0.0", Range: "^0.20.0", Peer: true, Resolved: "0.23.0"},
+			},
+		},
+		{
+			Kind: MissingOptional,
+			Name: "react-devtools",
+			Requirements: []Requirement{
+				{Dependent: "react-dom@18.2.0", Range: "^4.0.0", Peer: true, Optional: true},
+			},
+		},
+	}
+
+	issues := Check(graph())
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d: %+v", len(issues), len(want), issues)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(issues[i], want[i]) {
+			t.Errorf("issue %d:\n got %+v\nwant %+v", i, issues[i], want[i])
+		}
+	}
+}
+
+func TestSuggestKeepsInstallSuggestions(t *testing.T) {
+	packuments := map[string]string{
+		"/react": `{"name": "react", "versions": {"17.0.2": {}, "18.2.0": {}}}`,
+		"/react-plugin": `{"name": "react-plugin", "versions": {
+			"1.0.0": {"peerDependencies": {"react": "^18.0.0"}},
+			"1.1.0": {"peerDependencies": {"react": "^17.0.0 || ^18.0.0"}}
+		}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := packuments[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := registry.NewClient(&registry.Npmrc{Registry: server.URL}, t.TempDir())
+
+	// As read from "Conflicting peer dependency: react@18.2.0"
+	fromInstall := Suggestion{Package: "react", Version: "18.2.0", Reason: "the version the conflicting dependent needs"}
+	issue := Issue{
+		Kind:      Unmet,
+		Name:      "react",
+		Installed: "17.0.2",
+		Requirements: []Requirement{
+			{Dependent: "react-plugin@1.0.0", Range: "^18.0.0", Peer: true, Resolved: "17.0.2"},
+		},
+		Suggestions: []Suggestion{fromInstall},
+	}
+
+	if err := Suggest(context.Background(), client, &issue); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry also suggests react 18.2.0, which is kept once
+	want := []Suggestion{
+		fromInstall,
+		{Package: "react-plugin", Version: "1.1.0", Reason: `peer react "^17.0.0 || ^18.0.0" accepts 17.0.2`},
+	}
+	if !reflect.DeepEqual(issue.Suggestions, want) {
+		t.Errorf("Suggestions = %+v, want %+v", issue.Suggestions, want)
+	}
+
+	// Without the registry the install suggestions stay
+	server.Close()
+	client = registry.NewClient(&registry.Npmrc{Registry: server.URL}, t.TempDir())
+	issue.Suggestions = []Suggestion{fromInstall}
+	if err := Suggest(context.Background(), client, &issue); err == nil {
+		t.Fatal("Suggest with the registry down succeeded")
+	}
+	if !reflect.DeepEqual(issue.Suggestions, []Suggestion{fromInstall}) {
+		t.Errorf("Suggestions with the registry down = %+v, want the install one", issue.Suggestions)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package process
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopSignals are sent in order when stopping a process group
+var stopSignals = []string{"SIGINT", "SIGTERM", "SIGKILL"}
+
+// setProcessGroup starts cmd in its own process group so the whole tree can be signalled.
+// Commands running under a pseudo-terminal lead a new session with the
+// terminal on stdin as its controlling terminal instead.
+func setProcessGroup(cmd *exec.Cmd, pty bool) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	if pty {
+		cmd.SysProcAttr.Setsid = true
+		cmd.SysProcAttr.Setctty = true
+		return
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalGroup sends the named stop signal to the process group led by pid
+func signalGroup(pid int, signal string) error {
+	sig := syscall.SIGKILL
+	switch signal {
+	case "SIGINT":
+		sig = syscall.SIGINT
+	case "SIGTERM":
+		sig = syscall.SIGTERM
+	}
+
+	// A negative pid addresses the whole group
+	if err := syscall.Kill(-pid, sig); err != nil {
+		return syscall.Kill(pid, sig)
+	}
+	return nil
+}
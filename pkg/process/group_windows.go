@@ -0,0 +1,30 @@
+//go:build windows
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// stopSignals are the stop steps on Windows: a polite taskkill, then a forced one
+var stopSignals = []string{"SIGTERM", "SIGKILL"}
+
+// setProcessGroup starts cmd in a new process group
+func setProcessGroup(cmd *exec.Cmd, pty bool) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// signalGroup stops the process tree rooted at pid with taskkill
+func signalGroup(pid int, signal string) error {
+	args := []string{"/T", "/PID", strconv.Itoa(pid)}
+	if signal == "SIGKILL" {
+		args = append([]string{"/F"}, args...)
+	}
+
+	return exec.Command("taskkill", args...).Run()
+}
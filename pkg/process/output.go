@@ -0,0 +1,180 @@
+package process
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stream identifies where a line of output came from
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+)
+
+// String returns the stream name
+func (s Stream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// Line is a single line written by a process
+type Line struct {
+	// Seq orders lines across every buffer, so outputs of several processes can be merged
+	Seq    uint64
+	Stream Stream
+	Text   string
+	Time   time.Time
+}
+
+// sequence is shared by all buffers
+var sequence uint64
+
+// DefaultMaxLines is the number of lines kept per process
+const DefaultMaxLines = 5000
+
+// Output is a bounded, thread-safe buffer of process output.
+// Lines from stdout and stderr are stored in the order they arrive.
+type Output struct {
+	mu       sync.Mutex
+	lines    []Line
+	maxLines int
+	// open is set while the last line is still being written (terminal output)
+	open bool
+}
+
+// NewOutput creates an output buffer keeping at most maxLines lines
+func NewOutput(maxLines int) *Output {
+	if maxLines <= 0 {
+		maxLines = DefaultMaxLines
+	}
+
+	return &Output{
+		maxLines: maxLines,
+	}
+}
+
+// Append adds a line to the buffer
+func (o *Output) Append(stream Stream, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.open = false
+	o.add(stream, text)
+}
+
+// add appends a line; the caller holds the lock
+func (o *Output) add(stream Stream, text string) {
+	o.lines = append(o.lines, Line{
+		Seq:    atomic.AddUint64(&sequence, 1),
+		Stream: stream,
+		Text:   text,
+		Time:   time.Now(),
+	})
+
+	// Drop the oldest lines in one go once twice the limit is reached, so
+	// that trimming costs O(1) per line; only the last maxLines are visible
+	if len(o.lines) >= 2*o.maxLines {
+		o.lines = append([]Line(nil), o.lines[len(o.lines)-o.maxLines:]...)
+	}
+}
+
+// visible returns the last maxLines lines; the caller holds the lock
+func (o *Output) visible() []Line {
+	if len(o.lines) > o.maxLines {
+		return o.lines[len(o.lines)-o.maxLines:]
+	}
+	return o.lines
+}
+
+// writeOpen sets the text of the unterminated last line, adding it if needed
+func (o *Output) writeOpen(stream Stream, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.open && len(o.lines) > 0 {
+		o.lines[len(o.lines)-1].Text = text
+		o.lines[len(o.lines)-1].Time = time.Now()
+		return
+	}
+
+	o.add(stream, text)
+	o.open = true
+}
+
+// endLine terminates the open line
+func (o *Output) endLine() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.open = false
+}
+
+// reopen moves the cursor up a line: an empty open line is dropped and the
+// previous line becomes open again. It returns the text of the reopened line.
+func (o *Output) reopen() (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.open && len(o.lines) > 0 && o.lines[len(o.lines)-1].Text == "" {
+		o.lines = o.lines[:len(o.lines)-1]
+	}
+
+	if len(o.lines) == 0 {
+		o.open = false
+		return "", false
+	}
+
+	o.open = true
+	return o.lines[len(o.lines)-1].Text, true
+}
+
+// Lines returns a copy of all buffered lines
+func (o *Output) Lines() []Line {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Line(nil), o.visible()...)
+}
+
+// Tail returns a copy of the last n lines
+func (o *Output) Tail(n int) []Line {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lines := o.visible()
+	if n > len(lines) {
+		n = len(lines)
+	}
+	return append([]Line(nil), lines[len(lines)-n:]...)
+}
+
+// Len returns the number of buffered lines
+func (o *Output) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.visible())
+}
+
+// Capture reads r line by line into the buffer until EOF
+func (o *Output) Capture(stream Stream, r io.Reader) {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			o.Append(stream, strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package process
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOutputKeepsLastLines(t *testing.T) {
+	o := NewOutput(3)
+	for i := 1; i <= 10; i++ {
+		o.Append(Stdout, fmt.Sprint(i))
+
+		want := i
+		if want > 3 {
+			want = 3
+		}
+		if o.Len() != want {
+			t.Fatalf("Len() = %d after %d lines, want %d", o.Len(), i, want)
+		}
+		lines := o.Lines()
+		if last := lines[len(lines)-1].Text; last != fmt.Sprint(i) {
+			t.Fatalf("last line = %q after %d lines", last, i)
+		}
+		if first := lines[0].Text; first != fmt.Sprint(i-want+1) {
+			t.Fatalf("first line = %q after %d lines, want %d", first, i, i-want+1)
+		}
+	}
+
+	tail := o.Tail(5)
+	if len(tail) != 3 || tail[0].Text != "8" || tail[2].Text != "10" {
+		t.Errorf("Tail(5) = %+v, want lines 8 to 10", tail)
+	}
+	if len(o.lines) >= 2*o.maxLines {
+		t.Errorf("buffer holds %d lines, want fewer than %d", len(o.lines), 2*o.maxLines)
+	}
+}
@@ -0,0 +1,254 @@
+package process
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// drainTimeout bounds how long output is read after the process exits
+const drainTimeout = time.Second
+
+// Options control how a process is started
+type Options struct {
+	// PTY runs the process under a pseudo-terminal so it keeps colors and prompts
+	PTY bool
+	// Cols and Rows are the initial terminal size when PTY is set
+	Cols int
+	Rows int
+	// Output reuses an existing buffer, e.g. when a process is restarted
+	Output *Output
+}
+
+// Process is a running command whose output is streamed into a buffer
+type Process struct {
+	Name   string
+	Cmd    *exec.Cmd
+	Output *Output
+	// readers tracks the goroutines draining the output
+	readers sync.WaitGroup
+	// readEnds are the files the readers drain, closed once the process is reaped
+	readEnds []*os.File
+	// terminal is the pty master when running under a pseudo-terminal
+	terminal *os.File
+	// tty is our copy of the pty slave, held until the process is reaped so
+	// output written just before it exits can still be read
+	tty  *os.File
+	cols int
+	rows int
+	mu   sync.Mutex
+	// exited is closed once Wait returns; err holds its result
+	exited   chan struct{}
+	exitOnce sync.Once
+	err      error
+}
+
+// PTYSupported reports whether pseudo-terminals are available on this platform
+func PTYSupported() bool {
+	return runtime.GOOS != "windows"
+}
+
+// Start starts cmd and streams its output into a new output buffer
+func Start(name string, cmd *exec.Cmd, opts Options) (*Process, error) {
+	p := &Process{
+		Name:   name,
+		Cmd:    cmd,
+		Output: opts.Output,
+		exited: make(chan struct{}),
+	}
+	if p.Output == nil {
+		p.Output = NewOutput(DefaultMaxLines)
+	}
+
+	// Run in a separate process group so stopping reaches every child
+	usePTY := opts.PTY && PTYSupported()
+	setProcessGroup(cmd, usePTY)
+
+	if usePTY {
+		if err := p.startTerminal(opts); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	// Our own pipes rather than StdoutPipe: cmd.Wait closes those, so they
+	// would have to be drained first, and a daemonised grandchild holding
+	// them open would keep the process from ever being reaped
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdout.Close()
+		stdoutWriter.Close()
+		return nil, err
+	}
+	cmd.Stdout, cmd.Stderr = stdoutWriter, stderrWriter
+
+	// Start the command; the child now holds the write ends
+	err = cmd.Start()
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
+		return nil, err
+	}
+	p.readEnds = []*os.File{stdout, stderr}
+
+	// Read both streams as they are written
+	p.readers.Add(2)
+	go func() {
+		defer p.readers.Done()
+		p.Output.Capture(Stdout, stdout)
+	}()
+	go func() {
+		defer p.readers.Done()
+		p.Output.Capture(Stderr, stderr)
+	}()
+
+	return p, nil
+}
+
+// startTerminal starts the command attached to a new pseudo-terminal
+func (p *Process) startTerminal(opts Options) error {
+	cols, rows := opts.Cols, opts.Rows
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+
+	// Tell tools they may use colors
+	if p.Cmd.Env == nil {
+		p.Cmd.Env = os.Environ()
+	}
+	p.Cmd.Env = append(p.Cmd.Env, "TERM=xterm-256color", "FORCE_COLOR=1")
+
+	terminal, tty, err := pty.Open()
+	if err != nil {
+		return err
+	}
+	if err := pty.Setsize(terminal, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)}); err != nil {
+		terminal.Close()
+		tty.Close()
+		return err
+	}
+
+	// setProcessGroup made stdin the controlling terminal
+	p.Cmd.Stdin, p.Cmd.Stdout, p.Cmd.Stderr = tty, tty, tty
+	if err := p.Cmd.Start(); err != nil {
+		terminal.Close()
+		tty.Close()
+		return err
+	}
+
+	p.terminal = terminal
+	p.tty = tty
+	p.readEnds = []*os.File{terminal}
+	p.cols, p.rows = cols, rows
+
+	// stdout and stderr share the terminal
+	p.readers.Add(1)
+	go func() {
+		defer p.readers.Done()
+		p.Output.captureTerminal(terminal)
+	}()
+
+	return nil
+}
+
+// Interactive reports whether the process accepts keyboard input
+func (p *Process) Interactive() bool {
+	return p.terminal != nil
+}
+
+// Write sends input to the process terminal
+func (p *Process) Write(data []byte) error {
+	if p.terminal == nil {
+		return nil
+	}
+
+	_, err := p.terminal.Write(data)
+	return err
+}
+
+// Resize changes the size of the process terminal
+func (p *Process) Resize(cols, rows int) error {
+	if p.terminal == nil || cols <= 0 || rows <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Avoid a resize on every render
+	if cols == p.cols && rows == p.rows {
+		return nil
+	}
+	p.cols, p.rows = cols, rows
+
+	return pty.Setsize(p.terminal, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// Exited returns a channel that is closed once the process has exited and been waited for
+func (p *Process) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// Err returns the result of Wait once the process has exited
+func (p *Process) Err() error {
+	<-p.exited
+	return p.err
+}
+
+// Pid returns the process id
+func (p *Process) Pid() int {
+	if p.Cmd.Process == nil {
+		return 0
+	}
+	return p.Cmd.Process.Pid
+}
+
+// Wait waits for the command to exit and for its output to be read.
+// It must be called once; other goroutines can use Exited and Err.
+func (p *Process) Wait() error {
+	err := p.wait()
+
+	p.exitOnce.Do(func() {
+		p.err = err
+		close(p.exited)
+	})
+
+	return err
+}
+
+// wait reaps the command and drains its output
+func (p *Process) wait() error {
+	err := p.Cmd.Wait()
+
+	// Reads from the terminal end once the last copy of the slave is
+	// closed and its buffered output has been read
+	if p.tty != nil {
+		p.tty.Close()
+	}
+
+	// A background child may keep the output open, so only drain briefly
+	drained := make(chan struct{})
+	go func() {
+		p.readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+	}
+	for _, f := range p.readEnds {
+		f.Close()
+	}
+
+	return err
+}
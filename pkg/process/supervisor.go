@@ -0,0 +1,457 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// State describes the lifecycle of a supervised process
+type State string
+
+const (
+	StateRunning    State = "running"
+	StateStopping   State = "stopping"
+	StateExited     State = "exited"
+	StateRestarting State = "restarting"
+)
+
+// ErrAlreadyRunning is returned when starting a process that is still running
+var ErrAlreadyRunning = errors.New("process is already running")
+
+// ErrNotFound is returned for names the supervisor does not know
+var ErrNotFound = errors.New("no such process")
+
+// Spec describes how to start a supervised process
+type Spec struct {
+	Name string
+	// Command builds a fresh command for every start and restart
+	Command func() *exec.Cmd
+	Options Options
+	// RestartOnCrash restarts the process with backoff when it exits non-zero
+	RestartOnCrash bool
+}
+
+// Status is a snapshot of a supervised process
+type Status struct {
+	Name           string
+	State          State
+	Pid            int
+	ExitCode       int
+	Err            error
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	Restarts       int
+	RestartOnCrash bool
+}
+
+// Duration returns how long the process ran, or has been running so far
+func (s Status) Duration() time.Duration {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+	if s.FinishedAt.IsZero() {
+		return time.Since(s.StartedAt)
+	}
+	return s.FinishedAt.Sub(s.StartedAt)
+}
+
+// Running reports whether the process is alive
+func (s Status) Running() bool {
+	return s.State == StateRunning || s.State == StateStopping
+}
+
+// Backoff controls the delay between crash restarts
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Reset clears the backoff once a run lasted at least this long
+	Reset time.Duration
+}
+
+// entry is the supervisor's record of one process
+type entry struct {
+	spec    Spec
+	proc    *Process
+	output  *Output
+	status  Status
+	crashes int
+	// stopping is set while Stop is in progress so the exit is not treated as a crash
+	stopping bool
+	// restartTimer is the pending crash restart, if any
+	restartTimer *time.Timer
+	// reaped is closed once the current process has exited and its status is recorded
+	reaped chan struct{}
+}
+
+// Supervisor owns a set of named processes: it starts them in their own
+// process group, stops them gracefully, records how they exited, reaps
+// them and optionally restarts them when they crash.
+type Supervisor struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	order   []string
+	// GracePeriod is how long to wait after each stop signal before escalating
+	GracePeriod time.Duration
+	Backoff     Backoff
+}
+
+// NewSupervisor creates a supervisor with the given grace period
+func NewSupervisor(gracePeriod time.Duration) *Supervisor {
+	if gracePeriod <= 0 {
+		gracePeriod = 3 * time.Second
+	}
+
+	return &Supervisor{
+		entries:     make(map[string]*entry),
+		GracePeriod: gracePeriod,
+		Backoff: Backoff{
+			Initial: time.Second,
+			Max:     30 * time.Second,
+			Reset:   time.Minute,
+		},
+	}
+}
+
+// Start starts the process described by spec.
+// A finished process with the same name is replaced, keeping its output buffer.
+func (s *Supervisor) Start(spec Spec) (*Process, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[spec.Name]
+	if ok && (e.status.Running() || e.status.State == StateRestarting) {
+		return nil, ErrAlreadyRunning
+	}
+
+	if !ok {
+		e = &entry{output: NewOutput(DefaultMaxLines)}
+		s.entries[spec.Name] = e
+		s.order = append(s.order, spec.Name)
+	} else {
+		e.crashes = 0
+		e.status.Restarts = 0
+	}
+	e.spec = spec
+
+	return s.startLocked(e)
+}
+
+// startLocked launches the entry's command; the caller holds the lock
+func (s *Supervisor) startLocked(e *entry) (*Process, error) {
+	opts := e.spec.Options
+	opts.Output = e.output
+
+	if e.proc != nil {
+		e.output.Append(Stdout, fmt.Sprintf("── %s restarted ──", e.spec.Name))
+	}
+
+	proc, err := Start(e.spec.Name, e.spec.Command(), opts)
+	if err != nil {
+		e.status.State = StateExited
+		e.status.Err = err
+		return nil, err
+	}
+
+	e.proc = proc
+	e.reaped = make(chan struct{})
+	e.stopping = false
+	e.status = Status{
+		Name:           e.spec.Name,
+		State:          StateRunning,
+		Pid:            proc.Pid(),
+		StartedAt:      time.Now(),
+		Restarts:       e.status.Restarts,
+		RestartOnCrash: e.spec.RestartOnCrash,
+	}
+
+	// Reap the process when it exits
+	go s.reap(e, proc)
+
+	return proc, nil
+}
+
+// reap waits for proc, records its exit and schedules a crash restart if needed
+func (s *Supervisor) reap(e *entry, proc *Process) {
+	err := proc.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Ignore a process that was already replaced
+	if e.proc != proc {
+		return
+	}
+
+	e.status.State = StateExited
+	e.status.FinishedAt = time.Now()
+	e.status.Err = err
+	e.status.ExitCode = exitCode(proc, err)
+	close(e.reaped)
+
+	crashed := e.status.ExitCode != 0 && !e.stopping
+	if !crashed || !e.spec.RestartOnCrash {
+		return
+	}
+
+	// Restart with exponential backoff, reset after a long healthy run
+	if e.status.Duration() >= s.Backoff.Reset {
+		e.crashes = 0
+	}
+	delay := s.Backoff.Initial << uint(e.crashes)
+	if delay > s.Backoff.Max || delay <= 0 {
+		delay = s.Backoff.Max
+	}
+	e.crashes++
+
+	e.status.State = StateRestarting
+	e.output.Append(Stderr, fmt.Sprintf("── %s crashed with exit code %d, restarting in %s ──",
+		e.spec.Name, e.status.ExitCode, delay))
+
+	e.restartTimer = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if e.status.State != StateRestarting {
+			return
+		}
+		e.restartTimer = nil
+		e.status.Restarts++
+		if _, err := s.startLocked(e); err != nil {
+			e.output.Append(Stderr, fmt.Sprintf("── restart failed: %v ──", err))
+		}
+	})
+}
+
+// exitCode extracts the exit code of a finished process
+func exitCode(proc *Process, err error) int {
+	if proc.Cmd.ProcessState != nil {
+		return proc.Cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+// Stop stops a process: each stop signal (SIGINT, SIGTERM, then SIGKILL) is
+// sent to the whole process group, waiting GracePeriod after each one.
+// It blocks until the process has exited.
+func (s *Supervisor) Stop(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+
+	// Cancel a pending crash restart
+	if e.restartTimer != nil {
+		e.restartTimer.Stop()
+		e.restartTimer = nil
+		e.status.State = StateExited
+	}
+
+	if e.status.State != StateRunning {
+		s.mu.Unlock()
+		return nil // Not running
+	}
+
+	e.stopping = true
+	e.status.State = StateStopping
+	pid := e.proc.Pid()
+	reaped := e.reaped
+	grace := s.GracePeriod
+	s.mu.Unlock()
+
+	for _, signal := range stopSignals {
+		if err := signalGroup(pid, signal); err != nil {
+			// The process may already be gone
+			select {
+			case <-reaped:
+				return nil
+			default:
+			}
+		}
+
+		select {
+		case <-reaped:
+			return nil
+		case <-time.After(grace):
+		}
+	}
+
+	// Give the final kill a moment to be reaped
+	select {
+	case <-reaped:
+		return nil
+	case <-time.After(grace):
+		return fmt.Errorf("process %s did not exit", name)
+	}
+}
+
+// Restart stops the process if it is running and starts it again
+func (s *Supervisor) Restart(name string) (*Process, error) {
+	if err := s.Stop(name); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if e.status.Running() {
+		return nil, ErrAlreadyRunning
+	}
+
+	e.status.Restarts++
+	return s.startLocked(e)
+}
+
+// StopAll stops every running process
+func (s *Supervisor) StopAll() {
+	var wg sync.WaitGroup
+	for _, name := range s.Names() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			s.Stop(name)
+		}(name)
+	}
+	wg.Wait()
+}
+
+// SetRestartOnCrash enables or disables crash restarts for a process
+func (s *Supervisor) SetRestartOnCrash(name string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrNotFound
+	}
+
+	e.spec.RestartOnCrash = enabled
+	e.status.RestartOnCrash = enabled
+	return nil
+}
+
+// Status returns the status of a process
+func (s *Supervisor) Status(name string) (Status, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return Status{}, false
+	}
+	return e.status, true
+}
+
+// Statuses returns the status of every process in start order
+func (s *Supervisor) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, s.entries[name].status)
+	}
+	return statuses
+}
+
+// Running returns the number of processes alive
+func (s *Supervisor) Running() int {
+	running := 0
+	for _, status := range s.Statuses() {
+		if status.Running() {
+			running++
+		}
+	}
+	return running
+}
+
+// Names returns the names of all processes in start order
+func (s *Supervisor) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.order...)
+}
+
+// Process returns the current process for a name
+func (s *Supervisor) Process(name string) (*Process, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok || e.proc == nil {
+		return nil, false
+	}
+	return e.proc, true
+}
+
+// Output returns the output buffer of a process, shared across restarts
+func (s *Supervisor) Output(name string) (*Output, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return nil, false
+	}
+	return e.output, true
+}
+
+// Wait blocks until the named process has exited and returns its status
+func (s *Supervisor) Wait(name string) (Status, error) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return Status{}, ErrNotFound
+	}
+	reaped := e.reaped
+	s.mu.Unlock()
+
+	// A process that failed to start was never reaped
+	if reaped != nil {
+		<-reaped
+	}
+
+	status, _ := s.Status(name)
+	return status, nil
+}
+
+// Remove forgets a finished process and its output
+func (s *Supervisor) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrNotFound
+	}
+	if e.status.Running() || e.status.State == StateRestarting {
+		return ErrAlreadyRunning
+	}
+
+	delete(s.entries, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package process
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shSpec describes a process running script with sh
+func shSpec(name, script string) Spec {
+	return Spec{
+		Name:    name,
+		Command: func() *exec.Cmd { return exec.Command("sh", "-c", script) },
+	}
+}
+
+// newTestSupervisor returns a supervisor with short grace periods and backoff
+func newTestSupervisor(t *testing.T) *Supervisor {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	s := NewSupervisor(100 * time.Millisecond)
+	s.Backoff = Backoff{Initial: 20 * time.Millisecond, Max: 40 * time.Millisecond, Reset: time.Minute}
+	t.Cleanup(s.StopAll)
+	return s
+}
+
+// waitForLine waits until the output has a line containing text
+func waitForLine(t *testing.T, output *Output, text string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, line := range output.Lines() {
+			if strings.Contains(line.Text, text) {
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no line containing %q in %q", text, texts(output))
+}
+
+func TestSupervisorRecordsExit(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	if _, err := s.Start(shSpec("fail", "echo out; echo err >&2; sleep 0.05; exit 3")); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	status, err := s.Wait("fail")
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	if status.State != StateExited || status.ExitCode != 3 || status.Err == nil {
+		t.Errorf("status = %s, exit code %d, err %v, want exited with code 3", status.State, status.ExitCode, status.Err)
+	}
+	if status.Pid == 0 {
+		t.Error("status has no pid")
+	}
+	if status.FinishedAt.Before(status.StartedAt) || status.Duration() < 50*time.Millisecond {
+		t.Errorf("duration = %s, want at least 50ms", status.Duration())
+	}
+
+	output, _ := s.Output("fail")
+	lines := output.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q, want out and err", texts(output))
+	}
+	for _, line := range lines {
+		want := Stdout
+		if line.Text == "err" {
+			want = Stderr
+		}
+		if line.Stream != want {
+			t.Errorf("%q was written to %s, want %s", line.Text, line.Stream, want)
+		}
+	}
+}
+
+func TestSupervisorReapsWithDaemonChild(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	// The background sleep inherits stdout and outlives its parent
+	if _, err := s.Start(shSpec("daemon", "sleep 10 & echo started")); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	proc, _ := s.Process("daemon")
+	defer signalGroup(proc.Pid(), "SIGKILL")
+
+	reaped := make(chan Status)
+	go func() {
+		status, _ := s.Wait("daemon")
+		reaped <- status
+	}()
+
+	select {
+	case status := <-reaped:
+		if status.State != StateExited || status.ExitCode != 0 {
+			t.Errorf("status = %s, exit code %d, want exited with code 0", status.State, status.ExitCode)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process holding its output open through a child was never reaped")
+	}
+
+	output, _ := s.Output("daemon")
+	waitForLine(t, output, "started")
+}
+
+func TestSupervisorStop(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		// signals is the number of stop signals needed
+		signals int
+	}{
+		{"exits on SIGINT", "echo ready; sleep 10", 1},
+		{"exits on SIGTERM", `trap "" INT; echo ready; sleep 10`, 2},
+		{"needs SIGKILL", `trap "" INT TERM; echo ready; sleep 10`, 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestSupervisor(t)
+
+			if _, err := s.Start(shSpec("server", tc.script)); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			output, _ := s.Output("server")
+			waitForLine(t, output, "ready")
+
+			started := time.Now()
+			if err := s.Stop("server"); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			elapsed := time.Since(started)
+
+			// Each signal the process ignores costs one grace period
+			minimum := time.Duration(tc.signals-1) * s.GracePeriod
+			if elapsed < minimum || elapsed >= minimum+s.GracePeriod {
+				t.Errorf("Stop took %s, want between %s and %s", elapsed, minimum, minimum+s.GracePeriod)
+			}
+
+			status, _ := s.Status("server")
+			if status.State != StateExited || status.Running() {
+				t.Errorf("state = %s after Stop, want exited", status.State)
+			}
+		})
+	}
+}
+
+func TestSupervisorRestart(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	first, err := s.Start(shSpec("server", "echo ready; sleep 10"))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := s.Start(shSpec("server", "sleep 10")); err != ErrAlreadyRunning {
+		t.Errorf("Start of a running process = %v, want ErrAlreadyRunning", err)
+	}
+
+	second, err := s.Restart("server")
+	if err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if second == first || second.Pid() == first.Pid() {
+		t.Error("Restart kept the old process")
+	}
+
+	status, _ := s.Status("server")
+	if status.State != StateRunning || status.Restarts != 1 {
+		t.Errorf("status = %s with %d restarts, want running with 1", status.State, status.Restarts)
+	}
+
+	// The output buffer is shared across restarts
+	output, _ := s.Output("server")
+	waitForLine(t, output, "server restarted")
+	if output != second.Output {
+		t.Error("restarted process writes to another buffer")
+	}
+}
+
+func TestSupervisorRestartsOnCrash(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	spec := shSpec("crash", "exit 1")
+	spec.RestartOnCrash = true
+	if _, err := s.Start(spec); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// The delay doubles up to the maximum
+	output, _ := s.Output("crash")
+	waitForLine(t, output, "crashed with exit code 1, restarting in 20ms")
+	waitForLine(t, output, "restarting in 40ms")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if status, _ := s.Status("crash"); status.Restarts >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("process was not restarted three times")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Stopping cancels the pending restart
+	if err := s.Stop("crash"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	s.Wait("crash")
+	time.Sleep(100 * time.Millisecond)
+	if status, _ := s.Status("crash"); status.State != StateExited {
+		t.Errorf("state = %s after Stop, want exited", status.State)
+	}
+}
+
+func TestSupervisorStopIsNotACrash(t *testing.T) {
+	s := newTestSupervisor(t)
+
+	spec := shSpec("server", "echo ready; sleep 10")
+	spec.RestartOnCrash = true
+	if _, err := s.Start(spec); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	output, _ := s.Output("server")
+	waitForLine(t, output, "ready")
+
+	if err := s.Stop("server"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	status, _ := s.Status("server")
+	if status.State != StateExited || status.Restarts != 0 {
+		t.Errorf("status = %s with %d restarts after Stop, want exited without restarts", status.State, status.Restarts)
+	}
+}
@@ -0,0 +1,161 @@
+package process
+
+import (
+	"io"
+	"strconv"
+)
+
+// terminalWriter turns the raw byte stream of a pseudo-terminal into lines.
+// SGR escape sequences (colors and styles) are kept; carriage returns,
+// erase-line and cursor-up sequences rewrite the current line so spinners
+// and interactive prompts render in place. Other control sequences are dropped.
+type terminalWriter struct {
+	output  *Output
+	current []byte
+	// pendingCR is set when the last byte was a carriage return
+	pendingCR bool
+	// escape holds an unfinished escape sequence across reads
+	escape []byte
+	// dirty is set when current differs from the open line in the buffer
+	dirty bool
+}
+
+// captureTerminal reads r until EOF, writing terminal output into the buffer
+func (o *Output) captureTerminal(r io.Reader) {
+	w := &terminalWriter{output: o}
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			w.write(buf[:n])
+		}
+		if err != nil {
+			// Keep whatever is left of the last line
+			if w.dirty {
+				o.writeOpen(Stdout, string(w.current))
+			}
+			o.endLine()
+			return
+		}
+	}
+}
+
+// write processes a chunk of terminal output
+func (w *terminalWriter) write(data []byte) {
+	for _, b := range data {
+		// Continue an escape sequence
+		if w.escape != nil {
+			w.escape = append(w.escape, b)
+			if w.escapeComplete() {
+				w.applyEscape()
+				w.escape = nil
+			}
+			continue
+		}
+
+		// A carriage return not followed by a newline moves to the start of the line
+		if w.pendingCR {
+			w.pendingCR = false
+			if b != '\n' {
+				w.current = w.current[:0]
+				w.dirty = true
+			}
+		}
+
+		switch b {
+		case '\n':
+			w.output.writeOpen(Stdout, string(w.current))
+			w.output.endLine()
+			w.current = w.current[:0]
+			w.dirty = false
+		case '\r':
+			w.pendingCR = true
+		case 0x1b:
+			w.escape = []byte{b}
+		case '\b':
+			if len(w.current) > 0 {
+				w.current = w.current[:len(w.current)-1]
+				w.dirty = true
+			}
+		case '\a':
+			// Ignore the bell
+		default:
+			w.current = append(w.current, b)
+			w.dirty = true
+		}
+	}
+
+	// Show the line being written, e.g. a prompt waiting for input
+	if w.dirty {
+		w.output.writeOpen(Stdout, string(w.current))
+		w.dirty = false
+	}
+}
+
+// escapeComplete reports whether the buffered escape sequence has ended
+func (w *terminalWriter) escapeComplete() bool {
+	if len(w.escape) < 2 {
+		return false
+	}
+
+	switch w.escape[1] {
+	case '[':
+		// CSI sequences end with a byte in the range @ to ~
+		last := w.escape[len(w.escape)-1]
+		return len(w.escape) > 2 && last >= 0x40 && last <= 0x7e
+	case ']':
+		// OSC sequences end with BEL or ESC \
+		last := w.escape[len(w.escape)-1]
+		return last == '\a' || (last == '\\' && w.escape[len(w.escape)-2] == 0x1b)
+	}
+
+	// Two byte sequences
+	return true
+}
+
+// applyEscape handles a complete escape sequence
+func (w *terminalWriter) applyEscape() {
+	if w.escape[1] != '[' {
+		return
+	}
+
+	final := w.escape[len(w.escape)-1]
+	params := string(w.escape[2 : len(w.escape)-1])
+
+	switch final {
+	case 'm':
+		// Keep colors and styles
+		w.current = append(w.current, w.escape...)
+		w.dirty = true
+	case 'K':
+		// Erase line; only erasing the whole line or up to the start is handled
+		if params == "2" || params == "1" {
+			w.current = w.current[:0]
+			w.dirty = true
+		}
+	case 'G':
+		// Cursor to column; only the first column is handled
+		if params == "" || params == "1" || params == "0" {
+			w.current = w.current[:0]
+			w.dirty = true
+		}
+	case 'A':
+		// Cursor up: reopen previous lines
+		count, err := strconv.Atoi(params)
+		if err != nil || count < 1 {
+			count = 1
+		}
+		if w.dirty {
+			w.output.writeOpen(Stdout, string(w.current))
+			w.dirty = false
+		}
+		for i := 0; i < count; i++ {
+			text, ok := w.output.reopen()
+			if !ok {
+				break
+			}
+			w.current = append(w.current[:0], text...)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package process
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// texts returns the text of every line in the buffer
+func texts(o *Output) []string {
+	var result []string
+	for _, line := range o.Lines() {
+		result = append(result, line.Text)
+	}
+	return result
+}
+
+func TestTerminalWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{"lines", []string{"one\r\ntwo\r\n"}, []string{"one", "two"}},
+		{"colors are kept", []string{"\x1b[31mred\x1b[0m\r\n"}, []string{"\x1b[31mred\x1b[0m"}},
+		{"carriage return rewrites the line", []string{"50%\r100%\r\n"}, []string{"100%"}},
+		{"erase line", []string{"waiting\x1b[2K\rdone\r\n"}, []string{"done"}},
+		{"escape split across reads", []string{"\x1b[3", "2mok\x1b[0m\r\n"}, []string{"\x1b[32mok\x1b[0m"}},
+		{"cursor up reopens a line", []string{"a\r\nb\r\n\x1b[1A\x1b[2Kc\r\n"}, []string{"a", "c"}},
+		{"backspace", []string{"ab\bc\r\n"}, []string{"ac"}},
+		{"open prompt", []string{"Continue? "}, []string{"Continue? "}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewOutput(DefaultMaxLines)
+			w := &terminalWriter{output: o}
+			for _, chunk := range tc.chunks {
+				w.write([]byte(chunk))
+			}
+			if got := texts(o); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("lines = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStartTerminal(t *testing.T) {
+	if !PTYSupported() {
+		t.Skip("pseudo-terminals are not supported on this platform")
+	}
+
+	cmd := exec.Command("sh", "-c", `printf 'one\ntwo\n'; printf '\033[31mred\033[0m\n'; printf '50%%\r100%%\n'; exit 3`)
+	p, err := Start("test", cmd, Options{PTY: true})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !p.Interactive() {
+		t.Error("process under a pseudo-terminal is not interactive")
+	}
+
+	err = p.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("Wait = %v, want exit code 3", err)
+	}
+
+	want := []string{"one", "two", "\x1b[31mred\x1b[0m", "100%"}
+	if got := texts(p.Output); !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DetectOptions control how far Detect looks for package.json
+type DetectOptions struct {
+	// Depth is the number of parent directories searched above the start
+	Depth int
+	// StopAtGitRoot keeps the search inside the git repository of the start
+	StopAtGitRoot bool
+}
+
+// DefaultDetectOptions searches up to 5 parents, as Detect always has
+func DefaultDetectOptions() DetectOptions {
+	return DetectOptions{Depth: 5}
+}
+
+// Detect attempts to find a package.json in the current or parent directories
+func Detect() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return DetectFrom(dir, DefaultDetectOptions())
+}
+
+// DetectFrom looks for a package.json in dir, then in its parents
+func DetectFrom(dir string, opts DetectOptions) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i <= opts.Depth; i++ {
+		packageJSONPath := filepath.Join(dir, "package.json")
+		if _, err := os.Stat(packageJSONPath); err == nil {
+			return packageJSONPath, nil
+		}
+
+		// Don't leave the repository for an unrelated package.json above it
+		if opts.StopAtGitRoot && isGitRoot(dir) {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", os.ErrNotExist
+}
+
+// Resolve returns the package.json of a path given by the user: the file
+// itself, or the project found from a directory
+func Resolve(path string, opts DetectOptions) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return filepath.Abs(path)
+	}
+	return DetectFrom(path, opts)
+}
+
+// isGitRoot reports whether dir is the top of a git repository. .git is a
+// file in worktrees and submodules.
+func isGitRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Discover lists the package.json files below dir, up to depth levels
+// down, sorted by path. The packages inside a project, node_modules and
+// hidden directories are not searched.
+func Discover(dir string, depth int) []string {
+	if depth <= 0 {
+		return nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	var found []string
+	filepath.WalkDir(dir, func(current string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() || current == dir {
+			return nil
+		}
+		if name := entry.Name(); name == "node_modules" || strings.HasPrefix(name, ".") {
+			return filepath.SkipDir
+		}
+
+		packageJSONPath := filepath.Join(current, "package.json")
+		if _, err := os.Stat(packageJSONPath); err == nil {
+			found = append(found, packageJSONPath)
+			return filepath.SkipDir
+		}
+
+		if rel, err := filepath.Rel(dir, current); err == nil && strings.Count(filepath.ToSlash(rel), "/")+1 >= depth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	sort.Strings(found)
+	return found
+}
+
+// ReadName returns the name field of a package.json, or the name of its
+// directory when it has none or can't be read
+func ReadName(packageJSONPath string) string {
+	var manifest struct {
+		Name string `json:"name"`
+	}
+	if data, err := os.ReadFile(packageJSONPath); err == nil {
+		json.Unmarshal(data, &manifest)
+	}
+	if manifest.Name != "" {
+		return manifest.Name
+	}
+	return filepath.Base(filepath.Dir(packageJSONPath))
+}
@@ -1,10 +1,12 @@
 package project
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"os"
+	"bytes"
 	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/history"
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
 )
 
 // Project represents a Node.js project
@@ -22,13 +24,17 @@ type Project struct {
 	Bugs            map[string]string `json:"bugs,omitempty"`
 	Keywords        []string          `json:"keywords,omitempty"`
 	Engines         map[string]string `json:"engines,omitempty"`
+	History         *history.Journal  // Records field edits, for undo
 	packageJSON     map[string]interface{}
+	doc             *packagejson.Document // The file as written, edited in place
+	revision        int                   // History revision the document was read at
 }
 
 // NewProject creates a new project from a package.json file
 func NewProject(packageJSONPath string) (*Project, error) {
 	project := &Project{
 		PackageJSONPath: packageJSONPath,
+		History:         history.Open(filepath.Dir(packageJSONPath)),
 	}
 
 	// Load the package.json
@@ -42,77 +48,170 @@ func NewProject(packageJSONPath string) (*Project, error) {
 // LoadPackageJSON loads the package.json file into the project
 func (p *Project) LoadPackageJSON() error {
 	// Read package.json
-	data, err := ioutil.ReadFile(p.PackageJSONPath)
+	doc, err := packagejson.Load(p.PackageJSONPath)
 	if err != nil {
 		return err
 	}
 
+	return p.use(doc)
+}
+
+// use takes the fields of the project from a document
+func (p *Project) use(doc *packagejson.Document) error {
+	// Start over so fields removed from the file don't linger
+	*p = Project{PackageJSONPath: p.PackageJSONPath, History: p.History, doc: doc, revision: p.History.Revision()}
+
 	// Parse package.json into a raw map first
-	if err := json.Unmarshal(data, &p.packageJSON); err != nil {
+	if err := doc.Unmarshal(&p.packageJSON); err != nil {
 		return err
 	}
 
 	// Then parse into the Project struct to get the main fields
-	if err := json.Unmarshal(data, p); err != nil {
-		return err
-	}
-
-	return nil
+	return doc.Unmarshal(p)
 }
 
-// SavePackageJSON saves the project to package.json
+// SavePackageJSON saves the project to package.json. Only the fields that
+// changed are rewritten; the rest of the file keeps its formatting. If the
+// file was edited elsewhere since it was loaded, a *packagejson.ConflictError
+// is returned and nothing is written: LoadPackageJSON drops the changes,
+// OverwritePackageJSON and MergePackageJSON keep them.
 func (p *Project) SavePackageJSON() error {
-	// Apply changes from the Project struct to the raw map
-	p.packageJSON["name"] = p.Name
-	p.packageJSON["version"] = p.Version
-
-	if p.Description != "" {
-		p.packageJSON["description"] = p.Description
+	changed, err := p.applyFields()
+	if err != nil {
+		return err
 	}
 
-	if p.Main != "" {
-		p.packageJSON["main"] = p.Main
+	description := "Edit package.json"
+	if len(changed) > 0 {
+		description = "Edit " + strings.Join(changed, ", ")
 	}
 
-	if p.Author != "" {
-		p.packageJSON["author"] = p.Author
-	}
+	return p.record(description, func() error {
+		// Write to package.json
+		if err := p.doc.Save(p.PackageJSONPath); err != nil {
+			return err
+		}
 
-	if p.License != "" {
-		p.packageJSON["license"] = p.License
-	}
+		// Keep the raw map in step with the file
+		p.packageJSON = nil
+		return p.doc.Unmarshal(&p.packageJSON)
+	})
+}
 
-	if p.Private {
-		p.packageJSON["private"] = p.Private
+// OverwritePackageJSON saves the project to package.json, discarding any
+// edits made elsewhere since it was loaded
+func (p *Project) OverwritePackageJSON() error {
+	if _, err := p.applyFields(); err != nil {
+		return err
 	}
 
-	if p.Repository != nil {
-		p.packageJSON["repository"] = p.Repository
+	return p.record("Overwrite package.json", func() error {
+		if err := p.doc.Overwrite(p.PackageJSONPath); err != nil {
+			return err
+		}
+
+		p.packageJSON = nil
+		return p.doc.Unmarshal(&p.packageJSON)
+	})
+}
+
+// MergePackageJSON saves the changes made to the project on top of the
+// edits made elsewhere. Fields changed on both sides are returned and
+// nothing is written.
+func (p *Project) MergePackageJSON() ([]string, error) {
+	if _, err := p.applyFields(); err != nil {
+		return nil, err
 	}
 
-	if p.Homepage != "" {
-		p.packageJSON["homepage"] = p.Homepage
+	conflicts, err := p.doc.Merge(p.PackageJSONPath)
+	if err != nil || len(conflicts) > 0 {
+		return conflicts, err
 	}
 
-	if p.Bugs != nil {
-		p.packageJSON["bugs"] = p.Bugs
+	err = p.record("Merge edits into package.json", func() error {
+		return p.doc.Save(p.PackageJSONPath)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if p.Keywords != nil {
-		p.packageJSON["keywords"] = p.Keywords
+	// Pick up the other side's changes too
+	return nil, p.use(p.doc)
+}
+
+// record runs a write of the project in the history. A document that was up
+// to date when the write starts stays so: the revision moves along with it.
+func (p *Project) record(description string, op func() error) error {
+	revision := -1
+	err := p.History.Record(description, func() error {
+		// Checked once the operations before this one are done
+		if p.History != nil && p.History.Revision() == p.revision {
+			revision = p.revision + 1
+		}
+		return op()
+	})
+	if revision >= 0 {
+		p.revision = revision
 	}
+	return err
+}
 
-	if p.Engines != nil {
-		p.packageJSON["engines"] = p.Engines
+// Refresh reads package.json again when LazyNode changed it since the
+// project read it, e.g. by installing a package, adding a script or undoing.
+// Edits made outside LazyNode are not picked up: saving reports them.
+func (p *Project) Refresh() error {
+	if p.History.Revision() == p.revision {
+		return nil
 	}
+	return p.LoadPackageJSON()
+}
 
-	// Write to package.json
-	data, err := json.MarshalIndent(p.packageJSON, "", "  ")
-	if err != nil {
-		return err
+// applyFields writes the fields of the Project struct into the document and
+// returns the keys that changed
+func (p *Project) applyFields() ([]string, error) {
+	// Apply changes from the Project struct, in package.json's usual order
+	fields := []struct {
+		key   string
+		value interface{}
+		set   bool
+	}{
+		{"name", p.Name, p.Name != "" || p.has("name")},
+		{"version", p.Version, p.Version != "" || p.has("version")},
+		{"description", p.Description, p.Description != ""},
+		{"main", p.Main, p.Main != ""},
+		{"author", p.Author, p.Author != ""},
+		{"license", p.License, p.License != ""},
+		{"private", p.Private, p.Private},
+		{"repository", p.Repository, p.Repository != nil},
+		{"homepage", p.Homepage, p.Homepage != ""},
+		{"bugs", p.Bugs, p.Bugs != nil},
+		{"keywords", p.Keywords, p.Keywords != nil},
+		{"engines", p.Engines, p.Engines != nil},
+	}
+
+	var changed []string
+	for _, field := range fields {
+		if !field.set {
+			continue
+		}
+
+		before := p.doc.Bytes()
+		if err := p.doc.Set([]string{field.key}, field.value); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(before, p.doc.Bytes()) {
+			changed = append(changed, field.key)
+		}
 	}
 
-	return ioutil.WriteFile(p.PackageJSONPath, data, os.ModePerm)
+	return changed, nil
+}
+
+// has reports whether package.json has a key, so an empty field it doesn't
+// have is not added
+func (p *Project) has(key string) bool {
+	_, ok := p.doc.Get(key)
+	return ok
 }
 
 // GetPackageJSON returns the raw package.json data
@@ -122,11 +221,20 @@ func (p *Project) GetPackageJSON() map[string]interface{} {
 
 // UpdateField updates a field in the package.json
 func (p *Project) UpdateField(key string, value interface{}) error {
-	// Update the raw package.json
-	p.packageJSON[key] = value
+	return p.record("Set "+key, func() error {
+		// Update the field in place
+		if err := p.doc.Set([]string{key}, value); err != nil {
+			return err
+		}
+
+		// Save the changes
+		if err := p.doc.Save(p.PackageJSONPath); err != nil {
+			return err
+		}
 
-	// Save the changes
-	return p.SavePackageJSON()
+		// Reload so the struct follows
+		return p.LoadPackageJSON()
+	})
 }
 
 // GetField gets a field from the package.json
@@ -134,30 +242,3 @@ func (p *Project) GetField(key string) (interface{}, bool) {
 	value, ok := p.packageJSON[key]
 	return value, ok
 }
-
-// Detect attempts to find a package.json in the current or parent directories
-func Detect() (string, error) {
-	// Start with the current directory
-	dir, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-
-	// Check if package.json exists in the current directory
-	packageJSONPath := filepath.Join(dir, "package.json")
-	if _, err := os.Stat(packageJSONPath); err == nil {
-		return packageJSONPath, nil
-	}
-
-	// Look in parent directories (up to 5 levels)
-	for i := 0; i < 5; i++ {
-		dir = filepath.Dir(dir)
-		packageJSONPath = filepath.Join(dir, "package.json")
-
-		if _, err := os.Stat(packageJSONPath); err == nil {
-			return packageJSONPath, nil
-		}
-	}
-
-	return "", os.ErrNotExist
-}
@@ -0,0 +1,217 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+)
+
+func TestSaveKeepsMissingNameAndVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	original := "{\n  \"private\": true,\n  \"description\": \"An app\",\n  \"scripts\": {\n    \"dev\": \"vite\"\n  }\n}\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Description = "The app"
+	if err := p.SavePackageJSON(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"private\": true,\n  \"description\": \"The app\",\n  \"scripts\": {\n    \"dev\": \"vite\"\n  }\n}\n"
+	if string(data) != want {
+		t.Errorf("package.json after editing the description:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestSaveClearsPresentVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(path, []byte("{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Version = ""
+	if err := p.SavePackageJSON(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"name\": \"app\",\n  \"version\": \"\"\n}\n"; string(data) != want {
+		t.Errorf("package.json after clearing the version:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestRefreshAfterRecordedWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(path, []byte("{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another part of LazyNode adds a dependency through the same history
+	err = p.History.Record("Add lodash", func() error {
+		return os.WriteFile(path, []byte("{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"dependencies\": {\n    \"lodash\": \"^4.17.21\"\n  }\n}\n"), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	p.Version = "1.1.0"
+	if err := p.SavePackageJSON(); err != nil {
+		t.Fatalf("saving after LazyNode's own write: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"name\": \"app\",\n  \"version\": \"1.1.0\",\n  \"dependencies\": {\n    \"lodash\": \"^4.17.21\"\n  }\n}\n"; string(data) != want {
+		t.Errorf("package.json:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestOutsideEditStillConflicts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(path, []byte("{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An editor changes the file, without going through the history
+	if err := os.WriteFile(path, []byte("{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"license\": \"MIT\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	p.Version = "1.1.0"
+	var conflict *packagejson.ConflictError
+	if err := p.SavePackageJSON(); !errors.As(err, &conflict) {
+		t.Fatalf("saving over an outside edit: %v, want a ConflictError", err)
+	}
+}
+
+// writeFiles creates the given files, with empty JSON objects for package.json, below dir
+func writeFiles(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectFrom(t *testing.T) {
+	dir := t.TempDir()
+	// An unrelated package.json above a repository, whose project is nested
+	writeFiles(t, dir, "package.json", "repo/.git", "repo/app/package.json")
+	if err := os.MkdirAll(filepath.Join(dir, "repo", "app", "src", "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		start string
+		opts  DetectOptions
+		want  string // "" when nothing is found
+	}{
+		{"in the start directory", "repo/app", DefaultDetectOptions(), "repo/app/package.json"},
+		{"in a parent", "repo/app/src/a/b", DefaultDetectOptions(), "repo/app/package.json"},
+		{"beyond the depth", "repo/app/src/a/b", DetectOptions{Depth: 2}, ""},
+		{"depth 0 only checks the start", "repo/app/src", DetectOptions{Depth: 0}, ""},
+		{"above the git root by default", "repo", DefaultDetectOptions(), "package.json"},
+		{"stopped at the git root", "repo", DetectOptions{Depth: 5, StopAtGitRoot: true}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DetectFrom(filepath.Join(dir, filepath.FromSlash(tc.start)), tc.opts)
+			if tc.want == "" {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("DetectFrom = %q, %v, want os.ErrNotExist", got, err)
+				}
+				return
+			}
+			if want := filepath.Join(dir, filepath.FromSlash(tc.want)); err != nil || got != want {
+				t.Errorf("DetectFrom = %q, %v, want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"api/package.json",
+		// Packages inside a project are not listed
+		"api/packages/lib/package.json",
+		"apps/web/package.json",
+		"apps/mobile/package.json",
+		"deep/a/b/package.json",
+		"node_modules/lodash/package.json",
+		".cache/tool/package.json",
+	)
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, nil},
+		{1, []string{"api/package.json"}},
+		{2, []string{"api/package.json", "apps/mobile/package.json", "apps/web/package.json"}},
+		{3, []string{"api/package.json", "apps/mobile/package.json", "apps/web/package.json", "deep/a/b/package.json"}},
+	}
+
+	for _, tc := range tests {
+		var got []string
+		for _, path := range Discover(dir, tc.depth) {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Discover(depth %d) = %q, want %q", tc.depth, got, tc.want)
+		}
+	}
+}
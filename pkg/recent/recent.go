@@ -0,0 +1,157 @@
+package recent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+)
+
+// maxProjects is how many projects the registry remembers
+const maxProjects = 50
+
+// Project is a project LazyNode opened
+type Project struct {
+	PackageJSONPath string    `json:"path"`
+	Name            string    `json:"name"`
+	OpenedAt        time.Time `json:"openedAt"`
+	Pinned          bool      `json:"pinned,omitempty"` // Listed first, and never forgotten
+}
+
+// Registry is the list of recently opened projects, shared by every
+// LazyNode session of the user
+type Registry struct {
+	Path     string    `json:"-"`
+	Projects []Project `json:"projects"` // Pinned first, then the most recently opened
+}
+
+// DataDir returns the user's LazyNode data directory: $XDG_DATA_HOME/lazynode,
+// ~/.local/share/lazynode by default, or %LOCALAPPDATA%\lazynode on Windows
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "lazynode"), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "lazynode"), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "lazynode"), nil
+}
+
+// Load reads the registry from the data directory. A missing registry is
+// an empty one.
+func Load() (*Registry, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{Path: filepath.Join(dir, "recent.json")}
+
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Save writes the registry to its file
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+
+	// Written aside and renamed, so a concurrent session never reads half a file
+	return packagejson.WriteFile(r.Path, data)
+}
+
+// Touch records that a project was just opened
+func (r *Registry) Touch(packageJSONPath, name string) {
+	if name == "" {
+		name = filepath.Base(filepath.Dir(packageJSONPath))
+	}
+	project := Project{PackageJSONPath: filepath.Clean(packageJSONPath), Name: name, OpenedAt: time.Now()}
+
+	projects := []Project{project}
+	for _, existing := range r.Projects {
+		if existing.PackageJSONPath != project.PackageJSONPath {
+			projects = append(projects, existing)
+		} else {
+			projects[0].Pinned = existing.Pinned
+		}
+	}
+	r.Projects = projects
+	r.sort()
+
+	// Forget the oldest projects that aren't pinned
+	for i := len(r.Projects) - 1; i >= 0 && len(r.Projects) > maxProjects; i-- {
+		if !r.Projects[i].Pinned {
+			r.Projects = append(r.Projects[:i], r.Projects[i+1:]...)
+		}
+	}
+}
+
+// TogglePin pins a project, or unpins a pinned one, and reports whether it
+// is pinned now
+func (r *Registry) TogglePin(packageJSONPath string) bool {
+	packageJSONPath = filepath.Clean(packageJSONPath)
+	pinned := false
+	for i := range r.Projects {
+		if r.Projects[i].PackageJSONPath == packageJSONPath {
+			r.Projects[i].Pinned = !r.Projects[i].Pinned
+			pinned = r.Projects[i].Pinned
+		}
+	}
+	r.sort()
+	return pinned
+}
+
+// Existing returns the projects whose package.json is still there
+func (r *Registry) Existing() []Project {
+	var projects []Project
+	for _, project := range r.Projects {
+		if _, err := os.Stat(project.PackageJSONPath); err == nil {
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
+
+// sort puts the pinned projects first, then the most recently opened
+func (r *Registry) sort() {
+	sort.SliceStable(r.Projects, func(i, j int) bool {
+		if r.Projects[i].Pinned != r.Projects[j].Pinned {
+			return r.Projects[i].Pinned
+		}
+		return r.Projects[i].OpenedAt.After(r.Projects[j].OpenedAt)
+	})
+}
+
+// Remember records that a project was opened in the registry of the user
+func Remember(packageJSONPath, name string) error {
+	r, err := Load()
+	if err != nil {
+		return err
+	}
+	r.Touch(packageJSONPath, name)
+	return r.Save()
+}
@@ -0,0 +1,165 @@
+package recent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// paths returns the package.json paths of the registry in order
+func paths(r *Registry) []string {
+	var result []string
+	for _, project := range r.Projects {
+		result = append(result, project.PackageJSONPath)
+	}
+	return result
+}
+
+func TestTouch(t *testing.T) {
+	r := &Registry{}
+	r.Touch("/a/package.json", "a")
+	r.Touch("/b/package.json", "")
+	r.Touch("/c/./package.json", "c")
+
+	if got, want := paths(r), []string{"/c/package.json", "/b/package.json", "/a/package.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %q, want the most recent first %q", got, want)
+	}
+	if name := r.Projects[1].Name; name != "b" {
+		t.Errorf("name without one = %q, want the directory b", name)
+	}
+
+	// Opening a project again moves it to the top, without a duplicate
+	r.Touch("/a/package.json", "a")
+	if got, want := paths(r), []string{"/a/package.json", "/c/package.json", "/b/package.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %q after opening a again, want %q", got, want)
+	}
+}
+
+func TestPinning(t *testing.T) {
+	r := &Registry{}
+	r.Touch("/a/package.json", "a")
+	r.Touch("/b/package.json", "b")
+	r.Touch("/c/package.json", "c")
+
+	if !r.TogglePin("/a/package.json") {
+		t.Fatal("TogglePin did not pin a")
+	}
+	if got, want := paths(r), []string{"/a/package.json", "/c/package.json", "/b/package.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %q, want the pinned one first %q", got, want)
+	}
+
+	// Opening a pinned project keeps it pinned; others stay below it
+	r.Touch("/a/package.json", "a")
+	r.Touch("/b/package.json", "b")
+	if got, want := paths(r), []string{"/a/package.json", "/b/package.json", "/c/package.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %q, want %q", got, want)
+	}
+	if !r.Projects[0].Pinned {
+		t.Error("opening a pinned project unpinned it")
+	}
+
+	if r.TogglePin("/a/package.json") {
+		t.Error("TogglePin did not unpin a")
+	}
+	if got, want := paths(r), []string{"/b/package.json", "/a/package.json", "/c/package.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("projects = %q after unpinning, want %q", got, want)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	r := &Registry{}
+	r.Touch("/pinned/package.json", "pinned")
+	r.TogglePin("/pinned/package.json")
+	for i := 0; i < maxProjects+5; i++ {
+		r.Touch(fmt.Sprintf("/p%02d/package.json", i), "")
+	}
+
+	if len(r.Projects) != maxProjects {
+		t.Fatalf("registry holds %d projects, want %d", len(r.Projects), maxProjects)
+	}
+	if r.Projects[0].PackageJSONPath != "/pinned/package.json" {
+		t.Errorf("first project = %s, want the pinned one kept", r.Projects[0].PackageJSONPath)
+	}
+
+	// The oldest unpinned projects are forgotten
+	last := r.Projects[len(r.Projects)-1].PackageJSONPath
+	if last != "/p06/package.json" {
+		t.Errorf("oldest project kept = %s, want /p06/package.json", last)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	project := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(project, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gone := filepath.Join(t.TempDir(), "gone", "package.json")
+
+	if err := Remember(gone, "gone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remember(project, "app"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := paths(r), []string{project, gone}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded projects = %q, want %q", got, want)
+	}
+	if opened := r.Projects[0].OpenedAt; time.Since(opened) > time.Minute {
+		t.Errorf("opened at %s, want now", opened)
+	}
+
+	// Projects whose package.json was removed are not offered
+	existing := r.Existing()
+	if len(existing) != 1 || existing[0].PackageJSONPath != project || existing[0].Name != "app" {
+		t.Errorf("Existing() = %+v, want only app", existing)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	// Sessions saving at once never leave a torn file behind
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := Load()
+			if err != nil {
+				t.Errorf("Load while another session saves: %v", err)
+				return
+			}
+			for j := 0; j < maxProjects; j++ {
+				r.Touch(fmt.Sprintf("/session%d/p%02d/package.json", i, j), "")
+			}
+			if err := r.Save(); err != nil {
+				t.Errorf("Save: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	r, err := Load()
+	if err != nil {
+		t.Fatalf("Load after concurrent saves: %v", err)
+	}
+	if len(r.Projects) == 0 {
+		t.Error("registry is empty after concurrent saves")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(r.Path))
+	if len(entries) != 1 {
+		t.Errorf("data directory holds %d files, want only recent.json", len(entries))
+	}
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is a registry response stored on disk
+type cacheEntry struct {
+	URL       string          `json:"url"`
+	ETag      string          `json:"etag,omitempty"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// Cache stores registry responses with their ETag so they can be
+// revalidated cheaply and served when offline
+type Cache struct {
+	Dir string
+}
+
+// CacheDir returns the registry cache location for the given project directory
+func CacheDir(projectDir string) string {
+	return filepath.Join(projectDir, ".lazynode", "registry-cache")
+}
+
+// path returns the file caching the response for a key
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns the cached response for a key, or nil
+func (c *Cache) get(key string) *cacheEntry {
+	if c == nil || c.Dir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// put stores a response. Failing to write the cache is not an error.
+func (c *Cache) put(key string, entry *cacheEntry) {
+	if c == nil || c.Dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return
+	}
+
+	// Write to a temporary file first so readers never see half an entry.
+	// Its name is unique: two fetches of the same key may write at once.
+	tmp, err := os.CreateTemp(c.Dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrOffline is returned in offline mode for responses that were never cached
+var ErrOffline = errors.New("offline and not in the registry cache")
+
+// ErrNotFound is returned for packages the registry doesn't know
+var ErrNotFound = errors.New("package not found")
+
+// Accept headers of the full and abbreviated (install) packument
+const (
+	acceptFull        = "application/json"
+	acceptAbbreviated = "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8"
+)
+
+// Client talks to the npm registry API
+type Client struct {
+	Npmrc      *Npmrc
+	Cache      *Cache
+	HTTPClient *http.Client
+	// Offline serves every request from the cache without touching the network
+	Offline bool
+}
+
+// NewClient creates a registry client using the given settings and cache directory
+func NewClient(npmrc *Npmrc, cacheDir string) *Client {
+	return &Client{
+		Npmrc:      npmrc,
+		Cache:      &Cache{Dir: cacheDir},
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Offline:    npmrc.Offline,
+	}
+}
+
+// NewProjectClient creates a client for a project: its .npmrc settings and
+// a cache under .lazynode/
+func NewProjectClient(projectDir string) *Client {
+	return NewClient(LoadNpmrc(projectDir), CacheDir(projectDir))
+}
+
+// packageURL returns the packument URL of a package. Scoped names keep
+// their @ and escape the slash, as the registry expects.
+func (c *Client) packageURL(name string) string {
+	return c.Npmrc.RegistryFor(name) + strings.Replace(url.PathEscape(name), "%40", "@", 1)
+}
+
+// get fetches a URL, revalidating the cached response with its ETag. When
+// the network fails a cached response is served even if it may be stale.
+func (c *Client) get(ctx context.Context, requestURL, accept string) ([]byte, error) {
+	return c.fetch(ctx, requestURL, accept, c.Cache)
+}
+
+// fetch fetches a URL through the given cache, which may be nil
+func (c *Client) fetch(ctx context.Context, requestURL, accept string, cache *Cache) ([]byte, error) {
+	key := cacheKey(accept, requestURL)
+	cached := cache.get(key)
+
+	if c.Offline {
+		if cached == nil {
+			return nil, ErrOffline
+		}
+		return cached.Body, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "lazynode")
+	if auth := c.Npmrc.Authorization(requestURL); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		// Serve the cache without a network, unless the request was cancelled
+		if cached != nil && ctx.Err() == nil {
+			return cached.Body, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.Body, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("registry returned %s for %s", resp.Status, requestURL)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("registry returned invalid JSON for %s", requestURL)
+	}
+
+	cache.put(key, &cacheEntry{
+		URL:       requestURL,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: time.Now(),
+		Body:      body,
+	})
+
+	return body, nil
+}
+
+// Packument fetches the full metadata of a package, including its readme
+func (c *Client) Packument(ctx context.Context, name string) (*Packument, error) {
+	return c.packument(ctx, name, acceptFull)
+}
+
+// AbbreviatedPackument fetches the install metadata of a package: its
+// dist-tags and versions with their dependencies, which is much smaller
+func (c *Client) AbbreviatedPackument(ctx context.Context, name string) (*Packument, error) {
+	return c.packument(ctx, name, acceptAbbreviated)
+}
+
+// packument fetches and parses a packument
+func (c *Client) packument(ctx context.Context, name, accept string) (*Packument, error) {
+	body, err := c.get(ctx, c.packageURL(name), accept)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var packument Packument
+	if err := json.Unmarshal(body, &packument); err != nil {
+		return nil, fmt.Errorf("%s: invalid packument: %v", name, err)
+	}
+	return &packument, nil
+}
+
+// CachedPackument returns the full packument of a package if the cache
+// has it, without touching the network
+func (c *Client) CachedPackument(name string) (*Packument, bool) {
+	cached := c.Cache.get(cacheKey(acceptFull, c.packageURL(name)))
+	if cached == nil {
+		return nil, false
+	}
+
+	var packument Packument
+	if err := json.Unmarshal(cached.Body, &packument); err != nil {
+		return nil, false
+	}
+	return &packument, true
+}
+
+// cacheKey returns the key of a response in the cache: the same URL is
+// cached apart for each Accept header
+func cacheKey(accept, requestURL string) string {
+	return accept + " " + requestURL
+}
+
+// Search queries the registry search endpoint. Results are not cached:
+// every query typed would leave a file behind, so searches need a network.
+func (c *Client) Search(ctx context.Context, text string, size int) ([]SearchResult, error) {
+	query := url.Values{}
+	query.Set("text", text)
+	query.Set("size", fmt.Sprint(size))
+
+	body, err := c.fetch(ctx, c.Npmrc.RegistryFor("")+"-/v1/search?"+query.Encode(), acceptFull, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Objects []struct {
+			Package   SearchResult `json:"package"`
+			Downloads struct {
+				Weekly  int `json:"weekly"`
+				Monthly int `json:"monthly"`
+			} `json:"downloads"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid search response: %v", err)
+	}
+
+	results := make([]SearchResult, 0, len(response.Objects))
+	for _, object := range response.Objects {
+		result := object.Package
+		result.WeeklyDownloads = object.Downloads.Weekly
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,333 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// standIn is a local registry serving fixed documents by request URI, with
+// an ETag, and recording the requests it gets
+type standIn struct {
+	*httptest.Server
+	documents map[string]string // Request URI to JSON body
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+// newStandIn starts a stand-in registry serving documents
+func newStandIn(t *testing.T, documents map[string]string) *standIn {
+	s := &standIn{documents: documents}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.mu.Unlock()
+
+		body, ok := s.documents[r.RequestURI]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		etag := `"` + r.RequestURI + `-v1"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// request returns the nth request the registry got
+func (s *standIn) request(t *testing.T, n int) *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n >= len(s.requests) {
+		t.Fatalf("registry got %d requests, want at least %d", len(s.requests), n+1)
+	}
+	return s.requests[n]
+}
+
+// count returns the number of requests the registry got
+func (s *standIn) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// host returns the host:port of the registry, as used in .npmrc auth keys
+func (s *standIn) host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// newTestClient creates a client for the registry at registryURL with a
+// fresh cache
+func newTestClient(t *testing.T, registryURL string, npmrcLines ...string) *Client {
+	rc := &Npmrc{Registry: registryURL}
+	for _, line := range npmrcLines {
+		rc.Set(line)
+	}
+	return NewClient(rc, t.TempDir())
+}
+
+const lodashPackument = `{
+	"name": "lodash",
+	"dist-tags": {"latest": "4.17.21"},
+	"versions": {
+		"4.17.20": {"name": "lodash", "version": "4.17.20"},
+		"4.17.21": {"name": "lodash", "version": "4.17.21"}
+	}
+}`
+
+func TestPackumentRevalidatesWithETag(t *testing.T) {
+	registry := newStandIn(t, map[string]string{"/lodash": lodashPackument})
+	client := newTestClient(t, registry.URL)
+
+	first, err := client.Packument(context.Background(), "lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := registry.request(t, 0).Header.Get("If-None-Match"); got != "" {
+		t.Errorf("first request sent If-None-Match %q, want none", got)
+	}
+
+	second, err := client.Packument(context.Background(), "lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := registry.request(t, 1).Header.Get("If-None-Match"), `"/lodash-v1"`; got != want {
+		t.Errorf("second request sent If-None-Match %q, want %q", got, want)
+	}
+
+	// The 304 is answered from the cache
+	if second.Latest() != "4.17.21" || len(second.Versions) != len(first.Versions) {
+		t.Errorf("revalidated packument = latest %q with %d versions, want %q with %d",
+			second.Latest(), len(second.Versions), first.Latest(), len(first.Versions))
+	}
+}
+
+func TestAbbreviatedAndFullAreCachedApart(t *testing.T) {
+	registry := newStandIn(t, map[string]string{"/lodash": lodashPackument})
+	client := newTestClient(t, registry.URL)
+
+	if _, err := client.AbbreviatedPackument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Packument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+
+	full := registry.request(t, 1)
+	if got := full.Header.Get("Accept"); got != acceptFull {
+		t.Errorf("full packument Accept = %q, want %q", got, acceptFull)
+	}
+	if got := full.Header.Get("If-None-Match"); got != "" {
+		t.Errorf("full packument revalidated the abbreviated one with %q", got)
+	}
+}
+
+func TestCachedPackument(t *testing.T) {
+	registry := newStandIn(t, map[string]string{"/lodash": lodashPackument})
+	client := newTestClient(t, registry.URL)
+
+	if _, ok := client.CachedPackument("lodash"); ok {
+		t.Fatal("CachedPackument found lodash before it was fetched")
+	}
+
+	// The abbreviated packument doesn't stand in for the full one
+	if _, err := client.AbbreviatedPackument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.CachedPackument("lodash"); ok {
+		t.Fatal("CachedPackument found lodash after fetching the abbreviated packument only")
+	}
+
+	if _, err := client.Packument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+	requests := registry.count()
+	packument, ok := client.CachedPackument("lodash")
+	if !ok || packument.Latest() != "4.17.21" {
+		t.Fatalf("CachedPackument after fetching = %+v, %v, want lodash", packument, ok)
+	}
+	if registry.count() != requests {
+		t.Errorf("CachedPackument sent %d requests, want none", registry.count()-requests)
+	}
+}
+
+func TestScopedPackageURL(t *testing.T) {
+	registry := newStandIn(t, map[string]string{
+		"/@scope%2Fname": `{"name": "@scope/name", "dist-tags": {"latest": "1.0.0"}}`,
+	})
+	client := newTestClient(t, registry.URL)
+
+	packument, err := client.Packument(context.Background(), "@scope/name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packument.Name != "@scope/name" {
+		t.Errorf("Packument name = %q, want @scope/name", packument.Name)
+	}
+	if got := registry.request(t, 0).RequestURI; got != "/@scope%2Fname" {
+		t.Errorf("requested %s, want /@scope%%2Fname", got)
+	}
+}
+
+func TestScopeRegistryAndAuthToken(t *testing.T) {
+	registry := newStandIn(t, map[string]string{
+		"/public/lodash":       lodashPackument,
+		"/corp/npm/@corp%2Fui": `{"name": "@corp/ui"}`,
+		"/public/@other%2Fui":  `{"name": "@other/ui"}`,
+	})
+	client := newTestClient(t, registry.URL+"/public",
+		"@corp:registry="+registry.URL+"/corp/npm/",
+		"//"+registry.host()+"/:_authToken=root-token",
+		"//"+registry.host()+"/corp/:_authToken=corp-token",
+		"//"+registry.host()+"/corp/npm/:_authToken=npm-token",
+	)
+
+	tests := []struct {
+		name          string
+		uri           string
+		authorization string
+	}{
+		// The scope goes to its registry, with the token of the longest prefix
+		{"@corp/ui", "/corp/npm/@corp%2Fui", "Bearer npm-token"},
+		// Other packages go to the default registry, with the host token
+		{"lodash", "/public/lodash", "Bearer root-token"},
+		{"@other/ui", "/public/@other%2Fui", "Bearer root-token"},
+	}
+	for i, tc := range tests {
+		if _, err := client.Packument(context.Background(), tc.name); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		request := registry.request(t, i)
+		if request.RequestURI != tc.uri {
+			t.Errorf("%s requested %s, want %s", tc.name, request.RequestURI, tc.uri)
+		}
+		if got := request.Header.Get("Authorization"); got != tc.authorization {
+			t.Errorf("%s sent Authorization %q, want %q", tc.name, got, tc.authorization)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	registry := newStandIn(t, nil)
+	client := newTestClient(t, registry.URL)
+
+	_, err := client.Packument(context.Background(), "no-such-package")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Packument error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	registry := newStandIn(t, map[string]string{"/lodash": lodashPackument})
+	client := newTestClient(t, registry.URL)
+	client.Offline = true
+
+	_, err := client.Packument(context.Background(), "lodash")
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("Packument error on a cache miss = %v, want ErrOffline", err)
+	}
+	if registry.count() != 0 {
+		t.Fatalf("offline client sent %d requests, want none", registry.count())
+	}
+
+	// Once cached, offline requests are served from the cache
+	client.Offline = false
+	if _, err := client.Packument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+	client.Offline = true
+	packument, err := client.Packument(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("Packument offline after caching: %v", err)
+	}
+	if packument.Latest() != "4.17.21" || registry.count() != 1 {
+		t.Errorf("offline packument latest %q after %d requests, want 4.17.21 after 1", packument.Latest(), registry.count())
+	}
+}
+
+func TestStaleCacheOnNetworkError(t *testing.T) {
+	registry := newStandIn(t, map[string]string{"/lodash": lodashPackument})
+	client := newTestClient(t, registry.URL)
+
+	if _, err := client.Packument(context.Background(), "lodash"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry goes away: the cached response is served
+	registry.Close()
+	packument, err := client.Packument(context.Background(), "lodash")
+	if err != nil {
+		t.Fatalf("Packument with the registry down: %v", err)
+	}
+	if packument.Latest() != "4.17.21" {
+		t.Errorf("stale packument latest = %q, want 4.17.21", packument.Latest())
+	}
+
+	// Without a cached response the network error is returned
+	if _, err := client.Packument(context.Background(), "react"); err == nil {
+		t.Error("Packument of an uncached package with the registry down succeeded")
+	}
+}
+
+func TestSearchIsNotCached(t *testing.T) {
+	registry := newStandIn(t, map[string]string{
+		"/-/v1/search?size=5&text=lod": `{"objects": [{"package": {"name": "lodash", "version": "4.17.21"}, "downloads": {"weekly": 100}}]}`,
+	})
+	client := newTestClient(t, registry.URL)
+
+	results, err := client.Search(context.Background(), "lod", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "lodash" || results[0].WeeklyDownloads != 100 {
+		t.Errorf("Search = %+v, want lodash with 100 weekly downloads", results)
+	}
+
+	if entries, _ := os.ReadDir(client.Cache.Dir); len(entries) != 0 {
+		t.Errorf("search left %d files in the cache, want none", len(entries))
+	}
+
+	// So offline searches have nothing to serve
+	client.Offline = true
+	if _, err := client.Search(context.Background(), "lod", 5); !errors.Is(err, ErrOffline) {
+		t.Errorf("offline Search error = %v, want ErrOffline", err)
+	}
+}
+
+func TestConcurrentCacheWrites(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"writer": %d, "padding": %q}`, i, strings.Repeat("x", 64*1024))
+			cache.put("key", &cacheEntry{URL: "https://registry.npmjs.org/key", Body: json.RawMessage(body)})
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.get("key") == nil {
+		t.Error("cache entry written concurrently is unreadable")
+	}
+	if entries, _ := os.ReadDir(cache.Dir); len(entries) != 1 {
+		t.Errorf("cache holds %d files, want only the entry", len(entries))
+	}
+}
@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistry is the public npm registry
+const DefaultRegistry = "https://registry.npmjs.org/"
+
+// Npmrc holds the registry settings read from .npmrc files
+type Npmrc struct {
+	// Registry is the default registry URL
+	Registry string
+	// Scopes maps a scope such as "@myorg" to its registry URL
+	Scopes map[string]string
+	// Offline serves every request from the cache
+	Offline bool
+	// auth holds the per-registry settings keyed by "//host/path/:key"
+	auth map[string]string
+}
+
+// envVariable matches ${NAME} references, which npm expands in .npmrc values
+var envVariable = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// LoadNpmrc reads the user's ~/.npmrc and then the project's .npmrc, the
+// project winning, and applies npm_config_registry from the environment
+func LoadNpmrc(projectDir string) *Npmrc {
+	rc := &Npmrc{
+		Registry: DefaultRegistry,
+		Scopes:   make(map[string]string),
+		auth:     make(map[string]string),
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		rc.ReadFile(filepath.Join(home, ".npmrc"))
+	}
+	rc.ReadFile(filepath.Join(projectDir, ".npmrc"))
+
+	if registry := os.Getenv("npm_config_registry"); registry != "" {
+		rc.Registry = registry
+	}
+
+	return rc
+}
+
+// ReadFile merges the settings of an .npmrc file. A missing file is ignored.
+func (rc *Npmrc) ReadFile(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		rc.Set(scanner.Text())
+	}
+}
+
+// Set applies one "key=value" line of an .npmrc file
+func (rc *Npmrc) Set(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return
+	}
+
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return
+	}
+	key = strings.TrimSpace(key)
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+	// Expand ${NPM_TOKEN} and friends
+	value = envVariable.ReplaceAllStringFunc(value, func(ref string) string {
+		return os.Getenv(envVariable.FindStringSubmatch(ref)[1])
+	})
+
+	if rc.Scopes == nil {
+		rc.Scopes = make(map[string]string)
+	}
+	if rc.auth == nil {
+		rc.auth = make(map[string]string)
+	}
+
+	switch {
+	case key == "registry":
+		rc.Registry = value
+	case key == "offline":
+		rc.Offline = value == "true"
+	case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+		rc.Scopes[strings.TrimSuffix(key, ":registry")] = value
+	case strings.HasPrefix(key, "//"):
+		rc.auth[key] = value
+	}
+}
+
+// RegistryFor returns the registry serving a package, honoring scope registries
+func (rc *Npmrc) RegistryFor(name string) string {
+	registry := rc.Registry
+	if strings.HasPrefix(name, "@") {
+		scope, _, _ := strings.Cut(name, "/")
+		if scoped, ok := rc.Scopes[scope]; ok {
+			registry = scoped
+		}
+	}
+
+	if registry == "" {
+		registry = DefaultRegistry
+	}
+	if !strings.HasSuffix(registry, "/") {
+		registry += "/"
+	}
+	return registry
+}
+
+// Authorization returns the Authorization header for a request URL, or "".
+// Like npm it uses the settings of the longest "//host/path/" prefix of the URL.
+func (rc *Npmrc) Authorization(requestURL string) string {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+
+	// Try //host/a/b/, then //host/a/, then //host/
+	path := u.Path
+	for {
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			path = path[:i+1]
+		} else {
+			path = "/"
+		}
+		prefix := "//" + u.Host + path
+
+		if token := rc.auth[prefix+":_authToken"]; token != "" {
+			return "Bearer " + token
+		}
+		if auth := rc.auth[prefix+":_auth"]; auth != "" {
+			return "Basic " + auth
+		}
+		if username := rc.auth[prefix+":username"]; username != "" {
+			password, _ := base64.StdEncoding.DecodeString(rc.auth[prefix+":_password"])
+			return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+string(password)))
+		}
+
+		if path == "/" {
+			return ""
+		}
+		path = strings.TrimSuffix(path, "/")
+	}
+}
@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+)
+
+// Packument is the registry document describing a package and its versions
+type Packument struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	DistTags    map[string]string         `json:"dist-tags"`
+	Versions    map[string]PackageVersion `json:"versions"`
+	Time        map[string]string         `json:"time"`
+	Readme      string                    `json:"readme"`
+	Homepage    string                    `json:"homepage"`
+	Repository  Link                      `json:"repository"`
+	License     License                   `json:"license"`
+	Keywords    []string                  `json:"keywords"`
+	Maintainers []Person                  `json:"maintainers"`
+}
+
+// PackageVersion is the manifest of one published version
+type PackageVersion struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Description          string            `json:"description"`
+	Deprecated           string            `json:"deprecated"`
+	License              License           `json:"license"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	PeerDependenciesMeta map[string]struct {
+		Optional bool `json:"optional"`
+	} `json:"peerDependenciesMeta"`
+	Engines map[string]string `json:"engines"`
+	Bin     Bin               `json:"bin"`
+	Dist    struct {
+		Tarball      string `json:"tarball"`
+		Integrity    string `json:"integrity"`
+		FileCount    int    `json:"fileCount"`
+		UnpackedSize int64  `json:"unpackedSize"`
+	} `json:"dist"`
+}
+
+// Person is a maintainer or author
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Link is a repository or bugs field, written as a URL or as {"url": ...}
+type Link struct {
+	URL string
+}
+
+// UnmarshalJSON accepts both the string and the object form
+func (l *Link) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &l.URL) == nil {
+		return nil
+	}
+
+	var object struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil // Ignore unexpected shapes
+	}
+	l.URL = object.URL
+	return nil
+}
+
+// Bin maps command names to scripts. A plain string bin is named after
+// the package, without its scope.
+type Bin map[string]string
+
+// UnmarshalJSON accepts both the string and the object form. The string
+// form is stored under "" until the package name is known, see Named.
+func (b *Bin) UnmarshalJSON(data []byte) error {
+	var path string
+	if json.Unmarshal(data, &path) == nil {
+		*b = Bin{"": path}
+		return nil
+	}
+
+	var commands map[string]string
+	if json.Unmarshal(data, &commands) == nil {
+		*b = commands
+	}
+	return nil
+}
+
+// Named returns the commands with a string bin named after the package
+func (b Bin) Named(packageName string) map[string]string {
+	commands := make(map[string]string, len(b))
+	for name, path := range b {
+		if name == "" {
+			name = packageName[strings.LastIndex(packageName, "/")+1:]
+		}
+		commands[name] = path
+	}
+	return commands
+}
+
+// License is a license field, written as an SPDX string or the legacy {"type": ...}
+type License string
+
+// UnmarshalJSON accepts both the string and the object form
+func (l *License) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*l = License(s)
+		return nil
+	}
+
+	var object struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &object) == nil {
+		*l = License(object.Type)
+	}
+	return nil
+}
+
+// Latest returns the version of the latest dist-tag
+func (p *Packument) Latest() string {
+	return p.DistTags["latest"]
+}
+
+// VersionList returns the published versions, oldest first
+func (p *Packument) VersionList() []string {
+	versions := make([]string, 0, len(p.Versions))
+	for version := range p.Versions {
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// MaxSatisfying returns the version npm would install for a range: the
+// latest tag when it satisfies the range, otherwise the highest match
+func (p *Packument) MaxSatisfying(rng string) string {
+	if latest := p.Latest(); latest != "" && semver.Satisfies(latest, rng) {
+		return latest
+	}
+	return semver.MaxSatisfying(p.VersionList(), rng)
+}
+
+// SearchResult is a package returned by the search endpoint
+type SearchResult struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	Description     string   `json:"description"`
+	Keywords        []string `json:"keywords"`
+	Date            string   `json:"date"`
+	WeeklyDownloads int      `json:"-"`
+}
@@ -3,9 +3,14 @@ package scripts
 import (
 	"encoding/json"
 	"io/ioutil"
-	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/VesperAkshay/lazynode/pkg/config"
+	"github.com/VesperAkshay/lazynode/pkg/history"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
+	"github.com/VesperAkshay/lazynode/pkg/process"
 )
 
 // Script represents an npm script
@@ -14,19 +19,42 @@ type Script struct {
 	Command string `json:"command"`
 }
 
-// ScriptRunner handles npm script operations
+// ScriptRunner handles package.json script operations
 type ScriptRunner struct {
 	PackageJSONPath string
 	Scripts         []Script
-	RunningScripts  map[string]*exec.Cmd
+	Client          npm.Client
+	UsePTY          bool                // Run scripts under a pseudo-terminal
+	Supervisor      *process.Supervisor // Owns the running script processes
+	History         *history.Journal    // Records script edits, for undo
+	// Workspace names the project within its monorepo, whose root is
+	// RootDir. Scripts then run from the root with the workspace flag.
+	Workspace string
+	RootDir   string
+	// LastWorkspaceRun is the last script run across the workspaces, if any
+	LastWorkspaceRun *WorkspaceRun
 }
 
 // NewScriptRunner creates a new script runner for the given project
 func NewScriptRunner(packageJSONPath string) (*ScriptRunner, error) {
+	// Run scripts through the project's package manager
+	client, err := npm.DetectClient(packageJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(filepath.Dir(packageJSONPath))
+	if err != nil {
+		return nil, err
+	}
+
 	sr := &ScriptRunner{
 		PackageJSONPath: packageJSONPath,
 		Scripts:         []Script{},
-		RunningScripts:  make(map[string]*exec.Cmd),
+		Client:          client,
+		UsePTY:          cfg.UsePTY() && process.PTYSupported(),
+		Supervisor:      process.NewSupervisor(cfg.GracePeriod()),
+		History:         history.Open(filepath.Dir(packageJSONPath)),
 	}
 
 	// Load the initial scripts
@@ -68,131 +96,110 @@ func (sr *ScriptRunner) LoadScripts() error {
 	return nil
 }
 
-// RunScript runs a script by name
-func (sr *ScriptRunner) RunScript(name string) (*exec.Cmd, error) {
-	// Check if the script is already running
-	if _, ok := sr.RunningScripts[name]; ok {
+// RunScript runs a script by name, streaming its output into the process buffer.
+// It returns nil if the script is already running.
+func (sr *ScriptRunner) RunScript(name string) (*process.Process, error) {
+	proc, err := sr.Supervisor.Start(sr.scriptSpec(name))
+	if err == process.ErrAlreadyRunning {
 		return nil, nil // Already running
 	}
 
-	// Run the script using npm run
-	cmd := exec.Command("npm", "run", name)
-
-	// Set up the working directory to the directory containing package.json
-	cmd.Dir = filepath.Dir(sr.PackageJSONPath)
-
-	// Redirect output to pipes - we don't actually read from them in this function
-	// but setting them up allows capturing output elsewhere
-	_, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
+	return proc, err
+}
 
-	_, err = cmd.StderrPipe()
-	if err != nil {
-		return nil, err
+// scriptSpec describes how the supervisor starts a script
+func (sr *ScriptRunner) scriptSpec(name string) process.Spec {
+	return process.Spec{
+		Name: sr.processName(name),
+		Command: func() *exec.Cmd {
+			// Run the script using the client's run command, in the directory
+			// containing package.json or with the workspace flag
+			return npm.Command(sr.Client, filepath.Dir(sr.PackageJSONPath), sr.RootDir, sr.Workspace, sr.Client.RunArgs(name)...)
+		},
+		Options: process.Options{PTY: sr.UsePTY},
 	}
+}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return nil, err
+// processName returns the name a script runs under. Packages of a monorepo
+// share the supervisor, so their scripts carry the package name.
+func (sr *ScriptRunner) processName(script string) string {
+	if sr.Workspace == "" {
+		return script
 	}
-
-	// Store the running command
-	sr.RunningScripts[name] = cmd
-
-	return cmd, nil
+	return sr.Workspace + ":" + script
 }
 
-// StopScript stops a running script
+// StopScript stops a running script and every process it started
 func (sr *ScriptRunner) StopScript(name string) error {
-	cmd, ok := sr.RunningScripts[name]
-	if !ok {
-		return nil // Not running
+	err := sr.Supervisor.Stop(sr.processName(name))
+	if err == process.ErrNotFound {
+		return nil // Never started
 	}
 
-	// Kill the process
-	if err := cmd.Process.Kill(); err != nil {
-		return err
-	}
-
-	// Remove from running scripts
-	delete(sr.RunningScripts, name)
-
-	return nil
+	return err
 }
 
-// AddScript adds a new script to package.json
-func (sr *ScriptRunner) AddScript(name, command string) error {
-	// Read package.json
-	data, err := ioutil.ReadFile(sr.PackageJSONPath)
-	if err != nil {
-		return err
-	}
-
-	// Parse package.json
-	var packageJSON map[string]interface{}
-	if err := json.Unmarshal(data, &packageJSON); err != nil {
-		return err
-	}
-
-	// Get or create the scripts section
-	scripts, ok := packageJSON["scripts"].(map[string]interface{})
-	if !ok {
-		scripts = make(map[string]interface{})
-		packageJSON["scripts"] = scripts
+// RestartScript stops a script if it is running and starts it again
+func (sr *ScriptRunner) RestartScript(name string) (*process.Process, error) {
+	if _, ok := sr.Supervisor.Status(sr.processName(name)); !ok {
+		return sr.RunScript(name)
 	}
 
-	// Add the script
-	scripts[name] = command
+	return sr.Supervisor.Restart(sr.processName(name))
+}
 
-	// Write back to package.json
-	updatedData, err := json.MarshalIndent(packageJSON, "", "  ")
-	if err != nil {
-		return err
-	}
+// SetRestartOnCrash toggles restarting a script when it exits with an error
+func (sr *ScriptRunner) SetRestartOnCrash(name string, enabled bool) error {
+	return sr.Supervisor.SetRestartOnCrash(sr.processName(name), enabled)
+}
 
-	if err := ioutil.WriteFile(sr.PackageJSONPath, updatedData, os.ModePerm); err != nil {
-		return err
-	}
+// ScriptStatus returns the status of a script that has been run
+func (sr *ScriptRunner) ScriptStatus(name string) (process.Status, bool) {
+	return sr.Supervisor.Status(sr.processName(name))
+}
 
-	// Reload scripts
-	return sr.LoadScripts()
+// AddScript adds a new script to package.json, or changes an existing one
+func (sr *ScriptRunner) AddScript(name, command string) error {
+	return sr.History.Record("Add script "+name, func() error {
+		// Load package.json
+		doc, err := packagejson.Load(sr.PackageJSONPath)
+		if err != nil {
+			return err
+		}
+
+		// Add the script, creating the scripts section if needed
+		if err := doc.Set([]string{"scripts", name}, command); err != nil {
+			return err
+		}
+
+		if err := doc.Save(sr.PackageJSONPath); err != nil {
+			return err
+		}
+
+		// Reload scripts
+		return sr.LoadScripts()
+	})
 }
 
 // RemoveScript removes a script from package.json
 func (sr *ScriptRunner) RemoveScript(name string) error {
-	// Read package.json
-	data, err := ioutil.ReadFile(sr.PackageJSONPath)
-	if err != nil {
-		return err
-	}
-
-	// Parse package.json
-	var packageJSON map[string]interface{}
-	if err := json.Unmarshal(data, &packageJSON); err != nil {
-		return err
-	}
-
-	// Get the scripts section
-	scripts, ok := packageJSON["scripts"].(map[string]interface{})
-	if !ok {
-		return nil // No scripts section
-	}
-
-	// Remove the script
-	delete(scripts, name)
-
-	// Write back to package.json
-	updatedData, err := json.MarshalIndent(packageJSON, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	if err := ioutil.WriteFile(sr.PackageJSONPath, updatedData, os.ModePerm); err != nil {
-		return err
-	}
-
-	// Reload scripts
-	return sr.LoadScripts()
+	return sr.History.Record("Remove script "+name, func() error {
+		// Load package.json
+		doc, err := packagejson.Load(sr.PackageJSONPath)
+		if err != nil {
+			return err
+		}
+
+		// Remove the script
+		if err := doc.Delete("scripts", name); err != nil {
+			return err
+		}
+
+		if err := doc.Save(sr.PackageJSONPath); err != nil {
+			return err
+		}
+
+		// Reload scripts
+		return sr.LoadScripts()
+	})
 }
@@ -0,0 +1,302 @@
+package scripts
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/process"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+)
+
+// RunState is where a package stands in a run across workspaces
+type RunState string
+
+const (
+	RunPending   RunState = "pending"
+	RunRunning   RunState = "running"
+	RunSucceeded RunState = "succeeded"
+	RunFailed    RunState = "failed"
+	RunSkipped   RunState = "skipped"   // No such script, or a dependency failed
+	RunCancelled RunState = "cancelled" // The run stopped before it
+)
+
+// PackageRun is the script of one package within a run across workspaces
+type PackageRun struct {
+	Package    *workspace.Package
+	Process    string // Name of the process in the supervisor
+	State      RunState
+	Reason     string // Why it was skipped or cancelled
+	ExitCode   int
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Done reports whether the package won't change state anymore
+func (r PackageRun) Done() bool {
+	return r.State != RunPending && r.State != RunRunning
+}
+
+// Duration returns how long the script ran, or has been running so far
+func (r PackageRun) Duration() time.Duration {
+	switch {
+	case r.StartedAt.IsZero():
+		return 0
+	case r.FinishedAt.IsZero():
+		return time.Since(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// WorkspaceRun runs a script in every package of a monorepo that has it,
+// each package after the ones it depends on
+type WorkspaceRun struct {
+	Script   string
+	Parallel int // Packages run at the same time
+	// ContinueOnError keeps starting packages after a failure; packages
+	// depending on the failed one are still skipped
+	ContinueOnError bool
+	StartedAt       time.Time
+	FinishedAt      time.Time
+
+	mu         sync.Mutex
+	supervisor *process.Supervisor
+	packages   []*PackageRun
+	stopped    string        // Why no more packages start, if they don't
+	killed     bool          // Stop was called: running packages are being stopped
+	finished   chan struct{} // Signaled whenever a package is done
+}
+
+// RunAcrossWorkspaces runs script in the packages of a monorepo in
+// dependency order, up to parallel at a time, and returns at once. The
+// output of each package goes to its own process in the supervisor.
+func (sr *ScriptRunner) RunAcrossWorkspaces(w *workspace.Workspace, script string, parallel int, continueOnError bool) (*WorkspaceRun, error) {
+	sorted, err := w.Sorted()
+	if err != nil {
+		return nil, err
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	run := &WorkspaceRun{
+		Script:          script,
+		Parallel:        parallel,
+		ContinueOnError: continueOnError,
+		StartedAt:       time.Now(),
+		supervisor:      sr.Supervisor,
+		finished:        make(chan struct{}, len(sorted)),
+	}
+	for _, pkg := range sorted {
+		packageRun := &PackageRun{
+			Package: pkg,
+			Process: pkg.Selector(w.RootDir) + ":" + script,
+			State:   RunPending,
+		}
+		if _, ok := pkg.Scripts[script]; !ok {
+			packageRun.State = RunSkipped
+			packageRun.Reason = "no " + script + " script"
+		}
+		run.packages = append(run.packages, packageRun)
+	}
+
+	go run.schedule(func(packageRun *PackageRun) process.Spec {
+		pkg := packageRun.Package
+		return process.Spec{
+			Name: packageRun.Process,
+			Command: func() *exec.Cmd {
+				return npm.Command(sr.Client, pkg.Dir, w.RootDir, pkg.Selector(w.RootDir), sr.Client.RunArgs(script)...)
+			},
+			Options: process.Options{PTY: sr.UsePTY},
+		}
+	})
+
+	return run, nil
+}
+
+// schedule starts every package whose dependencies are done, as slots free
+// up, until all of them are done
+func (r *WorkspaceRun) schedule(spec func(*PackageRun) process.Spec) {
+	for {
+		r.mu.Lock()
+		running := 0
+		for _, packageRun := range r.packages {
+			if packageRun.State == RunRunning {
+				running++
+			}
+		}
+
+		for _, packageRun := range r.packages {
+			if running >= r.Parallel {
+				break
+			}
+			if packageRun.State != RunPending {
+				continue
+			}
+			if r.stopped != "" {
+				packageRun.State = RunCancelled
+				packageRun.Reason = r.stopped
+				continue
+			}
+
+			ready, blocker := r.dependenciesDone(packageRun)
+			if blocker != "" {
+				packageRun.State = RunSkipped
+				packageRun.Reason = blocker + " failed"
+				continue
+			}
+			if !ready {
+				continue
+			}
+
+			packageRun.State = RunRunning
+			packageRun.StartedAt = time.Now()
+			running++
+			go r.execute(packageRun, spec(packageRun))
+		}
+
+		if running == 0 {
+			// Nothing left to wait for: cancel what a stop left pending
+			for _, packageRun := range r.packages {
+				if packageRun.State == RunPending {
+					packageRun.State = RunCancelled
+					packageRun.Reason = r.stopped
+				}
+			}
+			r.FinishedAt = time.Now()
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+
+		<-r.finished
+	}
+}
+
+// dependenciesDone reports whether the local dependencies of a package in
+// the run are done, or names one that failed. A dependency without the
+// script is looked through: the package waits on its dependencies instead.
+func (r *WorkspaceRun) dependenciesDone(packageRun *PackageRun) (bool, string) {
+	ready := true
+	seen := make(map[string]bool)
+
+	var check func(pkg *workspace.Package) string
+	check = func(pkg *workspace.Package) string {
+		for _, name := range pkg.LocalDependencies {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			for _, dependency := range r.packages {
+				if dependency.Package.Name != name {
+					continue
+				}
+				switch dependency.State {
+				case RunFailed, RunCancelled:
+					return name
+				case RunSkipped:
+					// One skipped for a failure blocks the package, one
+					// without the script passes its own dependencies on
+					if _, ok := dependency.Package.Scripts[r.Script]; ok {
+						return name
+					}
+					if blocker := check(dependency.Package); blocker != "" {
+						return blocker
+					}
+				case RunSucceeded:
+				default:
+					ready = false
+				}
+			}
+		}
+		return ""
+	}
+
+	if blocker := check(packageRun.Package); blocker != "" {
+		return false, blocker
+	}
+	return ready, ""
+}
+
+// execute runs the script of one package and records how it ended
+func (r *WorkspaceRun) execute(packageRun *PackageRun, spec process.Spec) {
+	state, reason, exitCode := RunSucceeded, "", 0
+	if _, err := r.supervisor.Start(spec); err != nil {
+		state, reason = RunFailed, err.Error()
+	} else if status, err := r.supervisor.Wait(spec.Name); err != nil {
+		state, reason = RunFailed, err.Error()
+	} else if status.ExitCode != 0 {
+		state, exitCode = RunFailed, status.ExitCode
+		reason = fmt.Sprintf("exit code %d", status.ExitCode)
+	}
+
+	r.mu.Lock()
+	if state == RunFailed && r.killed {
+		// Killed by the stop
+		state = RunCancelled
+		reason = r.stopped
+	}
+	packageRun.State = state
+	packageRun.Reason = reason
+	packageRun.ExitCode = exitCode
+	packageRun.FinishedAt = time.Now()
+	if state == RunFailed && !r.ContinueOnError {
+		r.stopped = packageRun.Package.Name + " failed"
+	}
+	r.mu.Unlock()
+
+	r.finished <- struct{}{}
+}
+
+// Stop starts no more packages and stops the ones running
+func (r *WorkspaceRun) Stop() {
+	r.mu.Lock()
+	if r.stopped == "" {
+		r.stopped = "stopped"
+	}
+	r.killed = true
+	var running []string
+	for _, packageRun := range r.packages {
+		if packageRun.State == RunRunning {
+			running = append(running, packageRun.Process)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, name := range running {
+		go r.supervisor.Stop(name)
+	}
+}
+
+// Packages returns a copy of the state of every package, dependencies first
+func (r *WorkspaceRun) Packages() []PackageRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	packages := make([]PackageRun, len(r.packages))
+	for i, packageRun := range r.packages {
+		packages[i] = *packageRun
+	}
+	return packages
+}
+
+// Finished reports whether every package is done
+func (r *WorkspaceRun) Finished() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.FinishedAt.IsZero()
+}
+
+// Duration returns how long the run took, or has been running so far
+func (r *WorkspaceRun) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.FinishedAt.IsZero() {
+		return time.Since(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
@@ -0,0 +1,232 @@
+package scripts
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/process"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+)
+
+// chain is app → mid → core, where mid has no build script
+func chain() []*workspace.Package {
+	core := &workspace.Package{Name: "core", Scripts: map[string]string{"build": "tsc"}}
+	mid := &workspace.Package{Name: "mid", LocalDependencies: []string{"core"}, Scripts: map[string]string{}}
+	app := &workspace.Package{Name: "app", LocalDependencies: []string{"mid"}, Scripts: map[string]string{"build": "vite build"}}
+	return []*workspace.Package{core, mid, app}
+}
+
+// newRun prepares a run of the build script over packages, in that order
+func newRun(packages []*workspace.Package, parallel int) *WorkspaceRun {
+	run := &WorkspaceRun{
+		Script:     "build",
+		Parallel:   parallel,
+		StartedAt:  time.Now(),
+		supervisor: process.NewSupervisor(time.Second),
+		finished:   make(chan struct{}, len(packages)),
+	}
+	for _, pkg := range packages {
+		packageRun := &PackageRun{Package: pkg, Process: pkg.Name + ":build", State: RunPending}
+		if _, ok := pkg.Scripts["build"]; !ok {
+			packageRun.State = RunSkipped
+		}
+		run.packages = append(run.packages, packageRun)
+	}
+	return run
+}
+
+// independent returns packages with a build script and no dependencies
+func independent(names ...string) []*workspace.Package {
+	var packages []*workspace.Package
+	for _, name := range names {
+		packages = append(packages, &workspace.Package{Name: name, Scripts: map[string]string{"build": "tsc"}})
+	}
+	return packages
+}
+
+// shScripts runs the shell script given for each package, exit 0 for the others
+func shScripts(scripts map[string]string) func(*PackageRun) process.Spec {
+	return func(packageRun *PackageRun) process.Spec {
+		script, ok := scripts[packageRun.Package.Name]
+		if !ok {
+			script = "exit 0"
+		}
+		return process.Spec{
+			Name: packageRun.Process,
+			Command: func() *exec.Cmd {
+				return exec.Command("sh", "-c", script)
+			},
+		}
+	}
+}
+
+// states describes the packages of a run as "name state (reason)"
+func states(run *WorkspaceRun) []string {
+	var states []string
+	for _, packageRun := range run.Packages() {
+		states = append(states, fmt.Sprintf("%s %s (%s)", packageRun.Package.Name, packageRun.State, packageRun.Reason))
+	}
+	return states
+}
+
+func TestDependenciesDoneThroughSkipped(t *testing.T) {
+	run := newRun(chain(), 4)
+	core, app := run.packages[0], run.packages[2]
+
+	// app waits on core through mid, which has no build script
+	if ready, blocker := run.dependenciesDone(app); ready || blocker != "" {
+		t.Fatalf("app with core pending: ready=%v blocker=%q, want not ready", ready, blocker)
+	}
+
+	core.State = RunRunning
+	if ready, _ := run.dependenciesDone(app); ready {
+		t.Fatalf("app with core running: ready, want not ready")
+	}
+
+	core.State = RunSucceeded
+	if ready, blocker := run.dependenciesDone(app); !ready || blocker != "" {
+		t.Fatalf("app with core built: ready=%v blocker=%q, want ready", ready, blocker)
+	}
+
+	core.State = RunFailed
+	if ready, blocker := run.dependenciesDone(app); ready || blocker != "core" {
+		t.Fatalf("app with core failed: ready=%v blocker=%q, want blocked by core", ready, blocker)
+	}
+}
+
+func TestRunOrderThroughSkipped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	run := newRun(chain(), 4)
+	run.schedule(shScripts(map[string]string{"core": "sleep 0.2"}))
+
+	packages := run.Packages()
+	core, mid, app := packages[0], packages[1], packages[2]
+	if core.State != RunSucceeded || app.State != RunSucceeded {
+		t.Fatalf("core %s (%s), app %s (%s), want both succeeded", core.State, core.Reason, app.State, app.Reason)
+	}
+	if mid.State != RunSkipped {
+		t.Errorf("mid %s, want skipped", mid.State)
+	}
+	if app.StartedAt.Before(core.FinishedAt) {
+		t.Errorf("app started at %v, before core finished at %v", app.StartedAt, core.FinishedAt)
+	}
+}
+
+func TestRunStopsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	// One at a time: b and c are still pending when a fails
+	run := newRun(independent("a", "b", "c"), 1)
+	run.schedule(shScripts(map[string]string{"a": "exit 2"}))
+
+	want := []string{"a failed (exit code 2)", "b cancelled (a failed)", "c cancelled (a failed)"}
+	if got := states(run); !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %q, want %q", got, want)
+	}
+	if code := run.Packages()[0].ExitCode; code != 2 {
+		t.Errorf("a exit code = %d, want 2", code)
+	}
+	if !run.Finished() {
+		t.Error("run not finished")
+	}
+}
+
+func TestRunContinuesOnError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	// b depends on the failing a, c doesn't
+	packages := independent("a", "b", "c")
+	packages[1].LocalDependencies = []string{"a"}
+	run := newRun(packages, 1)
+	run.ContinueOnError = true
+	run.schedule(shScripts(map[string]string{"a": "exit 1"}))
+
+	want := []string{"a failed (exit code 1)", "b skipped (a failed)", "c succeeded ()"}
+	if got := states(run); !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %q, want %q", got, want)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	scripts := make(map[string]string)
+	names := []string{"a", "b", "c", "d", "e"}
+	for _, name := range names {
+		scripts[name] = "sleep 0.2"
+	}
+	run := newRun(independent(names...), 2)
+	run.schedule(shScripts(scripts))
+
+	// Count the packages running at the start of each one
+	packages := run.Packages()
+	most := 0
+	for _, started := range packages {
+		if started.State != RunSucceeded {
+			t.Fatalf("%s %s (%s), want succeeded", started.Package.Name, started.State, started.Reason)
+		}
+		running := 0
+		for _, other := range packages {
+			if !other.StartedAt.After(started.StartedAt) && other.FinishedAt.After(started.StartedAt) {
+				running++
+			}
+		}
+		if running > most {
+			most = running
+		}
+	}
+	if most != 2 {
+		t.Errorf("at most %d packages ran at once, want 2", most)
+	}
+}
+
+func TestRunStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	run := newRun(independent("a", "b", "c"), 2)
+	done := make(chan struct{})
+	go func() {
+		run.schedule(shScripts(map[string]string{"a": "sleep 10", "b": "sleep 10"}))
+		close(done)
+	}()
+
+	// Stop once a and b are running
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		packages := run.Packages()
+		if packages[0].State == RunRunning && packages[1].State == RunRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("states = %q, want a and b running", states(run))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	run.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run still going after Stop: %q", states(run))
+	}
+
+	want := []string{"a cancelled (stopped)", "b cancelled (stopped)", "c cancelled (stopped)"}
+	if got := states(run); !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %q, want %q", got, want)
+	}
+}
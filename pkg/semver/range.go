@@ -0,0 +1,452 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// comparator is a single condition such as ">=1.2.3". An empty operator
+// with a nil version matches anything.
+type comparator struct {
+	operator string
+	version  *Version
+}
+
+// matches reports whether v passes the condition
+func (c comparator) matches(v *Version) bool {
+	if c.version == nil {
+		return true
+	}
+
+	cmp := v.Compare(c.version)
+	switch c.operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// String formats the comparator, leaving out the operator of an exact version
+func (c comparator) String() string {
+	if c.version == nil {
+		return ""
+	}
+	if c.operator == "=" {
+		return c.version.String()
+	}
+	return c.operator + c.version.String()
+}
+
+// Range is a version range such as "^1.2.0 || >=2.1.0 <3": a union of
+// sets, each of which requires all its comparators to pass
+type Range struct {
+	raw  string
+	sets [][]comparator
+}
+
+var (
+	// Spaces after an operator, as in ">= 1.2.3" or "~ 1.2"
+	operatorSpace = regexp.MustCompile(`(<=|>=|~>|[<>=~^])\s+`)
+	// The dash between the bounds of a hyphen range
+	hyphen = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+	// An operator followed by a partial version like "1", "1.2.x" or "1.2.3-beta"
+	partialToken = regexp.MustCompile(`^(<=|>=|~>|[<>=~^])?\s*v?([0-9xX*]+)(?:\.([0-9xX*]+))?(?:\.([0-9xX*]+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// ParseRange parses a range. It understands comparators, caret and tilde
+// ranges, x-ranges, hyphen ranges and || unions, like node-semver.
+func ParseRange(s string) (*Range, error) {
+	r := &Range{raw: s}
+
+	for _, set := range strings.Split(s, "||") {
+		comparators, err := parseSet(set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %v", s, err)
+		}
+		r.sets = append(r.sets, comparators)
+	}
+
+	return r, nil
+}
+
+// parseSet parses the comparators of one set of a range
+func parseSet(set string) ([]comparator, error) {
+	set = strings.TrimSpace(set)
+	set = operatorSpace.ReplaceAllString(set, "$1")
+
+	if m := hyphen.FindStringSubmatch(set); m != nil {
+		return parseHyphen(m[1], m[2])
+	}
+
+	if set == "" {
+		return []comparator{{}}, nil
+	}
+
+	var comparators []comparator
+	for _, token := range strings.Fields(set) {
+		parsed, err := parseToken(token)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, parsed...)
+	}
+	return comparators, nil
+}
+
+// partial is a version with wildcards or missing parts, like "1.x" or "1.2"
+type partial struct {
+	major, minor, patch int
+	xMajor              bool
+	xMinor              bool
+	xPatch              bool
+	prerelease          string
+}
+
+// parsePartial splits a token into its operator and partial version
+func parsePartial(token string) (string, partial, error) {
+	m := partialToken.FindStringSubmatch(token)
+	if m == nil {
+		return "", partial{}, fmt.Errorf("invalid comparator %q", token)
+	}
+
+	p := partial{prerelease: m[5]}
+	fields := []struct {
+		text   string
+		number *int
+		x      *bool
+	}{
+		{m[2], &p.major, &p.xMajor},
+		{m[3], &p.minor, &p.xMinor},
+		{m[4], &p.patch, &p.xPatch},
+	}
+
+	for _, field := range fields {
+		if isX(field.text) {
+			*field.x = true
+			continue
+		}
+
+		n, err := strconv.Atoi(field.text)
+		if err != nil {
+			return "", partial{}, fmt.Errorf("invalid comparator %q", token)
+		}
+		*field.number = n
+	}
+
+	// A wildcard makes every part after it a wildcard too
+	if p.xMajor {
+		p.xMinor = true
+	}
+	if p.xMinor {
+		p.xPatch = true
+	}
+	if p.xPatch {
+		p.prerelease = ""
+	}
+
+	return m[1], p, nil
+}
+
+// isX reports whether a part of a version is missing or a wildcard
+func isX(s string) bool {
+	return s == "" || s == "x" || s == "X" || s == "*"
+}
+
+// version builds a version from the numbers, with an optional prerelease
+func version(major, minor, patch int, prerelease string) *Version {
+	v := &Version{Major: major, Minor: minor, Patch: patch}
+	if prerelease != "" {
+		v.Prerelease = strings.Split(prerelease, ".")
+	}
+	return v
+}
+
+// parseToken turns one token of a set into plain comparators
+func parseToken(token string) ([]comparator, error) {
+	operator, p, err := parsePartial(token)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator {
+	case "~", "~>":
+		return tilde(p), nil
+	case "^":
+		return caret(p), nil
+	}
+	return xRange(operator, p), nil
+}
+
+// tilde allows patch changes: ~1.2.3 is >=1.2.3 <1.3.0-0, ~1 is >=1.0.0 <2.0.0-0
+func tilde(p partial) []comparator {
+	switch {
+	case p.xMajor:
+		return []comparator{{}}
+	case p.xMinor:
+		return []comparator{
+			{">=", version(p.major, 0, 0, "")},
+			{"<", version(p.major+1, 0, 0, "0")},
+		}
+	case p.xPatch:
+		return []comparator{
+			{">=", version(p.major, p.minor, 0, "")},
+			{"<", version(p.major, p.minor+1, 0, "0")},
+		}
+	}
+	return []comparator{
+		{">=", version(p.major, p.minor, p.patch, p.prerelease)},
+		{"<", version(p.major, p.minor+1, 0, "0")},
+	}
+}
+
+// caret allows changes that don't modify the left-most non-zero part:
+// ^1.2.3 is >=1.2.3 <2.0.0-0, ^0.2.3 is >=0.2.3 <0.3.0-0, ^0.0.3 is >=0.0.3 <0.0.4-0
+func caret(p partial) []comparator {
+	switch {
+	case p.xMajor:
+		return []comparator{{}}
+	case p.xMinor:
+		return []comparator{
+			{">=", version(p.major, 0, 0, "")},
+			{"<", version(p.major+1, 0, 0, "0")},
+		}
+	case p.xPatch:
+		if p.major == 0 {
+			return []comparator{
+				{">=", version(0, p.minor, 0, "")},
+				{"<", version(0, p.minor+1, 0, "0")},
+			}
+		}
+		return []comparator{
+			{">=", version(p.major, p.minor, 0, "")},
+			{"<", version(p.major+1, 0, 0, "0")},
+		}
+	}
+
+	lower := comparator{">=", version(p.major, p.minor, p.patch, p.prerelease)}
+	switch {
+	case p.major == 0 && p.minor == 0:
+		return []comparator{lower, {"<", version(0, 0, p.patch+1, "0")}}
+	case p.major == 0:
+		return []comparator{lower, {"<", version(0, p.minor+1, 0, "0")}}
+	}
+	return []comparator{lower, {"<", version(p.major+1, 0, 0, "0")}}
+}
+
+// xRange handles plain comparators and wildcards: 1.x is >=1.0.0 <2.0.0-0,
+// >1.2 is >=1.3.0, <=1 is <2.0.0-0 and a full version without operator is exact
+func xRange(operator string, p partial) []comparator {
+	if operator == "=" && p.xPatch {
+		operator = ""
+	}
+
+	switch {
+	case p.xMajor:
+		if operator == "<" || operator == ">" {
+			// Nothing is allowed
+			return []comparator{{"<", version(0, 0, 0, "0")}}
+		}
+		return []comparator{{}}
+
+	case operator != "" && p.xPatch:
+		// Fill in the missing parts, then adjust the operator
+		if p.xMinor {
+			p.minor = 0
+		}
+		p.patch = 0
+
+		prerelease := ""
+		switch operator {
+		case ">":
+			// >1 is >=2.0.0, >1.2 is >=1.3.0
+			operator = ">="
+			if p.xMinor {
+				p.major++
+				p.minor = 0
+			} else {
+				p.minor++
+			}
+		case "<=":
+			// <=1 is <2.0.0-0, <=1.2 is <1.3.0-0
+			operator = "<"
+			if p.xMinor {
+				p.major++
+				p.minor = 0
+			} else {
+				p.minor++
+			}
+		}
+		if operator == "<" {
+			prerelease = "0"
+		}
+		return []comparator{{operator, version(p.major, p.minor, p.patch, prerelease)}}
+
+	case p.xMinor:
+		return []comparator{
+			{">=", version(p.major, 0, 0, "")},
+			{"<", version(p.major+1, 0, 0, "0")},
+		}
+
+	case p.xPatch:
+		return []comparator{
+			{">=", version(p.major, p.minor, 0, "")},
+			{"<", version(p.major, p.minor+1, 0, "0")},
+		}
+	}
+
+	if operator == "" {
+		operator = "="
+	}
+	return []comparator{{operator, version(p.major, p.minor, p.patch, p.prerelease)}}
+}
+
+// parseHyphen handles "1.2 - 2.3": the lower bound is filled with zeros, the
+// upper bound allows everything up to the next version of its last part
+func parseHyphen(from, to string) ([]comparator, error) {
+	_, low, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	_, high, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var comparators []comparator
+	switch {
+	case low.xMajor:
+		// No lower bound
+	case low.xMinor:
+		comparators = append(comparators, comparator{">=", version(low.major, 0, 0, "")})
+	case low.xPatch:
+		comparators = append(comparators, comparator{">=", version(low.major, low.minor, 0, "")})
+	default:
+		comparators = append(comparators, comparator{">=", version(low.major, low.minor, low.patch, low.prerelease)})
+	}
+
+	switch {
+	case high.xMajor:
+		// No upper bound
+	case high.xMinor:
+		comparators = append(comparators, comparator{"<", version(high.major+1, 0, 0, "0")})
+	case high.xPatch:
+		comparators = append(comparators, comparator{"<", version(high.major, high.minor+1, 0, "0")})
+	default:
+		comparators = append(comparators, comparator{"<=", version(high.major, high.minor, high.patch, high.prerelease)})
+	}
+
+	if len(comparators) == 0 {
+		comparators = append(comparators, comparator{})
+	}
+	return comparators, nil
+}
+
+// Contains reports whether v is allowed by the range. A prerelease is only
+// allowed when a comparator of the same set names a prerelease of the same
+// major, minor and patch, so ^1.2.0 doesn't pick up 1.3.0-beta.
+func (r *Range) Contains(v *Version) bool {
+	for _, set := range r.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// setContains checks one set of comparators
+func setContains(set []comparator, v *Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+
+	if !v.IsPrerelease() {
+		return true
+	}
+
+	for _, c := range set {
+		if c.version == nil {
+			continue
+		}
+		if c.version.IsPrerelease() && c.version.sameTuple(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the range as it was written
+func (r *Range) String() string {
+	return r.raw
+}
+
+// Normalized returns the range as plain comparators, e.g. ">=1.2.3 <2.0.0-0".
+// Like node-semver it drops a ">=0.0.0" lower bound and returns "*" when
+// any set allows everything.
+func (r *Range) Normalized() string {
+	var sets []string
+	for _, set := range r.sets {
+		var parts []string
+		for _, c := range set {
+			if s := c.String(); s != "" && s != ">=0.0.0" {
+				parts = append(parts, s)
+			}
+		}
+		if len(parts) == 0 {
+			return "*"
+		}
+		sets = append(sets, strings.Join(parts, " "))
+	}
+	return strings.Join(sets, "||")
+}
+
+// Satisfies reports whether version is allowed by rng. Invalid versions
+// and ranges never satisfy.
+func Satisfies(version, rng string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+	r, err := ParseRange(rng)
+	if err != nil {
+		return false
+	}
+	return r.Contains(v)
+}
+
+// MaxSatisfying returns the highest of the versions allowed by rng, or ""
+func MaxSatisfying(versions []string, rng string) string {
+	r, err := ParseRange(rng)
+	if err != nil {
+		return ""
+	}
+
+	var best *Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := Parse(raw)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+		if best == nil || v.Compare(best) > 0 {
+			best, bestRaw = v, raw
+		}
+	}
+	return bestRaw
+}
+
+// ValidRange reports whether rng is a semver range, as opposed to a tag,
+// URL, path or protocol such as "latest", "file:../lib" or "workspace:*"
+func ValidRange(rng string) bool {
+	_, err := ParseRange(rng)
+	return err == nil
+}
@@ -0,0 +1,317 @@
+package semver
+
+import "testing"
+
+// Ported from node-semver test/fixtures/range-include.js, without the cases
+// that need loose or includePrerelease options
+var rangeInclude = []struct {
+	rng, version string
+}{
+	{"1.0.0 - 2.0.0", "1.2.3"},
+	{"^1.2.3+build", "1.2.3"},
+	{"^1.2.3+build", "1.3.0"},
+	{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "1.2.3"},
+	{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "1.2.3-pre.2"},
+	{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "2.4.3-alpha"},
+	{"1.2.3+asdf - 2.4.3+asdf", "1.2.3"},
+	{"1.0.0", "1.0.0"},
+	{">=*", "0.2.4"},
+	{"", "1.0.0"},
+	{"*", "1.2.3"},
+	{"*", "v1.2.3"},
+	{">=1.0.0", "1.0.0"},
+	{">=1.0.0", "1.0.1"},
+	{">=1.0.0", "1.1.0"},
+	{">1.0.0", "1.0.1"},
+	{">1.0.0", "1.1.0"},
+	{"<=2.0.0", "2.0.0"},
+	{"<=2.0.0", "1.9999.9999"},
+	{"<=2.0.0", "0.2.9"},
+	{"<2.0.0", "1.9999.9999"},
+	{"<2.0.0", "0.2.9"},
+	{">= 1.0.0", "1.0.0"},
+	{">=  1.0.0", "1.0.1"},
+	{">=   1.0.0", "1.1.0"},
+	{"> 1.0.0", "1.0.1"},
+	{">  1.0.0", "1.1.0"},
+	{"<=   2.0.0", "2.0.0"},
+	{"<= 2.0.0", "1.9999.9999"},
+	{"<=  2.0.0", "0.2.9"},
+	{"<    2.0.0", "1.9999.9999"},
+	{"<\t2.0.0", "0.2.9"},
+	{">=0.1.97", "v0.1.97"},
+	{">=0.1.97", "0.1.97"},
+	{"0.1.20 || 1.2.4", "1.2.4"},
+	{">=0.2.3 || <0.0.1", "0.0.0"},
+	{">=0.2.3 || <0.0.1", "0.2.3"},
+	{">=0.2.3 || <0.0.1", "0.2.4"},
+	{"||", "1.3.4"},
+	{"2.x.x", "2.1.3"},
+	{"1.2.x", "1.2.3"},
+	{"1.2.x || 2.x", "2.1.3"},
+	{"1.2.x || 2.x", "1.2.3"},
+	{"x", "1.2.3"},
+	{"2.*.*", "2.1.3"},
+	{"1.2.*", "1.2.3"},
+	{"1.2.* || 2.*", "2.1.3"},
+	{"1.2.* || 2.*", "1.2.3"},
+	{"2", "2.1.2"},
+	{"2.3", "2.3.1"},
+	{"~0.0.1", "0.0.1"},
+	{"~0.0.1", "0.0.2"},
+	{"~x", "0.0.9"},
+	{"~2", "2.0.9"},
+	{"~2.4", "2.4.0"},
+	{"~2.4", "2.4.5"},
+	{"~>3.2.1", "3.2.2"},
+	{"~1", "1.2.3"},
+	{"~>1", "1.2.3"},
+	{"~> 1", "1.2.3"},
+	{"~1.0", "1.0.2"},
+	{"~ 1.0", "1.0.2"},
+	{"~ 1.0.3", "1.0.12"},
+	{">=1", "1.0.0"},
+	{">= 1", "1.0.0"},
+	{"<1.2", "1.1.1"},
+	{"< 1.2", "1.1.1"},
+	{"~v0.5.4-pre", "0.5.5"},
+	{"~v0.5.4-pre", "0.5.4"},
+	{"=0.7.x", "0.7.2"},
+	{"<=0.7.x", "0.7.2"},
+	{">=0.7.x", "0.7.2"},
+	{"<=0.7.x", "0.6.2"},
+	{"~1.2.1 >=1.2.3", "1.2.3"},
+	{"~1.2.1 =1.2.3", "1.2.3"},
+	{"~1.2.1 1.2.3", "1.2.3"},
+	{"~1.2.1 >=1.2.3 1.2.3", "1.2.3"},
+	{"~1.2.1 1.2.3 >=1.2.3", "1.2.3"},
+	{">=1.2.1 1.2.3", "1.2.3"},
+	{"1.2.3 >=1.2.1", "1.2.3"},
+	{">=1.2.3 >=1.2.1", "1.2.3"},
+	{">=1.2.1 >=1.2.3", "1.2.3"},
+	{">=1.2", "1.2.8"},
+	{"^1.2.3", "1.8.1"},
+	{"^0.1.2", "0.1.2"},
+	{"^0.1", "0.1.2"},
+	{"^0.0.1", "0.0.1"},
+	{"^1.2", "1.4.2"},
+	{"^1.2 ^1", "1.4.2"},
+	{"^1.2.3-alpha", "1.2.3-pre"},
+	{"^1.2.0-alpha", "1.2.0-pre"},
+	{"^0.0.1-alpha", "0.0.1-beta"},
+	{"^0.0.1-alpha", "0.0.1"},
+	{"^0.1.1-alpha", "0.1.1-beta"},
+	{"^x", "1.2.3"},
+	{"x - 1.0.0", "0.9.7"},
+	{"x - 1.x", "0.9.7"},
+	{"1.0.0 - x", "1.9.7"},
+	{"1.x - x", "1.9.7"},
+	{"<=7.x", "7.9.9"},
+}
+
+// Ported from node-semver test/fixtures/range-exclude.js, without the cases
+// that need loose or includePrerelease options
+var rangeExclude = []struct {
+	rng, version string
+}{
+	{"1.0.0 - 2.0.0", "2.2.3"},
+	{"1.2.3+asdf - 2.4.3+asdf", "1.2.3-pre.2"},
+	{"1.2.3+asdf - 2.4.3+asdf", "2.4.3-alpha"},
+	{"^1.2.3+build", "2.0.0"},
+	{"^1.2.3+build", "1.2.0"},
+	{"^1.2.3", "1.2.3-pre"},
+	{"^1.2", "1.2.0-pre"},
+	{">1.2", "1.3.0-beta"},
+	{"<=1.2.3", "1.2.3-beta"},
+	{"^1.2.3", "1.2.3-beta"},
+	{"=0.7.x", "0.7.0-asdf"},
+	{">=0.7.x", "0.7.0-asdf"},
+	{"<=0.7.x", "0.7.0-asdf"},
+	{"1.0.0", "1.0.1"},
+	{">=1.0.0", "0.0.0"},
+	{">=1.0.0", "0.0.1"},
+	{">=1.0.0", "0.1.0"},
+	{">1.0.0", "0.0.1"},
+	{">1.0.0", "0.1.0"},
+	{"<=2.0.0", "3.0.0"},
+	{"<=2.0.0", "2.9999.9999"},
+	{"<=2.0.0", "2.2.9"},
+	{"<2.0.0", "2.9999.9999"},
+	{"<2.0.0", "2.2.9"},
+	{">=0.1.97", "v0.1.93"},
+	{">=0.1.97", "0.1.93"},
+	{"0.1.20 || 1.2.4", "1.2.3"},
+	{">=0.2.3 || <0.0.1", "0.0.3"},
+	{">=0.2.3 || <0.0.1", "0.2.2"},
+	{"2.x.x", "1.1.3"},
+	{"2.x.x", "3.1.3"},
+	{"1.2.x", "1.3.3"},
+	{"1.2.x || 2.x", "3.1.3"},
+	{"1.2.x || 2.x", "1.1.3"},
+	{"2.*.*", "1.1.3"},
+	{"2.*.*", "3.1.3"},
+	{"1.2.*", "1.3.3"},
+	{"1.2.* || 2.*", "3.1.3"},
+	{"1.2.* || 2.*", "1.1.3"},
+	{"2", "1.1.2"},
+	{"2.3", "2.4.1"},
+	{"~0.0.1", "0.1.0-alpha"},
+	{"~0.0.1", "0.1.0"},
+	{"~2.4", "2.5.0"},
+	{"~2.4", "2.3.9"},
+	{"~>3.2.1", "3.3.2"},
+	{"~>3.2.1", "3.2.0"},
+	{"~1", "0.2.3"},
+	{"~>1", "2.2.3"},
+	{"~1.0", "1.1.0"},
+	{"<1", "1.0.0"},
+	{">=1.2", "1.1.1"},
+	{"~v0.5.4-beta", "0.5.4-alpha"},
+	{"=0.7.x", "0.8.2"},
+	{">=0.7.x", "0.6.2"},
+	{"<0.7.x", "0.7.2"},
+	{"<1.2.3", "1.2.3-beta"},
+	{"=1.2.3", "1.2.3-beta"},
+	{">1.2", "1.2.8"},
+	{"^0.0.1", "0.0.2-alpha"},
+	{"^0.0.1", "0.0.2"},
+	{"^1.2.3", "2.0.0-alpha"},
+	{"^1.2.3", "1.2.2"},
+	{"^1.2", "1.1.9"},
+	{"*", "v1.2.3-foo"},
+	{"^1.0.0", "2.0.0-rc1"},
+	{"1 - 2", "2.0.0-pre"},
+	{"1 - 2", "1.0.0-pre"},
+	{"1.0 - 2", "1.0.0-pre"},
+	{"1.1.x", "1.0.0-a"},
+	{"1.1.x", "1.1.0-a"},
+	{"1.1.x", "1.2.0-a"},
+	{"1.x", "1.0.0-a"},
+	{"1.x", "1.1.0-a"},
+	{"1.x", "1.2.0-a"},
+	{">=1.0.0 <1.1.0", "1.1.0"},
+	{">=1.0.0 <1.1.0", "1.1.0-pre"},
+	{">=1.0.0 <1.1.0-pre", "1.1.0-pre"},
+	{"blerg", "1.2.3"},
+	{"^1.2.3", "not a version"},
+}
+
+func TestSatisfiesInclude(t *testing.T) {
+	for _, tc := range rangeInclude {
+		if !Satisfies(tc.version, tc.rng) {
+			t.Errorf("Satisfies(%q, %q) = false, want true", tc.version, tc.rng)
+		}
+	}
+}
+
+func TestSatisfiesExclude(t *testing.T) {
+	for _, tc := range rangeExclude {
+		if Satisfies(tc.version, tc.rng) {
+			t.Errorf("Satisfies(%q, %q) = true, want false", tc.version, tc.rng)
+		}
+	}
+}
+
+// Ported from node-semver test/fixtures/range-parse.js
+func TestNormalized(t *testing.T) {
+	tests := []struct {
+		rng  string
+		want string
+	}{
+		{"1.0.0 - 2.0.0", ">=1.0.0 <=2.0.0"},
+		{"1.0.0", "1.0.0"},
+		{">=*", "*"},
+		{"", "*"},
+		{"*", "*"},
+		{">=1.0.0", ">=1.0.0"},
+		{">1.0.0", ">1.0.0"},
+		{"<=2.0.0", "<=2.0.0"},
+		{"1", ">=1.0.0 <2.0.0-0"},
+		{"<2.0.0", "<2.0.0"},
+		{">= 1.0.0", ">=1.0.0"},
+		{"<    2.0.0", "<2.0.0"},
+		{"0.1.20 || 1.2.4", "0.1.20||1.2.4"},
+		{">=0.2.3 || <0.0.1", ">=0.2.3||<0.0.1"},
+		{"||", "*"},
+		{"2.x.x", ">=2.0.0 <3.0.0-0"},
+		{"1.2.x", ">=1.2.0 <1.3.0-0"},
+		{"1.2.x || 2.x", ">=1.2.0 <1.3.0-0||>=2.0.0 <3.0.0-0"},
+		{"x", "*"},
+		{"2", ">=2.0.0 <3.0.0-0"},
+		{"2.3", ">=2.3.0 <2.4.0-0"},
+		{"~2.4", ">=2.4.0 <2.5.0-0"},
+		{"~>3.2.1", ">=3.2.1 <3.3.0-0"},
+		{"~1", ">=1.0.0 <2.0.0-0"},
+		{"~1.0", ">=1.0.0 <1.1.0-0"},
+		{"^0", "<1.0.0-0"},
+		{"^0.1", ">=0.1.0 <0.2.0-0"},
+		{"^1.0", ">=1.0.0 <2.0.0-0"},
+		{"^1.2", ">=1.2.0 <2.0.0-0"},
+		{"^0.0.1", ">=0.0.1 <0.0.2-0"},
+		{"^0.0.1-beta", ">=0.0.1-beta <0.0.2-0"},
+		{"^0.1.2", ">=0.1.2 <0.2.0-0"},
+		{"^1.2.3", ">=1.2.3 <2.0.0-0"},
+		{"^1.2.3-beta.4", ">=1.2.3-beta.4 <2.0.0-0"},
+		{"<1", "<1.0.0-0"},
+		{">=1", ">=1.0.0"},
+		{"<1.2", "<1.2.0-0"},
+		{"~v0.5.4-pre", ">=0.5.4-pre <0.6.0-0"},
+		{"=0.7.x", ">=0.7.0 <0.8.0-0"},
+		{"<=0.7.x", "<0.8.0-0"},
+		{">=0.7.x", ">=0.7.0"},
+		{"<0.7.x", "<0.7.0-0"},
+		{">1", ">=2.0.0"},
+		{">1.2", ">=1.3.0"},
+		{"1.2 - 3.4", ">=1.2.0 <3.5.0-0"},
+		{">x", "<0.0.0-0"},
+		{"<x", "<0.0.0-0"},
+	}
+	for _, tc := range tests {
+		r, err := ParseRange(tc.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q) returned error: %v", tc.rng, err)
+			continue
+		}
+		if got := r.Normalized(); got != tc.want {
+			t.Errorf("ParseRange(%q).Normalized() = %q, want %q", tc.rng, got, tc.want)
+		}
+	}
+}
+
+func TestValidRange(t *testing.T) {
+	for _, rng := range []string{"^1.2.3", "1.x || >=2.5.0", "1.0.0 - 2.0.0", "*", "", ">=1.2.3 <2"} {
+		if !ValidRange(rng) {
+			t.Errorf("ValidRange(%q) = false, want true", rng)
+		}
+	}
+	for _, rng := range []string{"latest", "next", "file:../lib", "workspace:*", "npm:lodash@^4", "github:user/repo", ">=1.2.3 blerg"} {
+		if ValidRange(rng) {
+			t.Errorf("ValidRange(%q) = true, want false", rng)
+		}
+	}
+}
+
+// Ported from node-semver test/functions/max-satisfying.js
+func TestMaxSatisfying(t *testing.T) {
+	tests := []struct {
+		versions []string
+		rng      string
+		want     string
+	}{
+		{[]string{"1.2.3", "1.2.4"}, "1.2", "1.2.4"},
+		{[]string{"1.2.4", "1.2.3"}, "1.2", "1.2.4"},
+		{[]string{"1.2.3", "1.2.4", "1.2.5", "1.2.6"}, "~1.2.3", "1.2.6"},
+		{[]string{"1.1.0", "1.2.0", "1.2.1", "1.3.0", "2.0.0-b1", "2.0.0-b2", "2.0.0", "2.1.0"}, "~2.0.0", "2.0.0"},
+		{[]string{"1.0.0", "2.0.0-beta.1"}, "*", "1.0.0"},
+		{[]string{"2.0.0-beta.1", "2.0.0-beta.2"}, "^2.0.0-beta.1", "2.0.0-beta.2"},
+		{[]string{"1.2.3", "not a version", "1.9.0"}, "^1", "1.9.0"},
+		{[]string{"1.2.3", "1.2.4"}, "2.x", ""},
+		{[]string{"1.2.3", "1.2.4"}, "blerg", ""},
+		{nil, "*", ""},
+	}
+	for _, tc := range tests {
+		if got := MaxSatisfying(tc.versions, tc.rng); got != tc.want {
+			t.Errorf("MaxSatisfying(%q, %q) = %q, want %q", tc.versions, tc.rng, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Package semver parses versions and ranges the way node-semver does, so
+// LazyNode agrees with npm about which versions a range allows.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses a version such as "1.2.3", "v1.2.3" or "1.2.3-beta.1+build".
+// Like node-semver in loose mode it accepts a leading "v" or "=" and spaces.
+func Parse(s string) (*Version, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimLeft(s, "=v ")
+
+	v := &Version{}
+
+	// Split off the build metadata and the prerelease
+	if i := strings.Index(s, "+"); i >= 0 {
+		v.Build = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+		for _, id := range v.Prerelease {
+			if id == "" {
+				return nil, fmt.Errorf("invalid version: %q", original)
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid version: %q", original)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := parseNumber(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %q", original)
+		}
+		numbers[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+// MustParse parses a version and panics if it is invalid
+func MustParse(s string) *Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// parseNumber parses a non-negative version number
+func parseNumber(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid number: %q", s)
+		}
+	}
+	return strconv.Atoi(s)
+}
+
+// String formats the version without build metadata
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version has a prerelease tag
+func (v *Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or greater than
+// other. Build metadata is ignored.
+func (v *Version) Compare(other *Version) int {
+	if c := v.compareMain(other); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// compareMain compares the major, minor and patch numbers
+func (v *Version) compareMain(other *Version) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInts(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInts(v.Minor, other.Minor)
+	}
+	return compareInts(v.Patch, other.Patch)
+}
+
+// sameTuple reports whether both versions have the same major, minor and patch
+func (v *Version) sameTuple(other *Version) bool {
+	return v.compareMain(other) == 0
+}
+
+// comparePrerelease orders prerelease tags: a release is greater than any
+// prerelease, numeric identifiers are lower than alphanumeric ones and
+// compared as numbers, and a longer tag wins when the shorter is its prefix
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifiers(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+// compareIdentifiers compares two prerelease identifiers
+func compareIdentifiers(a, b string) int {
+	na, errA := parseNumber(a)
+	nb, errB := parseNumber(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts returns -1, 0 or 1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Compare parses and compares two versions. Invalid versions sort before
+// valid ones and compare as strings among themselves.
+func Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// Bump types returned by Diff
+const (
+	Major      = "major"
+	Minor      = "minor"
+	Patch      = "patch"
+	PreMajor   = "premajor"
+	PreMinor   = "preminor"
+	PrePatch   = "prepatch"
+	Prerelease = "prerelease"
+)
+
+// Diff classifies the change between two versions like semver.diff:
+// "major", "minor", "patch", their "pre" variants when the greater version
+// is a prerelease, or "prerelease". It returns "" when the versions are
+// equal or invalid.
+func Diff(a, b string) string {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	if errA != nil || errB != nil {
+		return ""
+	}
+
+	c := va.Compare(vb)
+	if c == 0 {
+		return ""
+	}
+
+	high, low := va, vb
+	if c < 0 {
+		high, low = vb, va
+	}
+
+	// Going from a prerelease to its release
+	if low.IsPrerelease() && !high.IsPrerelease() {
+		// A prerelease of a major version is always a major bump
+		if low.Patch == 0 && low.Minor == 0 {
+			return Major
+		}
+
+		if low.compareMain(high) == 0 {
+			if low.Minor != 0 && low.Patch == 0 {
+				return Minor
+			}
+			return Patch
+		}
+	}
+
+	prefix := ""
+	if high.IsPrerelease() {
+		prefix = "pre"
+	}
+
+	switch {
+	case high.Major != low.Major:
+		return prefix + Major
+	case high.Minor != low.Minor:
+		return prefix + Minor
+	case high.Patch != low.Patch:
+		return prefix + Patch
+	}
+	return Prerelease
+}
+
+// Classify tells whether going from one version to a newer one is a
+// "major", "minor" or "patch" update, folding prereleases into the release
+// they lead to. It returns "" when to is not newer than from.
+func Classify(from, to string) string {
+	if Compare(to, from) <= 0 {
+		return ""
+	}
+
+	switch diff := Diff(from, to); diff {
+	case PreMajor:
+		return Major
+	case PreMinor:
+		return Minor
+	case PrePatch, Prerelease:
+		return Patch
+	default:
+		return diff
+	}
+}
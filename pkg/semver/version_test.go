@@ -0,0 +1,209 @@
+package semver
+
+import "testing"
+
+// Ported from node-semver test/fixtures/comparisons.js: the first version
+// is greater than the second
+var comparisons = []struct {
+	greater, lesser string
+}{
+	{"0.0.0", "0.0.0-foo"},
+	{"0.0.1", "0.0.0"},
+	{"1.0.0", "0.9.9"},
+	{"0.10.0", "0.9.0"},
+	{"0.99.0", "0.10.0"},
+	{"2.0.0", "1.2.3"},
+	{"v0.0.0", "0.0.0-foo"},
+	{"v0.0.1", "0.0.0"},
+	{"v1.0.0", "0.9.9"},
+	{"v0.10.0", "0.9.0"},
+	{"v0.99.0", "0.10.0"},
+	{"v2.0.0", "1.2.3"},
+	{"0.0.0", "v0.0.0-foo"},
+	{"0.0.1", "v0.0.0"},
+	{"1.0.0", "v0.9.9"},
+	{"0.10.0", "v0.9.0"},
+	{"0.99.0", "v0.10.0"},
+	{"2.0.0", "v1.2.3"},
+	{"1.2.3", "1.2.3-asdf"},
+	{"1.2.3", "1.2.3-4"},
+	{"1.2.3", "1.2.3-4-foo"},
+	{"1.2.3-5-foo", "1.2.3-5"},
+	{"1.2.3-5", "1.2.3-4"},
+	{"1.2.3-5-foo", "1.2.3-5-Foo"},
+	{"3.0.0", "2.7.2+asdf"},
+	{"1.2.3-a.10", "1.2.3-a.5"},
+	{"1.2.3-a.b", "1.2.3-a.5"},
+	{"1.2.3-a.b", "1.2.3-a"},
+	{"1.2.3-a.b.c.10.d.5", "1.2.3-a.b.c.5.d.100"},
+	{"1.2.3-r2", "1.2.3-r100"},
+	{"1.2.3-r100", "1.2.3-R2"},
+}
+
+// Ported from node-semver test/fixtures/equality.js
+var equalities = []struct {
+	a, b string
+}{
+	{"1.2.3", "v1.2.3"},
+	{"1.2.3", "=1.2.3"},
+	{"1.2.3", "v 1.2.3"},
+	{"1.2.3", "= 1.2.3"},
+	{"1.2.3", " v1.2.3"},
+	{"1.2.3", " =1.2.3"},
+	{"1.2.3", " v 1.2.3"},
+	{"1.2.3", " = 1.2.3"},
+	{"1.2.3-0", "v1.2.3-0"},
+	{"1.2.3-0", "=1.2.3-0"},
+	{"1.2.3-0", "v 1.2.3-0"},
+	{"1.2.3-0", "= 1.2.3-0"},
+	{"1.2.3-1", "v1.2.3-1"},
+	{"1.2.3-1", "=1.2.3-1"},
+	{"1.2.3-beta", "v1.2.3-beta"},
+	{"1.2.3-beta", "=1.2.3-beta"},
+	{"1.2.3-beta", " v 1.2.3-beta"},
+	{"1.2.3-beta+build", " = 1.2.3-beta+otherbuild"},
+	{"1.2.3+build", " = 1.2.3+otherbuild"},
+	{"1.2.3-beta+build", "1.2.3-beta+otherbuild"},
+	{"1.2.3+build", "1.2.3+otherbuild"},
+	{"  v1.2.3+build", "1.2.3+otherbuild"},
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range comparisons {
+		if got := Compare(tc.greater, tc.lesser); got != 1 {
+			t.Errorf("Compare(%q, %q) = %d, want 1", tc.greater, tc.lesser, got)
+		}
+		if got := Compare(tc.lesser, tc.greater); got != -1 {
+			t.Errorf("Compare(%q, %q) = %d, want -1", tc.lesser, tc.greater, got)
+		}
+		if got := Compare(tc.greater, tc.greater); got != 0 {
+			t.Errorf("Compare(%q, %q) = %d, want 0", tc.greater, tc.greater, got)
+		}
+	}
+}
+
+func TestEquality(t *testing.T) {
+	for _, tc := range equalities {
+		if got := Compare(tc.a, tc.b); got != 0 {
+			t.Errorf("Compare(%q, %q) = %d, want 0", tc.a, tc.b, got)
+		}
+		if got := Compare(tc.b, tc.a); got != 0 {
+			t.Errorf("Compare(%q, %q) = %d, want 0", tc.b, tc.a, got)
+		}
+	}
+}
+
+func TestCompareInvalid(t *testing.T) {
+	// Invalid versions sort before valid ones
+	if got := Compare("not a version", "0.0.1"); got != -1 {
+		t.Errorf("Compare(invalid, valid) = %d, want -1", got)
+	}
+	if got := Compare("0.0.1", "1.2"); got != 1 {
+		t.Errorf("Compare(valid, invalid) = %d, want 1", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{" =1.2.3 ", "1.2.3"},
+		{"1.2.3-beta.1", "1.2.3-beta.1"},
+		{"1.2.3-beta.1+build.5", "1.2.3-beta.1"},
+		{"1.2.3+build", "1.2.3"},
+		{"10.20.30", "10.20.30"},
+	}
+	for _, tc := range tests {
+		v, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if got := v.String(); got != tc.want {
+			t.Errorf("Parse(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{"", "1", "1.2", "1.2.3.4", "a.b.c", "1.2.3-", "1.2.3-a..b", "1.-2.3"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+// Ported from node-semver test/functions/diff.js
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"1.2.3", "0.2.3", Major},
+		{"0.2.3", "1.2.3", Major},
+		{"1.4.5", "0.2.3", Major},
+		{"1.2.3", "2.0.0-pre", PreMajor},
+		{"2.0.0-pre", "1.2.3", PreMajor},
+		{"1.2.3", "1.3.3", Minor},
+		{"1.0.1", "1.1.0-pre", PreMinor},
+		{"1.2.3", "1.2.4", Patch},
+		{"1.2.3", "1.2.4-pre", PrePatch},
+		{"0.0.1", "0.0.1-pre", Patch},
+		{"0.0.1", "0.0.1-pre-2", Patch},
+		{"1.1.0", "1.1.0-pre", Minor},
+		{"1.1.0-pre-1", "1.1.0-pre-2", Prerelease},
+		{"1.0.0", "1.0.0", ""},
+		{"1.0.0-1", "1.0.0-1", ""},
+		{"0.0.2-1", "0.0.2", Patch},
+		{"0.0.2-1", "0.0.3", Patch},
+		{"0.0.2-1", "0.1.0", Minor},
+		{"0.0.2-1", "1.0.0", Major},
+		{"0.1.0-1", "0.1.0", Minor},
+		{"1.0.0-1", "1.0.0", Major},
+		{"1.0.0-1", "1.1.1", Major},
+		{"1.0.0-1", "2.1.1", Major},
+		{"1.0.1-1", "1.0.1", Patch},
+		{"0.0.0-1", "0.0.0", Major},
+		{"1.0.0-1", "2.0.0", Major},
+		{"1.0.0-1", "2.0.0-1", PreMajor},
+		{"1.0.0-1", "1.1.0-1", PreMinor},
+		{"1.0.0-1", "1.0.1-1", PrePatch},
+		{"1.7.2-1", "1.8.1", Minor},
+		{"1.1.1-pre", "2.1.1-pre", PreMajor},
+		{"1.1.1-pre", "2.1.1", Major},
+		{"1.2.3-1", "1.2.3", Patch},
+		{"1.4.0-1", "2.3.5", Major},
+		{"1.6.1-5", "1.7.2", Minor},
+		{"2.0.0-1", "2.1.1", Major},
+		{"1.0.0", "not a version", ""},
+	}
+	for _, tc := range tests {
+		if got := Diff(tc.a, tc.b); got != tc.want {
+			t.Errorf("Diff(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"1.2.3", "2.0.0", Major},
+		{"1.2.3", "1.3.0", Minor},
+		{"1.2.3", "1.2.4", Patch},
+		{"1.2.3", "2.0.0-rc.1", Major},
+		{"1.2.3", "1.3.0-beta", Minor},
+		{"1.2.3", "1.2.4-alpha", Patch},
+		{"1.2.3-beta.1", "1.2.3-beta.2", Patch},
+		{"0.9.0", "1.0.0", Major},
+		{"1.2.3", "1.2.3", ""},
+		{"2.0.0", "1.9.9", ""},
+	}
+	for _, tc := range tests {
+		if got := Classify(tc.from, tc.to); got != tc.want {
+			t.Errorf("Classify(%q, %q) = %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/audit"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// severityColors colors advisories by how serious they are
+var severityColors = map[audit.Severity]lipgloss.Color{
+	audit.Critical: lipgloss.Color("#d3869b"),
+	audit.High:     terminalBrightRed,
+	audit.Moderate: terminalBrightYellow,
+	audit.Low:      terminalBrightBlue,
+	audit.Info:     terminalBrightBlack,
+}
+
+// severityStyle returns the style of a severity label
+func severityStyle(severity audit.Severity) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(severityColors[severity]).Bold(severity >= audit.High)
+}
+
+// AuditPanel lists the security advisories of the project's dependencies
+type AuditPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	report         *audit.Report
+	cursor         int
+	offset         int
+	details        bool
+	loading        bool
+	busy           string // Description of the running fix, if any
+	statusMessage  string
+	statusTime     time.Time
+	error          string
+}
+
+// NewAuditPanel runs a security audit of the project
+func NewAuditPanel(packageManager *npm.PackageManager) *AuditPanel {
+	p := &AuditPanel{
+		title:          "Security Audit",
+		packageManager: packageManager,
+		report:         packageManager.LastAudit,
+		details:        true,
+	}
+
+	p.runAudit()
+	return p
+}
+
+// runAudit audits the project in the background
+func (p *AuditPanel) runAudit() {
+	p.loading = true
+	p.error = ""
+
+	go func() {
+		report, err := p.packageManager.Audit()
+		if err != nil {
+			p.error = fmt.Sprintf("Error running audit: %v", err)
+		} else {
+			p.report = report
+			if p.cursor >= len(report.Advisories) {
+				p.cursor = 0
+			}
+		}
+		p.loading = false
+	}()
+}
+
+// runFix applies a fix in the background and audits again once it is done
+func (p *AuditPanel) runFix(description string, fix func() error) {
+	if p.busy != "" || p.loading {
+		return
+	}
+	p.busy = description
+
+	go func() {
+		err := fix()
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+			return
+		}
+
+		p.statusMessage = fmt.Sprintf("✅ %s", description)
+		p.statusTime = time.Now()
+		p.runAudit()
+	}()
+}
+
+// selected returns the advisory under the cursor
+func (p *AuditPanel) selected() (audit.Advisory, bool) {
+	if p.report == nil || p.cursor >= len(p.report.Advisories) {
+		return audit.Advisory{}, false
+	}
+	return p.report.Advisories[p.cursor], true
+}
+
+// Init initializes the panel
+func (p *AuditPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *AuditPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || p.report == nil {
+		return p, nil
+	}
+
+	count := len(p.report.Advisories)
+	p.error = ""
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = count - 1
+
+	case "enter", "v":
+		// Toggle the details of the selected advisory
+		p.details = !p.details
+
+	case "r":
+		// Audit again
+		if !p.loading && p.busy == "" {
+			p.runAudit()
+		}
+
+	case "f":
+		// Fix the selected advisory
+		if advisory, ok := p.selected(); ok {
+			if !advisory.FixAvailable {
+				p.error = fmt.Sprintf("No fix available for %s, add an override instead", advisory.Package)
+				break
+			}
+
+			target := advisory.Package
+			if advisory.FixPackage != "" {
+				target = advisory.FixPackage + "@" + advisory.FixVersion
+			}
+			p.runFix(fmt.Sprintf("Fixed %s (%s)", advisory.Package, target), func() error {
+				return p.packageManager.FixAdvisory(advisory)
+			})
+		}
+
+	case "F":
+		// Let the package manager fix everything it can
+		p.runFix("Ran audit fix", p.packageManager.AuditFix)
+
+	case "o":
+		// Force the patched version of the selected package
+		if advisory, ok := p.selected(); ok {
+			if advisory.PatchedRange == "" {
+				p.error = fmt.Sprintf("No patched version of %s is known", advisory.Package)
+				break
+			}
+
+			field := strings.Join(p.packageManager.Client.OverridesField(), ".")
+			p.runFix(fmt.Sprintf("Added %s %q to %s, reinstall to apply it", advisory.Package, advisory.PatchedRange, field), func() error {
+				return p.packageManager.SetOverride(advisory.Package, advisory.PatchedRange)
+			})
+		}
+	}
+
+	if p.cursor >= count {
+		p.cursor = count - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of advisory lines that fit
+func (p *AuditPanel) pageSize() int {
+	// Header, status line and the details below the list
+	size := p.height - 2
+	if p.details {
+		size -= len(p.detailLines())
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// advisoryLine renders one advisory of the list
+func (p *AuditPanel) advisoryLine(advisory audit.Advisory) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	line := severityStyle(advisory.Severity).Render(fmt.Sprintf("%-8s", advisory.Severity)) +
+		" " + HighlightStyle.Render(advisory.Package) +
+		" " + dimStyle.Render(advisory.VulnerableRange) +
+		" " + advisory.Title
+
+	switch {
+	case advisory.FixIsMajor:
+		line += lipgloss.NewStyle().Foreground(terminalBrightYellow).Render(" fix is a breaking change")
+	case advisory.FixAvailable:
+		line += lipgloss.NewStyle().Foreground(terminalBrightCyan).Render(" fix available")
+	default:
+		line += dimStyle.Render(" no fix")
+	}
+
+	return line
+}
+
+// detailLines describes the selected advisory
+func (p *AuditPanel) detailLines() []string {
+	advisory, ok := p.selected()
+	if !ok {
+		return nil
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	lines := []string{
+		dimStyle.Render(strings.Repeat("─", p.width)),
+		fmt.Sprintf("%s %s", severityStyle(advisory.Severity).Render(strings.ToUpper(advisory.Severity.String())), advisory.Title),
+		fmt.Sprintf("Vulnerable: %s   Patched: %s", advisory.VulnerableRange, orNone(advisory.PatchedRange)),
+	}
+
+	if advisory.URL != "" {
+		lines = append(lines, dimStyle.Render(advisory.URL))
+	}
+
+	if advisory.FixPackage != "" {
+		fix := fmt.Sprintf("Fix: install %s@%s", advisory.FixPackage, advisory.FixVersion)
+		if advisory.FixIsMajor {
+			fix += " (semver major)"
+		}
+		lines = append(lines, fix)
+	}
+
+	// Show a few of the paths, they can be many
+	const maxPaths = 3
+	for i, path := range advisory.Paths {
+		if i == maxPaths {
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("  and %d more paths", len(advisory.Paths)-maxPaths)))
+			break
+		}
+		lines = append(lines, "  "+path)
+	}
+
+	return lines
+}
+
+// orNone returns the value or "none" when it is empty
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// View renders the panel
+func (p *AuditPanel) View() string {
+	if p.report == nil {
+		if p.error != "" {
+			return ErrorStyle.Render(p.error)
+		}
+		return "Running security audit..."
+	}
+
+	header := "No known vulnerabilities"
+	if len(p.report.Advisories) > 0 {
+		var counts []string
+		bySeverity := p.report.Counts()
+		for _, severity := range audit.Severities {
+			if bySeverity[severity] > 0 {
+				counts = append(counts, severityStyle(severity).Render(fmt.Sprintf("%d %s", bySeverity[severity], severity)))
+			}
+		}
+		header = fmt.Sprintf("%d vulnerabilities: %s", len(p.report.Advisories), strings.Join(counts, ", "))
+	}
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for i := p.offset; i < len(p.report.Advisories) && i < p.offset+size; i++ {
+		line := p.advisoryLine(p.report.Advisories[i])
+		if i == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the details and status line stay at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	if p.details {
+		for _, line := range p.detailLines() {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+		}
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the progress, the last result or the key hints
+func (p *AuditPanel) statusLine() string {
+	status := "[f]Fix [F]Fix all [o]Override [↵]Details [r]Audit again"
+
+	switch {
+	case p.busy != "":
+		status = "Working... " + status
+	case p.loading:
+		status = "Auditing... " + status
+	case p.error != "":
+		status = ErrorStyle.Render(p.error)
+	case p.statusMessage != "" && time.Since(p.statusTime) < 5*time.Second:
+		status = HighlightStyle.Render(p.statusMessage)
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *AuditPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *AuditPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *AuditPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *AuditPanel) Title() string {
+	return p.title
+}
@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/registry"
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Autocomplete tuning
+const (
+	completeDelay    = 250 * time.Millisecond // Wait for typing to pause before querying
+	maxSuggestions   = 8
+	searchResultSize = 20
+)
+
+// suggestion is one entry of the autocomplete dropdown
+type suggestion struct {
+	value  string // Text put in the input when accepted
+	label  string
+	detail string
+	info   string // Shown after the label, e.g. latest version and downloads
+}
+
+// packageCompleter suggests package names from the registry while typing,
+// and versions and dist-tags once the input reads "name@"
+type packageCompleter struct {
+	registry *registry.Client
+
+	mu          sync.Mutex
+	query       string             // Input the suggestions are for, or being fetched for
+	cancel      context.CancelFunc // Cancels the pending query
+	searching   bool
+	suggestions []suggestion
+	selected    int // Highlighted suggestion, -1 for none
+	err         string
+	packument   *registry.Packument // Last fetched package, reused while typing its version
+}
+
+// newPackageCompleter creates a completer querying the given registry
+func newPackageCompleter(client *registry.Client) *packageCompleter {
+	return &packageCompleter{registry: client, selected: -1}
+}
+
+// Update queries the registry for the current input, cancelling the
+// previous query. The query starts once typing pauses.
+func (c *packageCompleter) Update(input string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	input = strings.TrimSpace(input)
+	if input == c.query {
+		return
+	}
+	// Drop the suggestions for the previous input so none can be accepted
+	// while the new ones are fetched
+	c.query = input
+	c.suggestions = nil
+	c.selected = -1
+	c.err = ""
+
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	if input == "" || c.registry == nil {
+		c.searching = false
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.searching = true
+
+	go func() {
+		// Debounce: a newer keystroke cancels us while we wait
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(completeDelay):
+		}
+
+		suggestions, err := c.fetch(ctx, input)
+		if ctx.Err() != nil {
+			return // Stale
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.query != input {
+			return
+		}
+		c.searching = false
+		if err != nil {
+			c.err = err.Error()
+			return
+		}
+		c.suggestions = suggestions
+	}()
+}
+
+// fetch returns the suggestions for an input
+func (c *packageCompleter) fetch(ctx context.Context, input string) ([]suggestion, error) {
+	// "name@" lists the versions of name; the @ of a scope doesn't count
+	if i := strings.LastIndex(input, "@"); i > 0 {
+		return c.versions(ctx, input[:i], input[i+1:])
+	}
+	return c.search(ctx, input)
+}
+
+// search suggests package names
+func (c *packageCompleter) search(ctx context.Context, text string) ([]suggestion, error) {
+	results, err := c.registry.Search(ctx, text, searchResultSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []suggestion
+	for _, result := range results {
+		info := result.Version
+		if result.WeeklyDownloads > 0 {
+			info = fmt.Sprintf("%s ↓%s/wk", result.Version, shortCount(result.WeeklyDownloads))
+		}
+		suggestions = append(suggestions, suggestion{
+			value:  result.Name,
+			label:  result.Name,
+			detail: result.Description,
+			info:   info,
+		})
+	}
+	return suggestions, nil
+}
+
+// versions suggests the dist-tags and published versions of a package
+// that start with the typed prefix, newest first
+func (c *packageCompleter) versions(ctx context.Context, name, prefix string) ([]suggestion, error) {
+	c.mu.Lock()
+	packument := c.packument
+	c.mu.Unlock()
+
+	if packument == nil || packument.Name != name {
+		var err error
+		packument, err = c.registry.AbbreviatedPackument(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		packument.Name = name
+
+		// The abbreviated packument has no publish dates, and the full one
+		// can weigh megabytes: show them only when it is cached already
+		if full, ok := c.registry.CachedPackument(name); ok {
+			packument.Time = full.Time
+		}
+
+		c.mu.Lock()
+		c.packument = packument
+		c.mu.Unlock()
+	}
+
+	var suggestions []suggestion
+	for _, tag := range distTagNames(packument.DistTags) {
+		if strings.HasPrefix(tag, prefix) {
+			suggestions = append(suggestions, suggestion{
+				value: name + "@" + tag,
+				label: tag,
+				info:  packument.DistTags[tag],
+			})
+		}
+	}
+
+	versions := packument.VersionList()
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		if !strings.HasPrefix(version, prefix) {
+			continue
+		}
+
+		detail := ""
+		if v, err := semver.Parse(version); err == nil && v.IsPrerelease() {
+			detail = "prerelease"
+		}
+		if deprecated := packument.Versions[version].Deprecated; deprecated != "" {
+			detail = "deprecated"
+		}
+
+		suggestions = append(suggestions, suggestion{
+			value:  name + "@" + version,
+			label:  version,
+			detail: detail,
+			info:   strings.SplitN(packument.Time[version], "T", 2)[0],
+		})
+	}
+
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("no version of %s matches %q", name, prefix)
+	}
+	return suggestions, nil
+}
+
+// Move highlights the next or previous suggestion
+func (c *packageCompleter) Move(delta int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := len(c.suggestions)
+	if count > maxSuggestions {
+		count = maxSuggestions
+	}
+	if count == 0 {
+		return
+	}
+
+	c.selected += delta
+	if c.selected < -1 {
+		c.selected = count - 1
+	}
+	if c.selected >= count {
+		c.selected = -1
+	}
+}
+
+// Accept returns the value of the highlighted suggestion, if any
+func (c *packageCompleter) Accept() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.selected < 0 || c.selected >= len(c.suggestions) {
+		return "", false
+	}
+	return c.suggestions[c.selected].value, true
+}
+
+// Reset clears the suggestions and cancels the pending query
+func (c *packageCompleter) Reset() {
+	c.Update("")
+}
+
+// Searching reports whether a query is pending
+func (c *packageCompleter) Searching() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.searching
+}
+
+// View renders the dropdown in at most height lines
+func (c *packageCompleter) View(width, height int) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	if c.query == "" {
+		return ""
+	}
+	if c.err != "" {
+		return ErrorStyle.Render(lipgloss.NewStyle().MaxWidth(width).Render(c.err))
+	}
+	if c.searching {
+		return dimStyle.Render("Searching...")
+	}
+
+	var lines []string
+	for i, s := range c.suggestions {
+		if i == maxSuggestions || len(lines) == height {
+			break
+		}
+
+		line := s.label
+		if i == c.selected {
+			line = SelectedItemStyle.Render(line)
+		} else {
+			line = HighlightStyle.Render(line)
+		}
+		if s.info != "" {
+			line += " " + lipgloss.NewStyle().Foreground(terminalBrightYellow).Render(s.info)
+		}
+		if s.detail != "" {
+			line += " " + dimStyle.Render(s.detail)
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// distTagNames returns the dist-tags with latest first, then alphabetically
+func distTagNames(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "latest" || names[j] == "latest" {
+			return names[i] == "latest"
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// shortCount formats a count as 950, 12.3k or 4.1M
+func shortCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprint(n)
+}
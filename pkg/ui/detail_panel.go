@@ -0,0 +1,358 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PackageDetailPanel shows everything known about a dependency, from
+// node_modules and the registry, followed by its README
+type PackageDetailPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+
+	mu            sync.Mutex // Guards the fields the registry fetch writes
+	detail        *npm.PackageDetail
+	loading       bool
+	registryError string
+	linesWidth    int // Width the lines were wrapped for
+
+	lines  []string
+	offset int
+}
+
+// NewPackageDetailPanel inspects the named dependency
+func NewPackageDetailPanel(packageManager *npm.PackageManager, name string) *PackageDetailPanel {
+	p := &PackageDetailPanel{
+		title:          name,
+		packageManager: packageManager,
+		detail:         packageManager.LocalDetail(name),
+		loading:        true,
+	}
+
+	// Fetch the registry metadata in the background
+	go func() {
+		detail := packageManager.LocalDetail(name)
+		err := packageManager.RegistryDetail(context.Background(), detail)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err != nil {
+			p.registryError = fmt.Sprintf("Registry: %v", err)
+		} else {
+			p.detail = detail
+		}
+		p.loading = false
+		p.linesWidth = 0 // Render again
+	}()
+
+	return p
+}
+
+// render builds the lines of the panel for the current width; the caller holds the lock
+func (p *PackageDetailPanel) render() []string {
+	d := p.detail
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	labelStyle := lipgloss.NewStyle().Foreground(terminalBrightBlue).Width(14)
+	warnStyle := lipgloss.NewStyle().Foreground(terminalBrightYellow)
+
+	var lines []string
+	field := func(label, value string) {
+		if value != "" {
+			lines = append(lines, labelStyle.Render(label)+value)
+		}
+	}
+
+	// Description
+	description := ""
+	if d.Installed != nil {
+		description = d.Installed.Description
+	}
+	if description == "" && d.Packument != nil {
+		description = d.Packument.Description
+	}
+	if description != "" {
+		lines = append(lines, description, "")
+	}
+
+	// Versions
+	installed := ErrorStyle.Render("not installed")
+	if d.Installed != nil {
+		installed = HighlightStyle.Render(d.Installed.Version)
+	}
+	field("Installed", installed)
+
+	declared := d.Range
+	if d.Type != "" && d.Type != npm.TypeDependency {
+		declared += dimStyle.Render(" (" + typeLabel(d.Type) + ")")
+	}
+	field("Declared", declared)
+
+	if d.Packument != nil {
+		latest := d.Packument.Latest()
+		if d.Installed != nil {
+			if bump := npm.BumpType(d.Installed.Version, latest); bump != "" {
+				latest = bumpStyle(bump).Render(latest) + dimStyle.Render(" "+bump+" update")
+			}
+		}
+		field("Latest", latest)
+
+		var tags []string
+		for _, tag := range distTagNames(d.Packument.DistTags) {
+			tags = append(tags, fmt.Sprintf("%s: %s", tag, d.Packument.DistTags[tag]))
+		}
+		field("Dist-tags", strings.Join(tags, ", "))
+	} else if p.loading {
+		field("Latest", dimStyle.Render("fetching..."))
+	}
+
+	// Metadata, preferring the installed package.json
+	version, hasVersion := d.Version()
+	license, homepage, repository := "", "", ""
+	if d.Installed != nil {
+		license, homepage, repository = string(d.Installed.License), d.Installed.Homepage, d.Installed.Repository.URL
+	}
+	if d.Packument != nil {
+		if license == "" {
+			license = string(d.Packument.License)
+		}
+		if homepage == "" {
+			homepage = d.Packument.Homepage
+		}
+		if repository == "" {
+			repository = d.Packument.Repository.URL
+		}
+	}
+	field("License", license)
+	field("Homepage", homepage)
+	field("Repository", repository)
+
+	if hasVersion && version.Deprecated != "" {
+		field("Deprecated", warnStyle.Render(version.Deprecated))
+	}
+	if hasVersion && version.Dist.UnpackedSize > 0 {
+		field("Unpacked size", fmt.Sprintf("%s (%d files)", formatBytes(version.Dist.UnpackedSize), version.Dist.FileCount))
+	}
+
+	// Dependencies, engines, peers and bins
+	dependencies, engines, peers, bins := version.Dependencies, version.Engines, version.PeerDependencies, version.Bin.Named(d.Name)
+	optionalPeers := make(map[string]bool)
+	for name, meta := range version.PeerDependenciesMeta {
+		optionalPeers[name] = meta.Optional
+	}
+	if d.Installed != nil {
+		dependencies, engines, peers, bins = d.Installed.Dependencies, d.Installed.Engines, d.Installed.PeerDependencies, d.Installed.Bin.Named(d.Name)
+		for name, meta := range d.Installed.PeerDependenciesMeta {
+			optionalPeers[name] = meta.Optional
+		}
+	}
+
+	if d.Installed != nil || hasVersion {
+		field("Dependencies", fmt.Sprint(len(dependencies)))
+	}
+	field("Engines", joinMap(engines, " "))
+
+	if len(peers) > 0 {
+		var list []string
+		for _, name := range sortedNames(peers) {
+			peer := name + " " + peers[name]
+			if optionalPeers[name] {
+				peer += dimStyle.Render(" (optional)")
+			}
+			list = append(list, peer)
+		}
+		field("Peers", strings.Join(list, ", "))
+	}
+
+	if len(bins) > 0 {
+		field("Bin", strings.Join(sortedNames(bins), ", "))
+	}
+
+	if p.registryError != "" {
+		lines = append(lines, "", dimStyle.Render(p.registryError))
+	}
+
+	// The README
+	lines = append(lines, "", TitleStyle.Render("README"))
+	if d.Readme == "" {
+		if p.loading {
+			lines = append(lines, dimStyle.Render("Fetching..."))
+		} else {
+			lines = append(lines, dimStyle.Render("No README"))
+		}
+	}
+	lines = append(lines, renderMarkdown(d.Readme, p.width)...)
+
+	return lines
+}
+
+// renderMarkdown turns a README into wrapped plain text lines: headings are
+// highlighted without their #, code blocks are dimmed and not wrapped
+func renderMarkdown(text string, width int) []string {
+	headingStyle := lipgloss.NewStyle().Foreground(terminalBrightYellow).Bold(true)
+	codeStyle := lipgloss.NewStyle().Foreground(terminalBrightCyan)
+	wrap := lipgloss.NewStyle().Width(width)
+
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+		case inCode:
+			lines = append(lines, codeStyle.Render("  "+line))
+		case strings.HasPrefix(trimmed, "#"):
+			lines = append(lines, headingStyle.Render(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		case trimmed == "":
+			// Collapse runs of blank lines
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+		default:
+			lines = append(lines, strings.Split(wrap.Render(line), "\n")...)
+		}
+	}
+
+	return lines
+}
+
+// formatBytes formats a size as 512 B, 12.3 kB or 4.5 MB
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1000*1000:
+		return fmt.Sprintf("%.1f MB", float64(size)/1000/1000)
+	case size >= 1000:
+		return fmt.Sprintf("%.1f kB", float64(size)/1000)
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// sortedNames returns the keys of a map in order
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// joinMap formats a map as "key value, key value"
+func joinMap(m map[string]string, separator string) string {
+	var parts []string
+	for _, name := range sortedNames(m) {
+		parts = append(parts, name+separator+m[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Init initializes the panel
+func (p *PackageDetailPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *PackageDetailPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	maxOffset := len(p.lines) - p.pageSize()
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.offset--
+	case "down", "j":
+		p.offset++
+	case "pgup", "b":
+		p.offset -= p.pageSize()
+	case "pgdown", " ":
+		p.offset += p.pageSize()
+	case "g", "home":
+		p.offset = 0
+	case "G", "end":
+		p.offset = maxOffset
+	}
+
+	if p.offset > maxOffset {
+		p.offset = maxOffset
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of lines that fit above the status line
+func (p *PackageDetailPanel) pageSize() int {
+	size := p.height - 1
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// View renders the panel
+func (p *PackageDetailPanel) View() string {
+	// Wrap again when the width changes or the registry answered
+	p.mu.Lock()
+	if p.linesWidth != p.width {
+		p.lines = p.render()
+		p.linesWidth = p.width
+	}
+	p.mu.Unlock()
+
+	size := p.pageSize()
+	lines := make([]string, 0, size+1)
+	for i := p.offset; i < len(p.lines) && i < p.offset+size; i++ {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(p.lines[i]))
+	}
+	for len(lines) < size {
+		lines = append(lines, "")
+	}
+
+	status := "[↑/↓]Scroll [PgUp/PgDn]Page [Esc]Close"
+	if len(p.lines) > size {
+		status += fmt.Sprintf("  %d%%", (p.offset+size)*100/len(p.lines))
+	}
+	lines = append(lines, status)
+
+	return strings.Join(lines, "\n")
+}
+
+// Width returns the panel width
+func (p *PackageDetailPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *PackageDetailPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *PackageDetailPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *PackageDetailPanel) Title() string {
+	return p.title
+}
@@ -0,0 +1,451 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/diff"
+	"github.com/VesperAkshay/lazynode/pkg/history"
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// diffFile is a file compared by the diff panel
+type diffFile struct {
+	path      string // Absolute location of the file
+	old       []byte
+	new       []byte
+	oldExists bool
+	newExists bool
+}
+
+// DiffPanel shows how package.json and the lockfile changed, against git
+// HEAD, against the state before the last LazyNode operation, or within a
+// recorded operation
+type DiffPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	journal        *history.Journal
+	source         string         // "head", "last" or "entry"
+	entry          *history.Entry // The operation shown for "entry"
+	back           Panel          // Panel to return to, if any
+	raw            bool           // Show lockfiles as text instead of package changes
+	lines          []string
+	offset         int
+	loading        bool
+	error          string
+}
+
+// NewDiffPanel compares package.json and the lockfile with git HEAD
+func NewDiffPanel(packageManager *npm.PackageManager) *DiffPanel {
+	p := &DiffPanel{
+		title:          "Diff",
+		packageManager: packageManager,
+		journal:        packageManager.History,
+		source:         "head",
+	}
+	p.refresh()
+	return p
+}
+
+// NewEntryDiffPanel shows what a recorded operation changed, returning to
+// back when done
+func NewEntryDiffPanel(packageManager *npm.PackageManager, entry history.Entry, back Panel) *DiffPanel {
+	p := &DiffPanel{
+		title:          "Diff",
+		packageManager: packageManager,
+		journal:        packageManager.History,
+		source:         "entry",
+		entry:          &entry,
+		back:           back,
+	}
+	p.refresh()
+	return p
+}
+
+// refresh reads the files and renders the diff in the background
+func (p *DiffPanel) refresh() {
+	p.loading = true
+	p.error = ""
+
+	go func() {
+		files, description, err := p.files()
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+			p.lines = nil
+			p.loading = false
+			return
+		}
+
+		lines := []string{lipgloss.NewStyle().Bold(true).Render(description)}
+		for _, file := range files {
+			lines = append(lines, "")
+			lines = append(lines, p.render(file)...)
+		}
+
+		p.lines = lines
+		p.loading = false
+	}()
+}
+
+// files returns the files to compare for the current source
+func (p *DiffPanel) files() ([]diffFile, string, error) {
+	switch p.source {
+	case "entry":
+		var files []diffFile
+		for _, recorded := range p.entry.Files {
+			file := diffFile{path: p.journal.FilePath(recorded)}
+			var err error
+			if file.old, file.oldExists, err = p.snapshot(recorded.Before); err != nil {
+				return nil, "", err
+			}
+			if file.new, file.newExists, err = p.snapshot(recorded.After); err != nil {
+				return nil, "", err
+			}
+			files = append(files, file)
+		}
+		return files, fmt.Sprintf("Changes made by: %s (%s)", p.entry.Description, p.entry.Time.Format("Jan 02 15:04")), nil
+
+	case "last":
+		entry, ok, err := p.journal.Last()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read the history: %v", err)
+		}
+		if !ok {
+			return nil, "", fmt.Errorf("no LazyNode operation recorded yet")
+		}
+
+		var files []diffFile
+		for _, recorded := range entry.Files {
+			file := diffFile{path: p.journal.FilePath(recorded)}
+			if file.old, file.oldExists, err = p.snapshot(recorded.Before); err != nil {
+				return nil, "", err
+			}
+			file.new, file.newExists = readFile(file.path)
+			files = append(files, file)
+		}
+		return files, fmt.Sprintf("Changes since before: %s (%s)", entry.Description, entry.Time.Format("Jan 02 15:04")), nil
+	}
+
+	// Against git HEAD
+	var files []diffFile
+	for _, path := range p.trackedFiles() {
+		file := diffFile{path: path}
+		var err error
+		if file.old, file.oldExists, err = diff.GitHead(path); err != nil {
+			return nil, "", err
+		}
+		file.new, file.newExists = readFile(path)
+		files = append(files, file)
+	}
+	return files, "Changes since git HEAD", nil
+}
+
+// trackedFiles returns package.json and the lockfile of the project
+func (p *DiffPanel) trackedFiles() []string {
+	paths := []string{p.packageManager.PackageJSONPath}
+
+	if path, _, err := lockfile.Find(filepath.Dir(p.packageManager.PackageJSONPath)); err == nil {
+		return append(paths, path)
+	}
+	// bun lockfiles aren't parsed, but still shown
+	for _, name := range []string{"bun.lock", "bun.lockb"} {
+		path := filepath.Join(filepath.Dir(p.packageManager.PackageJSONPath), name)
+		if _, err := os.Stat(path); err == nil {
+			return append(paths, path)
+		}
+	}
+	return paths
+}
+
+// snapshot reads a file recorded in the history, "" when it didn't exist
+func (p *DiffPanel) snapshot(object string) ([]byte, bool, error) {
+	if object == "" {
+		return nil, false, nil
+	}
+	data, err := p.journal.Read(object)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the history: %v", err)
+	}
+	return data, true, nil
+}
+
+// readFile reads a file from disk, false when it doesn't exist
+func readFile(path string) ([]byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// render returns the lines showing how a file changed
+func (p *DiffPanel) render(file diffFile) []string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	nameStyle := lipgloss.NewStyle().Bold(true).Foreground(terminalBrightBlue)
+
+	name := file.path
+	if rel, err := filepath.Rel(filepath.Dir(p.packageManager.PackageJSONPath), file.path); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+
+	switch {
+	case !file.oldExists && !file.newExists:
+		return []string{nameStyle.Render(name) + dimStyle.Render(" does not exist")}
+	case file.oldExists == file.newExists && bytes.Equal(file.old, file.new):
+		return []string{nameStyle.Render(name) + dimStyle.Render(" unchanged")}
+	case bytes.IndexByte(file.old, 0) >= 0 || bytes.IndexByte(file.new, 0) >= 0:
+		return []string{nameStyle.Render(name) + dimStyle.Render(" binary file changed")}
+	}
+
+	header := nameStyle.Render(name)
+	if !file.oldExists {
+		header += dimStyle.Render(" (new file)")
+	} else if !file.newExists {
+		header += dimStyle.Render(" (deleted)")
+	}
+
+	// Lockfiles are summarized as package changes unless asked for the text
+	if kind, ok := lockfile.KindOf(filepath.Base(file.path)); ok && !p.raw {
+		lines, err := p.renderLockfile(file, kind)
+		if err == nil {
+			return append([]string{header}, lines...)
+		}
+		header += ErrorStyle.Render(fmt.Sprintf(" (%v, showing the text)", err))
+	}
+
+	hunks := diff.Unified(file.old, file.new, diffContext)
+	added, removed := diff.Stats(hunks)
+	lines := []string{fmt.Sprintf("%s %s %s", header,
+		lipgloss.NewStyle().Foreground(terminalBrightGreen).Render(fmt.Sprintf("+%d", added)),
+		lipgloss.NewStyle().Foreground(terminalBrightRed).Render(fmt.Sprintf("-%d", removed)))}
+	return append(lines, renderHunks(hunks)...)
+}
+
+// renderHunks colors the lines of a unified diff
+func renderHunks(hunks []diff.Hunk) []string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	addStyle := lipgloss.NewStyle().Foreground(terminalBrightGreen)
+	removeStyle := lipgloss.NewStyle().Foreground(terminalBrightRed)
+	hunkStyle := lipgloss.NewStyle().Foreground(terminalCyan)
+
+	var lines []string
+	for _, hunk := range hunks {
+		lines = append(lines, hunkStyle.Render(hunk.Header()))
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case diff.Insert:
+				lines = append(lines, addStyle.Render("+"+line.Text))
+			case diff.Delete:
+				lines = append(lines, removeStyle.Render("-"+line.Text))
+			default:
+				lines = append(lines, dimStyle.Render(" "+line.Text))
+			}
+		}
+	}
+	return lines
+}
+
+// renderLockfile summarizes the packages added, removed and moved to
+// another version between two versions of a lockfile
+func (p *DiffPanel) renderLockfile(file diffFile, kind lockfile.Kind) ([]string, error) {
+	// yarn v1 lockfiles take their root from the package.json next to them
+	manifest := &lockfile.Manifest{}
+	if data, err := os.ReadFile(filepath.Join(filepath.Dir(file.path), "package.json")); err == nil {
+		json.Unmarshal(data, manifest)
+	}
+
+	var old, new *lockfile.Graph
+	var err error
+	if file.oldExists {
+		if old, err = lockfile.Parse(kind, file.old, manifest); err != nil {
+			return nil, fmt.Errorf("old lockfile parse error: %v", err)
+		}
+	}
+	if file.newExists {
+		if new, err = lockfile.Parse(kind, file.new, manifest); err != nil {
+			return nil, fmt.Errorf("lockfile parse error: %v", err)
+		}
+	}
+
+	comparison := lockfile.Compare(old, new)
+	lines := []string{HighlightStyle.Render(comparison.Summary())}
+
+	addStyle := lipgloss.NewStyle().Foreground(terminalBrightGreen)
+	removeStyle := lipgloss.NewStyle().Foreground(terminalBrightRed)
+	downStyle := lipgloss.NewStyle().Foreground(terminalBrightYellow)
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	for _, change := range comparison.Added {
+		lines = append(lines, addStyle.Render("+ "+change.String()))
+	}
+	for _, change := range comparison.Upgraded {
+		lines = append(lines, addStyle.Render("↑ "+change.String()))
+	}
+	for _, change := range comparison.Downgraded {
+		lines = append(lines, downStyle.Render("↓ "+change.String()))
+	}
+	for _, change := range comparison.Changed {
+		lines = append(lines, dimStyle.Render("~ "+change.String()))
+	}
+	for _, change := range comparison.Removed {
+		lines = append(lines, removeStyle.Render("- "+change.String()))
+	}
+	if comparison.Empty() {
+		lines = append(lines, dimStyle.Render("Only the lockfile text changed, press l to see it"))
+	}
+	return lines, nil
+}
+
+// Init initializes the panel
+func (p *DiffPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *DiffPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.offset--
+	case "down", "j":
+		p.offset++
+	case "pgup":
+		p.offset -= p.pageSize()
+	case "pgdown":
+		p.offset += p.pageSize()
+	case "g", "home":
+		p.offset = 0
+	case "G", "end":
+		p.offset = len(p.lines)
+
+	case "s", "tab":
+		// Switch between git HEAD and the last operation
+		if p.loading {
+			break
+		}
+		if p.source == "head" {
+			p.source = "last"
+		} else {
+			p.source = "head"
+		}
+		p.offset = 0
+		p.refresh()
+
+	case "l":
+		// Toggle the lockfile text
+		if !p.loading {
+			p.raw = !p.raw
+			p.refresh()
+		}
+
+	case "r":
+		if !p.loading {
+			p.refresh()
+		}
+
+	case "backspace":
+		// Back to the history
+		if p.back != nil {
+			return p.back, nil
+		}
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of lines that fit
+func (p *DiffPanel) pageSize() int {
+	// Status line
+	size := p.height - 1
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// View renders the panel
+func (p *DiffPanel) View() string {
+	if p.loading {
+		return "Comparing..."
+	}
+
+	// Keep the offset within the lines
+	size := p.pageSize()
+	if p.offset > len(p.lines)-size {
+		p.offset = len(p.lines) - size
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+
+	var lines []string
+	if p.error != "" {
+		lines = append(lines, ErrorStyle.Render(p.error))
+	}
+	for row := p.offset; row < len(p.lines) && row < p.offset+size; row++ {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(p.lines[row]))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the key hints
+func (p *DiffPanel) statusLine() string {
+	status := "[s]Compare with the last operation"
+	if p.source == "last" {
+		status = "[s]Compare with git HEAD"
+	}
+	if p.raw {
+		status += " [l]Package changes"
+	} else {
+		status += " [l]Lockfile text"
+	}
+	status += " [r]Refresh"
+	if p.back != nil {
+		status += " [⌫]Back"
+	}
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *DiffPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *DiffPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *DiffPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *DiffPanel) Title() string {
+	return p.title
+}
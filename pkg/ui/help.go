@@ -43,16 +43,103 @@ Navigation:
   ↑/↓         : Navigate within panel
   alt+↑/↓     : Switch between panels
   enter       : Select/run script
+  ctrl+z      : Undo last change to package.json / lockfile
+  ctrl+y      : Redo
+  H           : Change history
+  W           : Workspaces of the monorepo
+  ctrl+o      : Switch to a recent project
   
 Scripts:
   r           : Refresh scripts
+  s           : Stop script
+  R           : Restart script
+  v           : Show process status
+  c           : Toggle restart on crash
   
 Packages:
+  enter       : Package details and README
   a           : Show all actions
-  i           : Install package
-  d           : Uninstall package
+  i           : Install package (suggests names, and versions after @)
+  s           : Add package to a section (prod/dev/peer/optional/bundled/override)
+  m           : Move package to another section
+  f           : Filter by section
+  d           : Uninstall package (or remove override)
   u           : Update package
-  o           : Check for outdated
+  o           : Outdated packages
+  t           : Dependency tree
+  w           : Why is this package installed?
+  A           : Security audit
+  P           : Peer dependencies
+  D           : Diff package.json and the lockfile
+  V           : Version drift across workspaces and lockfile duplicates
+
+Dependency Tree:
+  enter/→     : Expand / collapse
+  ←           : Collapse or go to parent
+  /           : Search, n/N for next/previous
+  w           : Why is this package installed?
+  esc         : Close
+
+Outdated Packages:
+  space       : Select package
+  a           : Select all / none
+  w           : Update selected to wanted
+  L           : Update selected to latest
+  r           : Check again
+
+Security Audit:
+  f           : Fix selected advisory
+  F           : Fix all (audit fix)
+  o           : Override with the patched version
+  enter       : Toggle details
+  r           : Audit again
+
+Peer Dependencies:
+  1-9         : Install a suggested version
+  tab         : Switch between the last install's report and the tree check
+  r           : Check again
+
+History:
+  u / U       : Undo / redo
+  i           : Reinstall to bring node_modules in sync
+  enter       : Diff of the selected change
+
+Diff:
+  s / tab     : Compare with git HEAD / the last operation
+  l           : Toggle lockfile text / package changes
+  r           : Refresh
+  backspace   : Back to the history
+
+Workspaces:
+  enter       : Make the selected package the active project
+  x           : Run a script in every package
+  v           : Version drift and duplicates report
+
+Dependency Report:
+  tab         : Switch between version drift and lockfile duplicates
+  1-9         : Align every package to the selected range
+  d           : Dedupe the lockfile
+  r           : Analyze again
+
+Run Across Workspaces:
+  enter       : Run the selected script
+  +/-         : More / fewer packages at a time
+  c           : Continue or stop when a package fails
+  s           : Stop the run
+  r / n       : Run again / pick another script
+
+Switch Project:
+  type        : Filter projects by name or path
+  enter       : Open the selected project
+  ctrl+t      : Pin / unpin
+  k / s       : Keep the running processes in the background / stop them
+
+Terminal:
+  [ / ]       : Previous / next output tab
+  o           : Toggle output tabs / LazyNode logs
+  x           : Close tab of a finished process
+  i           : Send keystrokes to the running process
+  ctrl+]      : Stop sending keystrokes
 `
 
 	// Render without borders or padding
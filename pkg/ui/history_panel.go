@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/history"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/project"
+	"github.com/VesperAkshay/lazynode/pkg/scripts"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HistoryPanel lists the changes made to package.json and the lockfile, and
+// undoes or redoes them
+type HistoryPanel struct {
+	title          string
+	width          int
+	height         int
+	journal        *history.Journal
+	packageManager *npm.PackageManager
+	scriptRunner   *scripts.ScriptRunner
+	project        *project.Project
+	entries        []history.Entry
+	position       int // Number of entries applied
+	cursor         int
+	offset         int
+	busy           string // Description of the running undo, redo or install, if any
+	outOfSync      bool   // Files were restored, node_modules may not match them
+	statusMessage  string
+	statusTime     time.Time
+	error          string
+}
+
+// NewHistoryPanel opens the history of the project and runs action first,
+// "undo", "redo" or "" to only show it
+func NewHistoryPanel(packageManager *npm.PackageManager, scriptRunner *scripts.ScriptRunner, project *project.Project, action string) *HistoryPanel {
+	p := &HistoryPanel{
+		title:          "History",
+		journal:        packageManager.History,
+		packageManager: packageManager,
+		scriptRunner:   scriptRunner,
+		project:        project,
+	}
+
+	p.load()
+	switch action {
+	case "undo":
+		p.undo()
+	case "redo":
+		p.redo()
+	}
+	return p
+}
+
+// load reads the journal
+func (p *HistoryPanel) load() {
+	entries, position, err := p.journal.Entries()
+	if err != nil {
+		p.error = fmt.Sprintf("Error reading history: %v", err)
+		return
+	}
+	p.entries, p.position = entries, position
+}
+
+// undo restores the files as they were before the last applied entry
+func (p *HistoryPanel) undo() {
+	p.run("Undoing...", func() (string, error) {
+		entry, err := p.journal.Undo()
+		if err != nil {
+			return "", err
+		}
+		return "Undid: " + entry.Description, nil
+	})
+}
+
+// redo applies the entry after the last applied one again
+func (p *HistoryPanel) redo() {
+	p.run("Redoing...", func() (string, error) {
+		entry, err := p.journal.Redo()
+		if err != nil {
+			return "", err
+		}
+		return "Redid: " + entry.Description, nil
+	})
+}
+
+// run restores files in the background, then reloads everything reading them
+func (p *HistoryPanel) run(busy string, restore func() (string, error)) {
+	if p.busy != "" {
+		return
+	}
+
+	p.busy = busy
+	p.error = ""
+	go func() {
+		description, err := restore()
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+			return
+		}
+
+		p.reload()
+		p.load()
+		p.cursor = len(p.entries) - p.position
+		p.outOfSync = true
+		p.statusMessage = description
+		p.statusTime = time.Now()
+	}()
+}
+
+// reload makes the packages, scripts and project read the restored files
+func (p *HistoryPanel) reload() {
+	if err := p.packageManager.LoadPackages(); err != nil {
+		p.error = fmt.Sprintf("Error loading packages: %v", err)
+	}
+	if p.scriptRunner != nil {
+		if err := p.scriptRunner.LoadScripts(); err != nil {
+			p.error = fmt.Sprintf("Error loading scripts: %v", err)
+		}
+	}
+	if p.project != nil {
+		if err := p.project.LoadPackageJSON(); err != nil {
+			p.error = fmt.Sprintf("Error loading package.json: %v", err)
+		}
+	}
+}
+
+// reinstall brings node_modules in line with the restored files
+func (p *HistoryPanel) reinstall() {
+	if p.busy != "" {
+		return
+	}
+
+	p.busy = "Installing..."
+	p.error = ""
+	go func() {
+		err := p.packageManager.Reinstall()
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+			return
+		}
+
+		p.outOfSync = false
+		p.statusMessage = "✅ node_modules is in sync"
+		p.statusTime = time.Now()
+	}()
+}
+
+// Init initializes the panel
+func (p *HistoryPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *HistoryPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	p.error = ""
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = len(p.entries) - 1
+
+	case "u", "ctrl+z":
+		p.undo()
+	case "U", "ctrl+y":
+		p.redo()
+	case "i":
+		p.reinstall()
+	case "enter":
+		// Show what the selected entry changed
+		if index := len(p.entries) - 1 - p.cursor; index >= 0 && index < len(p.entries) && p.busy == "" {
+			return NewEntryDiffPanel(p.packageManager, p.entries[index], p), nil
+		}
+	}
+
+	if p.cursor >= len(p.entries) {
+		p.cursor = len(p.entries) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of entries that fit
+func (p *HistoryPanel) pageSize() int {
+	// Header and status line
+	size := p.height - 2
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// entryLine renders one entry, marking the ones undone
+func (p *HistoryPanel) entryLine(entry history.Entry, applied bool) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	files := make([]string, 0, len(entry.Files))
+	for _, file := range entry.Files {
+		files = append(files, file.Path)
+	}
+
+	description := entry.Description
+	if entry.Failed {
+		description += ErrorStyle.Render(" (failed)")
+	}
+
+	if !applied {
+		return dimStyle.Render(fmt.Sprintf("↶ %s  %s  %s", entry.Time.Format("Jan 02 15:04"), entry.Description, strings.Join(files, ", ")))
+	}
+	return fmt.Sprintf("%s %s  %s  %s",
+		HighlightStyle.Render("✓"),
+		dimStyle.Render(entry.Time.Format("Jan 02 15:04")),
+		description,
+		dimStyle.Render(strings.Join(files, ", ")))
+}
+
+// View renders the panel
+func (p *HistoryPanel) View() string {
+	if len(p.entries) == 0 {
+		if p.error != "" {
+			return ErrorStyle.Render(p.error)
+		}
+		return "No changes recorded yet\n" + p.statusLine()
+	}
+
+	header := fmt.Sprintf("%d changes, newest first", len(p.entries))
+	if undone := len(p.entries) - p.position; undone > 0 {
+		header += fmt.Sprintf(", %d undone", undone)
+	}
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for row := p.offset; row < len(p.entries) && row < p.offset+size; row++ {
+		// Newest first
+		index := len(p.entries) - 1 - row
+		line := p.entryLine(p.entries[index], index < p.position)
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the progress, the last result or the key hints
+func (p *HistoryPanel) statusLine() string {
+	status := "[u]Undo [U]Redo [↵]Diff"
+	if p.outOfSync {
+		status += " [i]Reinstall to sync node_modules"
+	}
+
+	switch {
+	case p.busy != "":
+		status = p.busy
+	case p.error != "":
+		status = ErrorStyle.Render(p.error)
+	case p.statusMessage != "" && time.Since(p.statusTime) < 5*time.Second:
+		status = HighlightStyle.Render(p.statusMessage) + " " + status
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *HistoryPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *HistoryPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *HistoryPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *HistoryPanel) Title() string {
+	return p.title
+}
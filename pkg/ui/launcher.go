@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/project"
+	"github.com/VesperAkshay/lazynode/pkg/recent"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Options tell the model which project to open
+type Options struct {
+	// Path is a project directory or package.json given on the command
+	// line. The project is looked for from the working directory without it.
+	Path string
+	// Detect controls the search for package.json in the parent directories
+	Detect project.DetectOptions
+	// ScanDepth is how many directory levels below the working directory
+	// are searched for projects to offer in the launcher
+	ScanDepth int
+}
+
+// DefaultOptions looks for the project from the working directory
+func DefaultOptions() Options {
+	return Options{Detect: project.DefaultDetectOptions(), ScanDepth: 3}
+}
+
+// showLauncherMsg asks the user to pick a project
+type showLauncherMsg struct {
+	dir        string   // Where the projects were discovered
+	discovered []string // package.json files below dir
+}
+
+// launcherEntry is a project offered by the launcher
+type launcherEntry struct {
+	packageJSONPath string
+	name            string
+	detail          string // When it was opened, for recent projects
+	recent          bool
+	pinned          bool
+}
+
+// LauncherPanel lists the recently opened projects and the ones found below
+// the working directory, to pick the one to open
+type LauncherPanel struct {
+	title   string
+	width   int
+	height  int
+	dir     string
+	entries []launcherEntry
+	cursor  int
+	offset  int
+}
+
+// NewLauncherPanel offers the recent projects first, then the ones
+// discovered below dir that are not recent
+func NewLauncherPanel(dir string, discovered []string) *LauncherPanel {
+	p := &LauncherPanel{title: "Open a Project", dir: dir}
+
+	seen := make(map[string]bool)
+	if registry, err := recent.Load(); err == nil {
+		for _, project := range registry.Existing() {
+			seen[project.PackageJSONPath] = true
+			p.entries = append(p.entries, launcherEntry{
+				packageJSONPath: project.PackageJSONPath,
+				name:            project.Name,
+				detail:          "opened " + project.OpenedAt.Format("Jan 02 15:04"),
+				recent:          true,
+				pinned:          project.Pinned,
+			})
+		}
+	}
+	for _, packageJSONPath := range discovered {
+		if seen[filepath.Clean(packageJSONPath)] {
+			continue
+		}
+		p.entries = append(p.entries, launcherEntry{
+			packageJSONPath: packageJSONPath,
+			name:            project.ReadName(packageJSONPath),
+		})
+	}
+	return p
+}
+
+// Init initializes the panel
+func (p *LauncherPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *LauncherPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = len(p.entries) - 1
+
+	case "enter":
+		// Open the selected project
+		if p.cursor < len(p.entries) {
+			path := p.entries[p.cursor].packageJSONPath
+			return p, func() tea.Msg {
+				return switchProjectMsg{path: path}
+			}
+		}
+	}
+
+	if p.cursor >= len(p.entries) {
+		p.cursor = len(p.entries) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of projects that fit
+func (p *LauncherPanel) pageSize() int {
+	// Header and status line
+	size := p.height - 2
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// location shows where a project lives, relative to the launch directory
+// when it's below it and to the home directory otherwise
+func (p *LauncherPanel) location(packageJSONPath string) string {
+	dir := filepath.Dir(packageJSONPath)
+	if rel, err := filepath.Rel(p.dir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return "./" + filepath.ToSlash(rel)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return dir
+}
+
+// View renders the panel
+func (p *LauncherPanel) View() string {
+	if len(p.entries) == 0 {
+		return fmt.Sprintf("No package.json found in or below %s, and no recent projects\n\nPress q to quit", p.dir)
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	header := fmt.Sprintf("No package.json in %s: choose the project to open", p.dir)
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for row := p.offset; row < len(p.entries) && row < p.offset+size; row++ {
+		entry := p.entries[row]
+
+		kind := dimStyle.Render("found ")
+		if entry.pinned {
+			kind = SelectedItemStyle.Render("pinned")
+		} else if entry.recent {
+			kind = HighlightStyle.Render("recent")
+		}
+		line := fmt.Sprintf(" %s %-30s %-40s %s", kind, entry.name, p.location(entry.packageJSONPath), dimStyle.Render(entry.detail))
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render("[↵]Open [q]Quit"))
+	return strings.Join(lines, "\n")
+}
+
+// Width returns the panel width
+func (p *LauncherPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *LauncherPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *LauncherPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *LauncherPanel) Title() string {
+	return p.title
+}
@@ -2,13 +2,17 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/VesperAkshay/lazynode/pkg/npm"
 	"github.com/VesperAkshay/lazynode/pkg/npx"
+	"github.com/VesperAkshay/lazynode/pkg/process"
 	"github.com/VesperAkshay/lazynode/pkg/project"
+	"github.com/VesperAkshay/lazynode/pkg/recent"
 	"github.com/VesperAkshay/lazynode/pkg/scripts"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,6 +34,11 @@ type KeyMap struct {
 	Update      key.Binding
 	Reload      key.Binding
 	Edit        key.Binding
+	Undo        key.Binding
+	Redo        key.Binding
+	History     key.Binding
+	Workspaces  key.Binding
+	Projects    key.Binding
 	TabScripts  key.Binding
 	TabPackages key.Binding
 	TabProject  key.Binding
@@ -53,6 +62,7 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.TabScripts, k.TabPackages, k.TabProject, k.TabNpx, k.TabLogs},
 		{k.Install, k.Delete, k.Outdated, k.Update},
 		{k.Enter, k.Reload, k.Edit},
+		{k.Undo, k.Redo, k.History, k.Workspaces, k.Projects},
 		{k.Help, k.Quit},
 	}
 }
@@ -112,6 +122,26 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("e"),
 			key.WithHelp("e", "edit package.json"),
 		),
+		Undo: key.NewBinding(
+			key.WithKeys("ctrl+z"),
+			key.WithHelp("ctrl+z", "undo last change"),
+		),
+		Redo: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "redo change"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "change history"),
+		),
+		Workspaces: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "workspaces"),
+		),
+		Projects: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "switch project"),
+		),
 		TabScripts: key.NewBinding(
 			key.WithKeys("1"),
 			key.WithHelp("1", "scripts panel"),
@@ -156,7 +186,12 @@ type Model struct {
 	packageMgr   *npm.PackageManager
 	scriptRunner *scripts.ScriptRunner
 	npxRunner    *npx.Runner
+	workspace    *workspace.Workspace // Monorepo the project belongs to, nil outside one
+	options      Options
+	launcher     *LauncherPanel                 // Shown until a project is picked, when none was found
+	background   map[string]*process.Supervisor // Projects switched away from with processes left running, see backgroundKey
 	logs         *LogsPanel
+	overlay      Panel // Full-width panel shown in place of the grid, e.g. the dependency tree
 	helpPanel    *HelpPanel
 	showHelp     bool
 	ready        bool
@@ -169,8 +204,8 @@ type Model struct {
 	quitScreen QuitModel
 }
 
-// NewModel initializes a new model with default values
-func NewModel() Model {
+// NewModel initializes a new model opening the project the options point to
+func NewModel(options Options) Model {
 	keys := DefaultKeyMap()
 	helpPanel := NewHelpPanel()
 	splashScreen := NewSplashModel()
@@ -181,6 +216,8 @@ func NewModel() Model {
 		help:         help.New(),
 		activeTab:    "scripts",
 		panels:       make(map[string]Panel),
+		options:      options,
+		background:   make(map[string]*process.Supervisor),
 		helpPanel:    helpPanel,
 		showHelp:     false,
 		ready:        false,
@@ -201,13 +238,112 @@ func (m Model) Init() tea.Cmd {
 	)
 }
 
-// detectProject is a command that tries to find a package.json file
+// detectProject is a command that tries to find a package.json file. When
+// the working directory has none and several projects lie below it, or none
+// is found at all, the launcher lets the user pick one.
 func (m Model) detectProject() tea.Msg {
-	packageJSONPath, err := project.Detect()
+	if m.options.Path != "" {
+		packageJSONPath, err := project.Resolve(m.options.Path, m.options.Detect)
+		if err != nil {
+			return errorMsg(fmt.Sprintf("Could not find a package.json file for %s: %v", m.options.Path, err))
+		}
+		return loadProject(packageJSONPath, nil)
+	}
+
+	dir, err := os.Getwd()
 	if err != nil {
 		return errorMsg(fmt.Sprintf("Could not find a package.json file: %v", err))
 	}
 
+	// The working directory is a project
+	packageJSONPath := filepath.Join(dir, "package.json")
+	if _, err := os.Stat(packageJSONPath); err == nil {
+		return loadProject(packageJSONPath, nil)
+	}
+
+	// Don't pick one of several projects below
+	discovered := project.Discover(dir, m.options.ScanDepth)
+	if len(discovered) > 1 {
+		return showLauncherMsg{dir: dir, discovered: discovered}
+	}
+
+	// Then the project around the working directory, or the only one below
+	if packageJSONPath, err := project.DetectFrom(dir, m.options.Detect); err == nil {
+		return loadProject(packageJSONPath, nil)
+	}
+	if len(discovered) == 1 {
+		return loadProject(discovered[0], nil)
+	}
+
+	return showLauncherMsg{dir: dir}
+}
+
+// reloadProject is a command that loads the current project again, keeping
+// its running processes
+func (m Model) reloadProject() tea.Msg {
+	return loadProject(m.projectPath, m.scriptRunner.Supervisor)
+}
+
+// switchProject returns a command making another package.json the active
+// project. Processes started from the current one keep running.
+func (m Model) switchProject(packageJSONPath string) tea.Cmd {
+	supervisor := m.scriptRunner.Supervisor
+	return func() tea.Msg {
+		return loadProject(packageJSONPath, supervisor)
+	}
+}
+
+// replaceProject returns a command opening another project with its own
+// package manager, script runner and npx runner. The current project is only
+// replaced once the other one has loaded: see projectOpenedMsg.
+func (m Model) replaceProject(packageJSONPath string, stopRunning bool) tea.Cmd {
+	// Look up the supervisors from the command without racing Update
+	background := make(map[string]*process.Supervisor, len(m.background))
+	for key, supervisor := range m.background {
+		background[key] = supervisor
+	}
+	current, currentKey := m.scriptRunner.Supervisor, backgroundKey(m.projectPath, m.workspace)
+
+	return func() tea.Msg {
+		// Pick up the processes the project, or its monorepo, left running.
+		// Another package of the current monorepo shares its supervisor.
+		ws, _ := workspace.Find(filepath.Dir(packageJSONPath))
+		key := backgroundKey(packageJSONPath, ws)
+		supervisor := background[key]
+		if key == currentKey {
+			supervisor = current
+		}
+
+		msg := loadProject(packageJSONPath, supervisor)
+		if err, ok := msg.(errorMsg); ok {
+			return openProjectFailedMsg{path: packageJSONPath, error: string(err)}
+		}
+		return projectOpenedMsg{detected: msg.(projectDetectedMsg), stopRunning: stopRunning}
+	}
+}
+
+// backgroundKey files the supervisor of a project in Model.background: the
+// packages of a monorepo share one, under the root of the monorepo
+func backgroundKey(packageJSONPath string, ws *workspace.Workspace) string {
+	if ws != nil {
+		return filepath.Clean(ws.RootDir)
+	}
+	return filepath.Clean(packageJSONPath)
+}
+
+// backgroundRunning returns the number of processes running in the projects
+// switched away from
+func (m Model) backgroundRunning() int {
+	running := 0
+	for _, supervisor := range m.background {
+		running += supervisor.Running()
+	}
+	return running
+}
+
+// loadProject opens the project of a package.json. The supervisor, when
+// given, is kept so the processes it runs stay visible.
+func loadProject(packageJSONPath string, supervisor *process.Supervisor) tea.Msg {
 	// Initialize the project
 	proj, err := project.NewProject(packageJSONPath)
 	if err != nil {
@@ -232,12 +368,66 @@ func (m Model) detectProject() tea.Msg {
 		return errorMsg(fmt.Sprintf("Error initializing npx runner: %v", err))
 	}
 
+	// Share one supervisor so every running process is tracked in one place
+	if supervisor != nil {
+		scriptRunner.Supervisor = supervisor
+	}
+	npxRunner.Supervisor = scriptRunner.Supervisor
+
+	// Offer the project in the launcher next time. A registry that can't
+	// be written doesn't stop the project from opening.
+	recent.Remember(packageJSONPath, proj.Name)
+
+	// A package of a monorepo runs its commands from the root, with the
+	// client's workspace flag
+	ws, err := workspace.Find(filepath.Dir(packageJSONPath))
+	if err != nil {
+		ws = nil
+	} else if pkg := ws.PackageAt(filepath.Dir(packageJSONPath)); pkg != ws.Root {
+		pkgMgr.Workspace, pkgMgr.RootDir = pkg.Selector(ws.RootDir), ws.RootDir
+		scriptRunner.Workspace, scriptRunner.RootDir = pkg.Selector(ws.RootDir), ws.RootDir
+	}
+
 	return projectDetectedMsg{
 		path:         packageJSONPath,
 		project:      proj,
 		packageMgr:   pkgMgr,
 		scriptRunner: scriptRunner,
 		npxRunner:    npxRunner,
+		workspace:    ws,
+	}
+}
+
+// overlaySize returns the content size of the overlay: the full width and
+// the height of the panel grid, minus borders and title
+func (m Model) overlaySize() (int, int) {
+	termWidth, termHeight := m.width, m.height
+	if termWidth < 80 {
+		termWidth = 80
+	}
+	if termHeight < 24 {
+		termHeight = 24
+	}
+
+	topRowHeight := (termHeight - 2) * 4 / 5
+	if topRowHeight < 8 {
+		topRowHeight = 8
+	}
+
+	// Match the height of the two grid rows, borders included
+	gridHeight := topRowHeight/2*2 + 2
+
+	return termWidth - 4, gridHeight - 1
+}
+
+// refreshPanels shows the packages and scripts again, which the overlay may
+// have changed, e.g. by undoing an install
+func (m Model) refreshPanels() {
+	if packagesPanel, ok := m.panels["packages"].(*PackagesPanel); ok {
+		packagesPanel.refreshPackageList()
+	}
+	if scriptsPanel, ok := m.panels["scripts"].(*ScriptsPanel); ok {
+		scriptsPanel.refreshScripts()
 	}
 }
 
@@ -251,6 +441,7 @@ type projectDetectedMsg struct {
 	packageMgr   *npm.PackageManager
 	scriptRunner *scripts.ScriptRunner
 	npxRunner    *npx.Runner
+	workspace    *workspace.Workspace
 }
 
 // startTicker creates a ticker for real-time updates
@@ -289,6 +480,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// If quit screen is done, exit the application
 		if m.quitScreen.IsDone() {
+			// Stop running scripts and commands before exiting
+			if m.scriptRunner != nil {
+				m.scriptRunner.Supervisor.StopAll()
+			}
+			for _, supervisor := range m.background {
+				supervisor.StopAll()
+			}
 			return m, tea.Quit
 		} else if cmd != nil {
 			return m, cmd
@@ -365,12 +563,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// The launcher gets every key press until a project is picked
+		if m.launcher != nil && !key.Matches(msg, m.keys.Quit) {
+			updatedPanel, cmd := m.launcher.Update(msg)
+			m.launcher = updatedPanel.(*LauncherPanel)
+			return m, cmd
+		}
+
+		// The overlay gets every key press, esc closes it
+		if m.ready && !m.showHelp && m.overlay != nil {
+			capturer, ok := m.overlay.(inputCapturer)
+			capturing := ok && capturer.CapturingInput()
+
+			switch {
+			case !capturing && msg.String() == "esc":
+				m.overlay = nil
+				m.refreshPanels()
+				return m, nil
+			case !capturing && key.Matches(msg, m.keys.Quit):
+				m.showQuit = true
+				m.quitScreen = NewQuitModel()
+				return m, m.quitScreen.Init()
+			case !capturing && key.Matches(msg, m.keys.Help):
+				m.showHelp = !m.showHelp
+				return m, nil
+			}
+
+			updatedPanel, cmd := m.overlay.Update(msg)
+			m.overlay = updatedPanel
+			return m, cmd
+		}
+
 		// Check if any panel has active input or confirmation dialog - if so, pass the event to that panel
 		if m.ready && !m.showHelp {
 			if panel, ok := m.panels[m.activeTab]; ok {
-				// Check if panel has active input/dialogs (for PackagesPanel)
-				if packagesPanel, ok := panel.(*PackagesPanel); ok &&
-					(packagesPanel.showInput || packagesPanel.showActions || packagesPanel.showConfirm) {
+				if capturer, ok := panel.(inputCapturer); ok && capturer.CapturingInput() {
 					updatedPanel, cmd := panel.Update(msg)
 					m.panels[m.activeTab] = updatedPanel
 					return m, cmd
@@ -412,7 +639,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.Reload) && m.ready:
 			// Reload the project
-			return m, m.detectProject
+			return m, m.reloadProject
+
+		case key.Matches(msg, m.keys.Undo) && m.ready:
+			// Undo the last change and show where it leaves the history
+			return m, openOverlay(NewHistoryPanel(m.packageMgr, m.scriptRunner, m.project, "undo"))
+
+		case key.Matches(msg, m.keys.Redo) && m.ready:
+			return m, openOverlay(NewHistoryPanel(m.packageMgr, m.scriptRunner, m.project, "redo"))
+
+		case key.Matches(msg, m.keys.History) && m.ready:
+			return m, openOverlay(NewHistoryPanel(m.packageMgr, m.scriptRunner, m.project, ""))
+
+		case key.Matches(msg, m.keys.Workspaces) && m.ready:
+			if m.workspace == nil {
+				m.logs.AddLog("Not in a monorepo: no workspaces declared in package.json, pnpm-workspace.yaml, lerna.json or nx.json")
+				return m, nil
+			}
+			return m, openOverlay(NewWorkspacesPanel(m.workspace, m.scriptRunner, m.packageMgr, m.projectPath))
+
+		case key.Matches(msg, m.keys.Projects) && m.ready:
+			return m, openOverlay(NewProjectSwitcherPanel(m.projectPath, m.scriptRunner.Supervisor))
 		}
 
 		// Pass Tab key to switch panels
@@ -477,6 +724,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.error = string(msg)
 		return m, nil
 
+	case openOverlayMsg:
+		// Show the panel in place of the grid
+		m.overlay = msg.panel
+		m.overlay.SetSize(m.overlaySize())
+		return m, m.overlay.Init()
+
+	case showLauncherMsg:
+		// Let the user pick the project
+		m.launcher = NewLauncherPanel(msg.dir, msg.discovered)
+		m.launcher.SetSize(m.width-4, m.height-4)
+		return m, nil
+
+	case switchProjectMsg:
+		if m.launcher != nil {
+			// Open the project picked in the launcher
+			m.launcher = nil
+			return m, func() tea.Msg {
+				return loadProject(msg.path, nil)
+			}
+		}
+
+		// Make another package of the monorepo the active project
+		m.logs.AddLog(fmt.Sprintf("Switching to %s", msg.path))
+		return m, m.switchProject(msg.path)
+
+	case openProjectMsg:
+		// Load the project picked in the switcher
+		m.logs.AddLog(fmt.Sprintf("Opening %s", msg.path))
+		return m, m.replaceProject(msg.path, msg.stopRunning)
+
+	case projectOpenedMsg:
+		// The project loaded: stop the processes of the current one, or keep
+		// them running in the background until it is opened again or
+		// LazyNode quits. Another package of the same monorepo keeps them.
+		var stop tea.Cmd
+		current := m.scriptRunner.Supervisor
+		delete(m.background, backgroundKey(msg.detected.path, msg.detected.workspace))
+		if msg.stopRunning {
+			m.logs.AddLog(fmt.Sprintf("Stopping the processes of %s", m.project.Name))
+			stop = func() tea.Msg {
+				current.StopAll()
+				return nil
+			}
+		} else if current != msg.detected.scriptRunner.Supervisor && current.Running() > 0 {
+			m.background[backgroundKey(m.projectPath, m.workspace)] = current
+		}
+
+		model, cmd := m.Update(msg.detected)
+		return model, tea.Batch(stop, cmd)
+
+	case openProjectFailedMsg:
+		// Keep the current project, and tell why in the switcher
+		m.logs.AddLog(fmt.Sprintf("Could not open %s: %s", msg.path, msg.error))
+		if switcher, ok := m.overlay.(*ProjectSwitcherPanel); ok {
+			switcher.error = msg.error
+		}
+		return m, nil
+
+	case closeOverlayMsg:
+		m.overlay = nil
+		m.refreshPanels()
+		return m, nil
+
 	case projectDetectedMsg:
 		// Save the project info
 		m.projectPath = msg.path
@@ -484,9 +794,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.packageMgr = msg.packageMgr
 		m.scriptRunner = msg.scriptRunner
 		m.npxRunner = msg.npxRunner
+		m.workspace = msg.workspace
+		m.overlay = nil
 
-		// Create logs panel first
-		m.logs = NewLogsPanel()
+		// Create logs panel first, with an output tab per running process.
+		// A reload or a switch keeps the logs.
+		if m.logs == nil {
+			m.logs = NewLogsPanel()
+		}
+		m.logs.SetSupervisor(m.scriptRunner.Supervisor)
 
 		// Create panels
 		scriptsPanel := NewScriptsPanel(m.scriptRunner)
@@ -516,6 +832,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Add welcome message to logs
 		m.logs.AddLog(fmt.Sprintf("Welcome to LazyNode v1.0 - Managing project: %s", m.project.Name))
 		m.logs.AddLog(fmt.Sprintf("Found %d packages in package.json", len(m.packageMgr.Packages)))
+		m.logs.AddLog(fmt.Sprintf("Using package manager: %s", m.packageMgr.Client.Name()))
+		if m.packageMgr.Graph != nil {
+			m.logs.AddLog(fmt.Sprintf("Read %s", m.packageMgr.Graph))
+		}
 		m.logs.AddLog("Use tabs 1-5 to navigate between panels")
 		m.logs.AddLog("Press ? for help")
 
@@ -530,15 +850,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				logsPanel.Update(nil)
 			}
 
+			// Let the overlay pick up work finished in the background
+			if m.overlay != nil {
+				m.overlay, _ = m.overlay.Update(nil)
+			}
+
+			// Show the changes made to package.json by other panels
+			if projectPanel, ok := m.panels["project"].(*ProjectPanel); ok {
+				projectPanel.Update(nil)
+			}
+
 			// Update script status in real-time
 			if scriptsPanel, ok := m.panels["scripts"].(*ScriptsPanel); ok {
-				if scriptsPanel.activeScript != "" {
-					scriptsPanel.Update(nil)
+				scriptsPanel.Update(nil)
+				if scriptsPanel.loading {
 					activeOperation = true
-					// Add log for long-running scripts periodically
-					if time.Now().Second()%5 == 0 {
-						m.logs.AddLog(fmt.Sprintf("Script still running: %s", scriptsPanel.activeScript))
-					}
 				}
 			}
 
@@ -549,6 +875,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.panels["packages"] = updatedPanel
 					activeOperation = true
 				}
+				if packagesPanel.completing() && packagesPanel.completer.Searching() {
+					activeOperation = true
+				}
 			}
 
 			// If there's an active operation, reduce tick interval for more responsive UI
@@ -583,6 +912,14 @@ func (m Model) View() string {
 		return fmt.Sprintf("Error: %s\n\nPress q to quit", m.error)
 	}
 
+	if m.launcher != nil {
+		m.launcher.SetSize(m.width-4, m.height-4)
+		return lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#b8bb26")).
+			Render(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#fabd2f")).Padding(0, 1).Render(m.launcher.Title()) + "\n" + m.launcher.View())
+	}
+
 	if !m.ready {
 		return "Loading LazyNode..."
 	}
@@ -654,8 +991,24 @@ func (m Model) View() string {
 	}
 
 	// Top status bar
-	statusBar := topBarStyle.Width(termWidth).
-		Render(fmt.Sprintf("LazyNode - %s", m.project.Name))
+	status := fmt.Sprintf("LazyNode - %s [%s]", m.project.Name, m.packageMgr.Client.Name())
+	if m.workspace != nil {
+		// Where the project sits in its monorepo
+		if m.packageMgr.Workspace != "" {
+			status += fmt.Sprintf(" | Workspace of %s", workspaceRootName(m.workspace))
+		} else {
+			status += fmt.Sprintf(" | Workspace root, %d packages", len(m.workspace.Packages))
+		}
+	}
+	if running := m.backgroundRunning(); running > 0 {
+		// Processes left running by other projects
+		status += fmt.Sprintf(" | %d running in other projects", running)
+	}
+	if m.packageMgr.LastAudit != nil {
+		// Severity counts of the last audit
+		status += " | Audit: " + m.packageMgr.LastAudit.Summary()
+	}
+	statusBar := topBarStyle.Width(termWidth).Render(status)
 
 	// Prepare all panels
 	panelContents := make(map[string]string)
@@ -704,9 +1057,24 @@ func (m Model) View() string {
 	bottomLeftRow := lipgloss.JoinHorizontal(lipgloss.Top, bottomLeftRendered, bottomRightRendered)
 	topGrid := lipgloss.JoinVertical(lipgloss.Left, topLeftRow, bottomLeftRow)
 
+	// An overlay takes the place of the grid
+	if m.overlay != nil {
+		overlayWidth, overlayHeight := m.overlaySize()
+		m.overlay.SetSize(overlayWidth, overlayHeight)
+
+		topGrid = selectedPanelStyle.
+			Width(termWidth - 2).
+			Height(overlayHeight + 1).
+			Render(fmt.Sprintf("%s\n%s",
+				titleStyle.Render(m.overlay.Title()),
+				m.overlay.View()))
+	}
+
 	// Help bar at the bottom
 	helpText := "[q]Quit [?]Help [Tab]Switch panels [1-5]Select panel [↵]Select"
-	if m.activeTab == "scripts" {
+	if m.overlay != nil {
+		helpText = "[q]Quit [?]Help [Esc]Close"
+	} else if m.activeTab == "scripts" {
 		helpText += " | [r]Run script"
 	} else if m.activeTab == "packages" {
 		helpText += " | [i]Install [d]Delete [u]Update"
@@ -92,13 +92,16 @@ func (p *NpxPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 					go func() {
 						p.logsPanel.AddLog(fmt.Sprintf("Running npx %s", value))
 
-						c, err := p.npxRunner.RunCommand(value)
+						proc, err := p.npxRunner.RunCommand(value)
 						if err != nil {
 							p.error = fmt.Sprintf("Error running npx command: %v", err)
 							p.logsPanel.AddLog(fmt.Sprintf("Error: %v", err))
 						} else {
+							// Stream the output into the terminal panel
+							p.logsPanel.AttachProcess(proc)
+
 							// Wait for the command to finish
-							err = c.Wait()
+							err = proc.Err()
 							if err != nil {
 								p.logsPanel.AddLog(fmt.Sprintf("Command exited with error: %v", err))
 							} else {
@@ -144,13 +147,16 @@ func (p *NpxPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 					go func() {
 						p.logsPanel.AddLog(fmt.Sprintf("Running npx %s", i.command.Command))
 
-						c, err := p.npxRunner.RunCommand(i.command.Command)
+						proc, err := p.npxRunner.RunCommand(i.command.Command)
 						if err != nil {
 							p.error = fmt.Sprintf("Error running npx command: %v", err)
 							p.logsPanel.AddLog(fmt.Sprintf("Error: %v", err))
 						} else {
+							// Stream the output into the terminal panel
+							p.logsPanel.AttachProcess(proc)
+
 							// Wait for the command to finish
-							err = c.Wait()
+							err = proc.Err()
 							if err != nil {
 								p.logsPanel.AddLog(fmt.Sprintf("Command exited with error: %v", err))
 							} else {
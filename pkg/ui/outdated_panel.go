@@ -0,0 +1,398 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bumpColors colors versions by how big the update is
+var bumpColors = map[string]lipgloss.Color{
+	npm.BumpPatch: terminalBrightGreen,
+	npm.BumpMinor: terminalBrightYellow,
+	npm.BumpMajor: terminalBrightRed,
+}
+
+// bumpStyle returns the style of a version reached by the given bump
+func bumpStyle(bump string) lipgloss.Style {
+	if color, ok := bumpColors[bump]; ok {
+		return lipgloss.NewStyle().Foreground(color)
+	}
+	return lipgloss.NewStyle()
+}
+
+// OutdatedPanel lists outdated packages with their current, wanted and
+// latest versions and updates a selection of them
+type OutdatedPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	packages       []npm.OutdatedPackage
+	selected       map[string]bool
+	cursor         int
+	offset         int
+	loading        bool
+	updating       string // Description of the running update, if any
+	confirm        bool   // Asking before rewriting ranges to latest
+	results        []npm.UpdateResult
+	resultsError   string // Why the versions the update ended with are unknown
+	error          string
+}
+
+// NewOutdatedPanel checks the project for outdated packages
+func NewOutdatedPanel(packageManager *npm.PackageManager) *OutdatedPanel {
+	p := &OutdatedPanel{
+		title:          "Outdated Packages",
+		packageManager: packageManager,
+		selected:       make(map[string]bool),
+	}
+
+	p.load()
+	return p
+}
+
+// load checks for outdated packages in the background
+func (p *OutdatedPanel) load() {
+	p.loading = true
+	p.error = ""
+
+	go func() {
+		outdated, err := p.packageManager.CheckOutdatedPackages()
+		if err != nil {
+			p.error = fmt.Sprintf("Error checking outdated packages: %v", err)
+		} else {
+			p.packages = npm.SortedOutdated(outdated)
+			p.selected = make(map[string]bool)
+			p.cursor = 0
+		}
+		p.loading = false
+	}()
+}
+
+// targets returns the selected packages, or the one under the cursor
+func (p *OutdatedPanel) targets() []npm.OutdatedPackage {
+	var targets []npm.OutdatedPackage
+	for _, pkg := range p.packages {
+		if p.selected[pkg.Name] {
+			targets = append(targets, pkg)
+		}
+	}
+
+	if len(targets) == 0 && p.cursor < len(p.packages) {
+		targets = append(targets, p.packages[p.cursor])
+	}
+	return targets
+}
+
+// update updates the targets in the background and shows the results
+func (p *OutdatedPanel) update(toLatest bool) {
+	targets := p.targets()
+	if len(targets) == 0 || p.updating != "" {
+		return
+	}
+
+	p.updating = fmt.Sprintf("Updating %d packages to wanted...", len(targets))
+	if toLatest {
+		p.updating = fmt.Sprintf("Updating %d packages to latest...", len(targets))
+	}
+
+	go func() {
+		results, err := p.packageManager.UpdatePackages(targets, toLatest)
+		p.results, p.resultsError = results, ""
+		if err != nil {
+			p.resultsError = err.Error()
+		}
+		p.updating = ""
+		p.load()
+	}()
+}
+
+// Init initializes the panel
+func (p *OutdatedPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *OutdatedPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || p.updating != "" {
+		return p, nil
+	}
+
+	// Confirm rewriting the ranges
+	if p.confirm {
+		p.confirm = false
+		if keyMsg.String() == "y" || keyMsg.String() == "Y" {
+			p.update(true)
+		}
+		return p, nil
+	}
+
+	// Any key dismisses the summary of the last update
+	if p.results != nil {
+		p.results = nil
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = len(p.packages) - 1
+
+	case " ":
+		// Toggle the package under the cursor
+		if p.cursor < len(p.packages) {
+			name := p.packages[p.cursor].Name
+			p.selected[name] = !p.selected[name]
+			p.cursor++
+		}
+
+	case "a":
+		// Select all, or none when all are selected
+		all := true
+		for _, pkg := range p.packages {
+			all = all && p.selected[pkg.Name]
+		}
+		for _, pkg := range p.packages {
+			p.selected[pkg.Name] = !all
+		}
+
+	case "w":
+		// Update within the declared ranges
+		p.update(false)
+
+	case "L":
+		// Update to latest, rewriting the ranges
+		if len(p.targets()) > 0 {
+			p.confirm = true
+		}
+
+	case "r":
+		// Check again
+		if !p.loading {
+			p.load()
+		}
+	}
+
+	if p.cursor >= len(p.packages) {
+		p.cursor = len(p.packages) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// CapturingInput reports whether the panel is asking for confirmation
+func (p *OutdatedPanel) CapturingInput() bool {
+	return p.confirm
+}
+
+// pageSize returns the number of package rows that fit
+func (p *OutdatedPanel) pageSize() int {
+	// Column header and status line
+	size := p.height - 2
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// View renders the panel
+func (p *OutdatedPanel) View() string {
+	if p.results != nil {
+		return p.summaryView()
+	}
+	if p.error != "" {
+		return ErrorStyle.Render(p.error)
+	}
+	if p.loading && p.packages == nil {
+		return "Checking for outdated packages..."
+	}
+	if len(p.packages) == 0 {
+		return HighlightStyle.Render("All packages are up to date")
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	// Size the name column to the longest name
+	nameWidth := len("Package")
+	for _, pkg := range p.packages {
+		if len(pkg.Name) > nameWidth {
+			nameWidth = len(pkg.Name)
+		}
+	}
+
+	header := dimStyle.Render(fmt.Sprintf("     %-*s  %-12s %-12s %-12s %s", nameWidth, "Package", "Current", "Wanted", "Latest", "Type"))
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for i := p.offset; i < len(p.packages) && i < p.offset+size; i++ {
+		pkg := p.packages[i]
+
+		check := "[ ]"
+		if p.selected[pkg.Name] {
+			check = HighlightStyle.Render("[x]")
+		}
+
+		current := pkg.Current
+		if current == "" {
+			current = "missing"
+		}
+
+		section := "prod"
+		if pkg.Type != "" {
+			section = typeLabel(pkg.Type)
+		}
+
+		// Color the name by the bump to latest, the versions by their own bump
+		bump := npm.BumpType(pkg.Current, pkg.Latest)
+		line := fmt.Sprintf("%s %s  %-12s %s %s %s",
+			check,
+			bumpStyle(bump).Render(fmt.Sprintf("%-*s", nameWidth, pkg.Name)),
+			current,
+			bumpStyle(npm.BumpType(pkg.Current, pkg.Wanted)).Render(fmt.Sprintf("%-12s", pkg.Wanted)),
+			bumpStyle(bump).Render(fmt.Sprintf("%-12s", pkg.Latest)),
+			dimStyle.Render(section))
+
+		if i == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the progress, the confirmation or the key hints
+func (p *OutdatedPanel) statusLine() string {
+	status := fmt.Sprintf("[Space]Select [a]All [w]Update to wanted [L]Update to latest [r]Refresh  %d outdated", len(p.packages))
+
+	switch {
+	case p.updating != "":
+		status = p.updating
+	case p.confirm:
+		status = lipgloss.NewStyle().Foreground(terminalBrightYellow).
+			Render(fmt.Sprintf("Update %d packages to latest and rewrite their ranges in package.json? [y/n]", len(p.targets())))
+	case p.loading:
+		status = "Checking... " + status
+	default:
+		var legend []string
+		for _, bump := range []string{npm.BumpPatch, npm.BumpMinor, npm.BumpMajor} {
+			legend = append(legend, bumpStyle(bump).Render(bump))
+		}
+		status += "  " + strings.Join(legend, " ")
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// summaryView lists what the last update changed
+func (p *OutdatedPanel) summaryView() string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	var changed, unchanged, unknown, failed int
+	var lines []string
+	for _, result := range p.results {
+		switch {
+		case result.Err != nil:
+			failed++
+			lines = append(lines, ErrorStyle.Render(fmt.Sprintf("✗ %s: %v", result.Name, result.Err)))
+		case result.To == "":
+			// The versions couldn't be read back, see resultsError
+			unknown++
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("? %s from %s, new version unknown", result.Name, result.From)))
+		case result.Changed():
+			changed++
+			line := fmt.Sprintf("✓ %s %s → %s", result.Name, result.From, bumpStyle(npm.BumpType(result.From, result.To)).Render(result.To))
+			if result.Range != "" {
+				line += dimStyle.Render(fmt.Sprintf("  package.json: %q", result.Range))
+			}
+			lines = append(lines, line)
+		default:
+			unchanged++
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("· %s stayed at %s", result.Name, result.From)))
+		}
+	}
+
+	header := HighlightStyle.Render(fmt.Sprintf("%d updated", changed)) +
+		fmt.Sprintf(", %d unchanged", unchanged)
+	if unknown > 0 {
+		header += fmt.Sprintf(", %d unknown", unknown)
+	}
+	if failed > 0 {
+		header += ErrorStyle.Render(fmt.Sprintf(", %d failed", failed))
+	}
+	if p.resultsError != "" {
+		header += ErrorStyle.Render(" - " + p.resultsError)
+	}
+
+	// Keep the status line visible, the first results matter most
+	size := p.height - 2
+	if size < 1 {
+		size = 1
+	}
+	if len(lines) > size {
+		lines = append(lines[:size-1], dimStyle.Render(fmt.Sprintf("and %d more", len(lines)-size+1)))
+	}
+	for len(lines) < size {
+		lines = append(lines, "")
+	}
+
+	for i, line := range lines {
+		lines[i] = lipgloss.NewStyle().MaxWidth(p.width).Render(line)
+	}
+
+	return strings.Join(append(append([]string{header}, lines...), "Press any key to continue"), "\n")
+}
+
+// Width returns the panel width
+func (p *OutdatedPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *OutdatedPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *OutdatedPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *OutdatedPanel) Title() string {
+	return p.title
+}
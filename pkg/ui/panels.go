@@ -2,10 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/process"
 	"github.com/VesperAkshay/lazynode/pkg/scripts"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -105,6 +107,32 @@ type Panel interface {
 	Title() string
 }
 
+// openOverlayMsg shows a panel in place of the panel grid
+type openOverlayMsg struct {
+	panel Panel
+}
+
+// closeOverlayMsg closes the overlay and returns to the panel grid
+type closeOverlayMsg struct{}
+
+// openOverlay returns a command that shows panel in place of the panel grid
+func openOverlay(panel Panel) tea.Cmd {
+	return func() tea.Msg {
+		return openOverlayMsg{panel}
+	}
+}
+
+// closeOverlay is a command that closes the overlay
+func closeOverlay() tea.Msg {
+	return closeOverlayMsg{}
+}
+
+// inputCapturer is implemented by panels that can take over all key presses,
+// e.g. while a text input, dialog or interactive terminal is active
+type inputCapturer interface {
+	CapturingInput() bool
+}
+
 // ScriptsPanel displays and manages npm scripts
 type ScriptsPanel struct {
 	title        string
@@ -116,6 +144,7 @@ type ScriptsPanel struct {
 	error        string
 	activeScript string
 	logsPanel    *LogsPanel
+	showStatus   bool // Show process details of the selected script
 }
 
 // NewScriptsPanel creates a new scripts panel
@@ -125,7 +154,7 @@ func NewScriptsPanel(scriptRunner *scripts.ScriptRunner) *ScriptsPanel {
 
 	// Add scripts to the list
 	for _, script := range scriptRunner.Scripts {
-		scriptItems = append(scriptItems, scriptItem{script})
+		scriptItems = append(scriptItems, scriptItem{script: script})
 	}
 
 	// Create compact custom list delegate
@@ -174,12 +203,73 @@ func NewScriptsPanel(scriptRunner *scripts.ScriptRunner) *ScriptsPanel {
 // scriptItem represents a script item in the list
 type scriptItem struct {
 	script scripts.Script
+	status process.Status
+	ran    bool // The script has been started at least once
+}
+
+func (i scriptItem) Title() string {
+	if !i.ran {
+		return i.script.Name
+	}
+	return fmt.Sprintf("%s %s", i.script.Name, statusBadge(i.status))
 }
 
-func (i scriptItem) Title() string       { return i.script.Name }
 func (i scriptItem) Description() string { return i.script.Command }
 func (i scriptItem) FilterValue() string { return i.script.Name }
 
+// statusBadge renders a short process state, e.g. "● 12s" or "✗ exit 1"
+func statusBadge(status process.Status) string {
+	duration := status.Duration().Round(time.Second)
+
+	switch status.State {
+	case process.StateRunning:
+		return HighlightStyle.Render(fmt.Sprintf("● %s", duration))
+	case process.StateStopping:
+		return lipgloss.NewStyle().Foreground(terminalBrightYellow).Render("◌ stopping")
+	case process.StateRestarting:
+		return lipgloss.NewStyle().Foreground(terminalBrightYellow).Render("↻ restarting")
+	}
+
+	if status.ExitCode == 0 {
+		return lipgloss.NewStyle().Foreground(terminalBrightBlue).Render(fmt.Sprintf("✓ exit 0 %s", duration))
+	}
+	return ErrorStyle.Render(fmt.Sprintf("✗ exit %d %s", status.ExitCode, duration))
+}
+
+// refreshStatuses updates the status badge of every script
+func (p *ScriptsPanel) refreshStatuses() {
+	for index, item := range p.scriptList.Items() {
+		i, ok := item.(scriptItem)
+		if !ok {
+			continue
+		}
+
+		status, ran := p.scriptRunner.ScriptStatus(i.script.Name)
+		if ran != i.ran || status != i.status {
+			i.status, i.ran = status, ran
+			p.scriptList.SetItem(index, i)
+		}
+	}
+}
+
+// refreshScripts lists the scripts of package.json again
+func (p *ScriptsPanel) refreshScripts() {
+	items := make([]list.Item, 0, len(p.scriptRunner.Scripts))
+	for _, script := range p.scriptRunner.Scripts {
+		items = append(items, scriptItem{script: script})
+	}
+	p.scriptList.SetItems(items)
+	p.refreshStatuses()
+}
+
+// selectedScript returns the name of the selected script
+func (p *ScriptsPanel) selectedScript() (string, bool) {
+	if i, ok := p.scriptList.SelectedItem().(scriptItem); ok {
+		return i.script.Name, true
+	}
+	return "", false
+}
+
 // SetLogsPanel sets the logs panel for script output
 func (p *ScriptsPanel) SetLogsPanel(logsPanel *LogsPanel) {
 	p.logsPanel = logsPanel
@@ -206,23 +296,29 @@ func (p *ScriptsPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 
 				// Log the script execution
 				if p.logsPanel != nil {
-					p.logsPanel.AddLog(fmt.Sprintf("Running script: npm run %s", i.script.Name))
+					p.logsPanel.AddLog(fmt.Sprintf("Running script: %s %s", p.scriptRunner.Client.Binary(),
+						strings.Join(p.scriptRunner.Client.RunArgs(i.script.Name), " ")))
 				}
 
 				// Run the script in the background
 				go func() {
-					cmd, err := p.scriptRunner.RunScript(i.script.Name)
+					proc, err := p.scriptRunner.RunScript(i.script.Name)
 					if err != nil {
 						p.error = fmt.Sprintf("Error running script: %v", err)
 						if p.logsPanel != nil {
 							p.logsPanel.AddLog(fmt.Sprintf("Error: %v", err))
 						}
+					} else if proc == nil {
+						if p.logsPanel != nil {
+							p.logsPanel.AddLog(fmt.Sprintf("Script already running: %s", i.script.Name))
+						}
 					} else {
-						// Capture output from the command
+						// Stream the output into the terminal panel
 						if p.logsPanel != nil {
+							p.logsPanel.AttachProcess(proc)
 							go func() {
 								// Wait for the command to finish
-								err := cmd.Wait()
+								err := proc.Err()
 								if err != nil {
 									p.logsPanel.AddLog(fmt.Sprintf("Script exited with error: %v", err))
 								} else {
@@ -235,12 +331,63 @@ func (p *ScriptsPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 				}()
 			}
 
+		case "s":
+			// Stop the selected script and its child processes
+			if name, ok := p.selectedScript(); ok {
+				if p.logsPanel != nil {
+					p.logsPanel.AddLog(fmt.Sprintf("Stopping script: %s", name))
+				}
+
+				go func() {
+					if err := p.scriptRunner.StopScript(name); err != nil {
+						p.error = fmt.Sprintf("Error stopping script: %v", err)
+					}
+					p.refreshStatuses()
+				}()
+			}
+
+		case "R":
+			// Restart the selected script
+			if name, ok := p.selectedScript(); ok {
+				p.activeScript = name
+				if p.logsPanel != nil {
+					p.logsPanel.AddLog(fmt.Sprintf("Restarting script: %s", name))
+				}
+
+				go func() {
+					proc, err := p.scriptRunner.RestartScript(name)
+					if err != nil {
+						p.error = fmt.Sprintf("Error restarting script: %v", err)
+					} else if proc != nil && p.logsPanel != nil {
+						p.logsPanel.AttachProcess(proc)
+					}
+					p.refreshStatuses()
+				}()
+			}
+
+		case "c":
+			// Toggle restart on crash for the selected script
+			if name, ok := p.selectedScript(); ok {
+				if status, ran := p.scriptRunner.ScriptStatus(name); ran {
+					p.scriptRunner.SetRestartOnCrash(name, !status.RestartOnCrash)
+					p.refreshStatuses()
+				}
+			}
+
+		case "v":
+			// Toggle the status details
+			p.showStatus = !p.showStatus
+
 		case "k", "j":
 			// Navigate the list but also handle updating the script info
 			if i, ok := p.scriptList.SelectedItem().(scriptItem); ok {
 				p.activeScript = i.script.Name
 			}
 		}
+
+	case nil:
+		// Ticks refresh the status badges
+		p.refreshStatuses()
 	}
 
 	// Update the list model
@@ -257,6 +404,15 @@ func (p *ScriptsPanel) View() string {
 		availableHeight = 1
 	}
 
+	// Make room for the status details
+	details := p.statusDetails()
+	if details != "" {
+		availableHeight -= strings.Count(details, "\n") + 1
+		if availableHeight < 1 {
+			availableHeight = 1
+		}
+	}
+
 	// Update the list dimensions for compact display
 	p.scriptList.SetSize(p.width, availableHeight)
 
@@ -267,7 +423,14 @@ func (p *ScriptsPanel) View() string {
 	} else if p.error != "" {
 		statusInfo = ErrorStyle.Render(p.error)
 	} else if _, ok := p.scriptList.SelectedItem().(scriptItem); ok {
-		statusInfo = "[↵]Run"
+		statusInfo = "[↵]Run [s]Stop [R]Restart [v]Status"
+	}
+
+	if details != "" {
+		return fmt.Sprintf("%s\n%s\n%s",
+			p.scriptList.View(),
+			details,
+			statusInfo)
 	}
 
 	// Ultra compact view with minimal status line
@@ -276,6 +439,37 @@ func (p *ScriptsPanel) View() string {
 		statusInfo)
 }
 
+// statusDetails describes the process of the selected script when details are shown
+func (p *ScriptsPanel) statusDetails() string {
+	if !p.showStatus {
+		return ""
+	}
+
+	name, ok := p.selectedScript()
+	if !ok {
+		return ""
+	}
+
+	status, ran := p.scriptRunner.ScriptStatus(name)
+	if !ran {
+		return fmt.Sprintf("%s: not started", name)
+	}
+
+	restart := "off"
+	if status.RestartOnCrash {
+		restart = "on"
+	}
+
+	details := fmt.Sprintf("%s: %s pid %d, %s, restarts %d\n[c]Restart on crash: %s",
+		name, status.State, status.Pid, status.Duration().Round(time.Millisecond), status.Restarts, restart)
+	if status.State == process.StateExited {
+		details = fmt.Sprintf("%s: exit code %d after %s, restarts %d\n[c]Restart on crash: %s",
+			name, status.ExitCode, status.Duration().Round(time.Millisecond), status.Restarts, restart)
+	}
+
+	return details
+}
+
 // Width returns the panel width
 func (p *ScriptsPanel) Width() int {
 	return p.width
@@ -334,6 +528,10 @@ func GetPackageActions() []PackageAction {
 		{Name: "Uninstall", Description: "Uninstall a package", Key: "d", Command: "uninstall"},
 		{Name: "Update", Description: "Update a package", Key: "u", Command: "update"},
 		{Name: "Check Outdated", Description: "Check for outdated packages", Key: "o", Command: "outdated"},
+		{Name: "Security Audit", Description: "List vulnerabilities and fix them", Key: "A", Command: "audit"},
+		{Name: "Peer Dependencies", Description: "Find unmet and conflicting peers", Key: "P", Command: "peers"},
+		{Name: "Diff", Description: "Diff package.json and the lockfile", Key: "D", Command: "diff"},
+		{Name: "Dependency Report", Description: "Version drift across workspaces and duplicates", Key: "V", Command: "report"},
 	}
 }
 
@@ -357,10 +555,74 @@ type PackagesPanel struct {
 	showActions    bool       // New field to show action selection mode
 	actionList     list.Model // New field for action list
 	actions        []PackageAction
-	showConfirm    bool          // Field for confirmation dialog
-	confirmMessage string        // Message for confirmation dialog
-	confirmAction  PackageAction // Action to perform if confirmed
-	confirmPackage string        // Package to act on if confirmed
+	showConfirm    bool              // Field for confirmation dialog
+	confirmMessage string            // Message for confirmation dialog
+	confirmAction  PackageAction     // Action to perform if confirmed
+	confirmPackage string            // Package to act on if confirmed
+	completer      *packageCompleter // Registry suggestions for the install input
+	filter         string            // Only list packages of this type, "" for all
+	sectionAction  string            // "move" or "add" while picking a section
+	sectionPackage string            // Package being moved to another section
+	addType        string            // Section the "add" and "move" actions use
+	removeOverride npm.Override      // Override to remove if confirmed
+}
+
+// sectionKeys picks a section in the move and add prompts
+var sectionKeys = []struct {
+	key     string
+	depType string
+}{
+	{"p", npm.TypeDependency},
+	{"d", npm.TypeDevDependency},
+	{"e", npm.TypePeerDependency},
+	{"o", npm.TypeOptionalDependency},
+	{"b", npm.TypeBundleDependency},
+	{"r", npm.TypeOverride},
+}
+
+// filterTypes is the order the filter key cycles through, "" showing everything
+var filterTypes = []string{"", npm.TypeDependency, npm.TypeDevDependency, npm.TypePeerDependency, npm.TypeOptionalDependency, npm.TypeBundleDependency, npm.TypeOverride}
+
+// typeLabel returns the short name of a dependency type used in badges
+func typeLabel(depType string) string {
+	switch depType {
+	case npm.TypeDependency:
+		return "prod"
+	case npm.TypeDevDependency:
+		return "dev"
+	case npm.TypePeerDependency:
+		return "peer"
+	case npm.TypeOptionalDependency:
+		return "optional"
+	case npm.TypeBundleDependency:
+		return "bundled"
+	case npm.TypeOverride:
+		return "override"
+	}
+	return depType
+}
+
+// typeBadges returns the badges of every section a package is declared in.
+// Plain dependencies have none.
+func typeBadges(pkg npm.Package) string {
+	var badges []string
+	for _, depType := range pkg.Sections {
+		switch {
+		case depType == npm.TypeDependency:
+			continue
+		case depType == npm.TypePeerDependency && pkg.OptionalPeer:
+			badges = append(badges, "[peer?]")
+		default:
+			badges = append(badges, "["+typeLabel(depType)+"]")
+		}
+	}
+	if pkg.Bundled {
+		badges = append(badges, "[bundled]")
+	}
+	if pkg.Type == npm.TypeOverride {
+		badges = append(badges, "[override]")
+	}
+	return strings.Join(badges, " ")
 }
 
 // NewPackagesPanel creates a new packages panel
@@ -421,6 +683,7 @@ func NewPackagesPanel(packageManager *npm.PackageManager) *PackagesPanel {
 		lastUpdate:     time.Now(),
 		actionList:     actionList,
 		actions:        actions,
+		completer:      newPackageCompleter(packageManager.Registry),
 	}
 
 	// Immediately load packages when panel is created
@@ -434,9 +697,7 @@ func NewPackagesPanel(packageManager *npm.PackageManager) *PackagesPanel {
 		}
 
 		// Add all packages to the list
-		for _, pkg := range packageManager.Packages {
-			panel.packageList.InsertItem(len(panel.packageList.Items()), packageItem{pkg})
-		}
+		panel.refreshPackageList()
 
 		// Set loading to false after initial load
 		panel.loading = false
@@ -447,7 +708,8 @@ func NewPackagesPanel(packageManager *npm.PackageManager) *PackagesPanel {
 
 // packageItem represents a package item in the list
 type packageItem struct {
-	pkg npm.Package
+	pkg      npm.Package
+	override npm.Override // Set for overrides, whose pkg only carries the name and range
 }
 
 func (i packageItem) Title() string {
@@ -462,8 +724,8 @@ func (i packageItem) Title() string {
 }
 
 func (i packageItem) Description() string {
-	if i.pkg.Type == "devDependency" {
-		return fmt.Sprintf("[dev] %s", i.pkg.Description)
+	if badges := typeBadges(i.pkg); badges != "" {
+		return fmt.Sprintf("%s %s", badges, i.pkg.Description)
 	}
 	return i.pkg.Description
 }
@@ -494,6 +756,9 @@ func (p *PackagesPanel) executeAction(action PackageAction, packageName string)
 	p.loading = true
 	p.error = ""
 
+	// Read the picked section now, the prompt may be reused before we finish
+	addType, removeOverride := p.addType, p.removeOverride
+
 	go func() {
 		var err error
 		defer func() {
@@ -519,12 +784,30 @@ func (p *PackagesPanel) executeAction(action PackageAction, packageName string)
 				p.statusMessage = fmt.Sprintf("✅ Installed %s (dev)", packageName)
 				p.statusTime = time.Now()
 			}
+		case "add":
+			err = p.packageManager.AddToSection(packageName, addType)
+			if err == nil {
+				p.statusMessage = fmt.Sprintf("✅ Added %s (%s)", packageName, typeLabel(addType))
+				p.statusTime = time.Now()
+			}
+		case "move":
+			err = p.packageManager.MoveToSection(packageName, addType)
+			if err == nil {
+				p.statusMessage = fmt.Sprintf("✅ Moved %s to %s", packageName, npm.SectionField(addType))
+				p.statusTime = time.Now()
+			}
 		case "uninstall":
 			err = p.packageManager.UninstallPackage(packageName)
 			if err == nil {
 				p.statusMessage = fmt.Sprintf("✅ Uninstalled %s", packageName)
 				p.statusTime = time.Now()
 			}
+		case "remove-override":
+			err = p.packageManager.RemoveOverride(removeOverride)
+			if err == nil {
+				p.statusMessage = fmt.Sprintf("✅ Removed override of %s", packageName)
+				p.statusTime = time.Now()
+			}
 		case "update":
 			err = p.packageManager.UpdatePackage(packageName)
 			if err == nil {
@@ -546,6 +829,13 @@ func (p *PackagesPanel) executeAction(action PackageAction, packageName string)
 
 		if err != nil {
 			p.error = fmt.Sprintf("Error: %v", err)
+
+			// Point at the structured report instead of the ERESOLVE wall of text
+			installing := action.Command == "install" || action.Command == "install-dev" || action.Command == "add" || action.Command == "move"
+			if issues := p.packageManager.LastInstallIssues; installing && len(issues) > 0 {
+				p.error = fmt.Sprintf("Error: %s failed on peer dependencies (%s %s) - press P for details",
+					action.Name, issues[0].Kind, issues[0].Name)
+			}
 		}
 	}()
 }
@@ -596,14 +886,82 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 				p.confirmPackage = ""
 			}
 
+		case p.sectionAction != "":
+			// Handle the section prompt
+			if msg.String() == "esc" {
+				p.sectionAction = ""
+				return p, nil
+			}
+
+			for _, section := range sectionKeys {
+				if msg.String() != section.key || (p.sectionAction == "move" && section.depType == npm.TypeOverride) {
+					continue
+				}
+				p.addType = section.depType
+
+				if p.sectionAction == "move" {
+					// Move the package right away
+					p.executeAction(PackageAction{Name: "Move", Command: "move"}, p.sectionPackage)
+				} else {
+					// Ask for the package to add
+					p.showInput = true
+					p.inputMode = "add"
+					p.input.Placeholder = fmt.Sprintf("Package to add to %s", npm.SectionField(section.depType))
+					if section.depType == npm.TypeOverride {
+						p.input.Placeholder = "name@version to force everywhere"
+					}
+					p.input.Focus()
+				}
+				p.sectionAction = ""
+				break
+			}
+			return p, nil
+
 		case p.showInput:
 			// Handle input mode
 			switch msg.String() {
+			case "up", "ctrl+p":
+				// Move through the suggestions
+				if p.completing() {
+					p.completer.Move(-1)
+					return p, nil
+				}
+
+			case "down", "ctrl+n":
+				if p.completing() {
+					p.completer.Move(1)
+					return p, nil
+				}
+
+			case "tab":
+				// Take the highlighted suggestion
+				if p.completing() {
+					if value, ok := p.completer.Accept(); ok {
+						p.input.SetValue(value)
+						p.input.CursorEnd()
+						p.completer.Update(value)
+					}
+					return p, nil
+				}
+
 			case "enter":
+				// Take the highlighted suggestion instead of submitting
+				if p.completing() {
+					if value, ok := p.completer.Accept(); ok {
+						p.input.SetValue(value)
+						p.input.CursorEnd()
+						p.completer.Update(value)
+						return p, nil
+					}
+				}
+
 				// Process the input
 				value := p.input.Value()
+				p.completer.Reset()
 
-				if value != "" {
+				if value != "" && p.inputMode == "add" {
+					p.executeAction(PackageAction{Name: "Add", Command: "add"}, value)
+				} else if value != "" {
 					// Find the action by command
 					for _, action := range p.actions {
 						if action.Command == p.inputMode {
@@ -629,10 +987,16 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 				// Cancel the input
 				p.showInput = false
 				p.input.SetValue("")
+				p.completer.Reset()
 			}
 
 			// Update the input
 			p.input, cmd = p.input.Update(msg)
+
+			// Search the registry as we type
+			if p.completing() {
+				p.completer.Update(p.input.Value())
+			}
 			if cmd != nil {
 				return p, cmd
 			}
@@ -654,14 +1018,26 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 							p.confirmAction = action
 							p.confirmPackage = pkgItem.pkg.Name
 						}
+					} else if action.Command == "audit" {
+						// Open the audit panel
+						p.showActions = false
+						return p, openOverlay(NewAuditPanel(p.packageManager))
+					} else if action.Command == "peers" {
+						// Open the peer dependency check
+						p.showActions = false
+						return p, openOverlay(NewPeersPanel(p.packageManager))
+					} else if action.Command == "diff" {
+						// Open the diff of package.json and the lockfile
+						p.showActions = false
+						return p, openOverlay(NewDiffPanel(p.packageManager))
+					} else if action.Command == "report" {
+						// Open the version drift and duplicates report
+						p.showActions = false
+						return p, openOverlay(NewDependencyReportPanel(p.packageManager))
 					} else if action.Command == "outdated" {
-						// Directly run outdated check
-						for _, a := range p.actions {
-							if a.Command == "outdated" {
-								p.executeAction(a, "")
-								break
-							}
-						}
+						// Open the outdated view
+						p.showActions = false
+						return p, openOverlay(NewOutdatedPanel(p.packageManager))
 					} else {
 						// Show input for other actions
 						p.showInput = true
@@ -707,7 +1083,40 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 				p.input.Placeholder = "Package name to install as dev dependency"
 				p.input.Focus()
 
+			case "f":
+				// Only list the next type of dependency
+				for i, depType := range filterTypes {
+					if depType == p.filter {
+						p.filter = filterTypes[(i+1)%len(filterTypes)]
+						break
+					}
+				}
+				p.refreshPackageList()
+				p.packageList.Select(0)
+				return p, nil
+
+			case "s":
+				// Add a package to a section picked next
+				p.sectionAction = "add"
+
+			case "m":
+				// Move the selected package to a section picked next
+				if i, ok := p.packageList.SelectedItem().(packageItem); ok && i.pkg.Type != npm.TypeOverride {
+					p.sectionAction = "move"
+					p.sectionPackage = i.pkg.Name
+				}
+
 			case "d":
+				// Remove an override (with confirmation)
+				if i, ok := p.packageList.SelectedItem().(packageItem); ok && i.pkg.Type == npm.TypeOverride {
+					p.showConfirm = true
+					p.confirmMessage = fmt.Sprintf("Remove the override of '%s' from %s?", i.override.Name, i.override.Field)
+					p.confirmAction = PackageAction{Name: "Remove Override", Command: "remove-override"}
+					p.confirmPackage = i.override.Name
+					p.removeOverride = i.override
+					return p, nil
+				}
+
 				// Uninstall a package (with confirmation)
 				if i, ok := p.packageList.SelectedItem().(packageItem); ok {
 					p.showConfirm = true
@@ -725,13 +1134,8 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 				}
 
 			case "o":
-				// Check for outdated packages
-				for _, action := range p.actions {
-					if action.Command == "outdated" {
-						p.executeAction(action, "")
-						break
-					}
-				}
+				// Show outdated packages
+				return p, openOverlay(NewOutdatedPanel(p.packageManager))
 
 			case "u":
 				// Update a package
@@ -743,6 +1147,38 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 					p.input.Focus()
 				}
 
+			case "t":
+				// Show the dependency tree
+				return p, openOverlay(NewDependencyTreePanel(p.packageManager))
+
+			case "enter":
+				// Inspect the selected package
+				if i, ok := p.packageList.SelectedItem().(packageItem); ok && i.pkg.Type != npm.TypeOverride {
+					return p, openOverlay(NewPackageDetailPanel(p.packageManager, i.pkg.Name))
+				}
+
+			case "A":
+				// Audit the dependencies for vulnerabilities
+				return p, openOverlay(NewAuditPanel(p.packageManager))
+
+			case "P":
+				// Check the peer dependencies, or explain the last failed install
+				return p, openOverlay(NewPeersPanel(p.packageManager))
+
+			case "D":
+				// Show how package.json and the lockfile changed
+				return p, openOverlay(NewDiffPanel(p.packageManager))
+
+			case "V":
+				// Report version drift across workspaces and lockfile duplicates
+				return p, openOverlay(NewDependencyReportPanel(p.packageManager))
+
+			case "w":
+				// Explain why the selected package is installed
+				if i, ok := p.packageList.SelectedItem().(packageItem); ok {
+					return p, openOverlay(NewWhyPanel(p.packageManager.Graph, i.pkg.Name))
+				}
+
 			case "/":
 				// Search for a package
 				p.showInput = true
@@ -759,6 +1195,16 @@ func (p *PackagesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 	return p, cmd
 }
 
+// completing reports whether the input is for a package to install
+func (p *PackagesPanel) completing() bool {
+	return p.showInput && (p.inputMode == "install" || p.inputMode == "install-dev" || p.inputMode == "add")
+}
+
+// CapturingInput reports whether an input or dialog is active
+func (p *PackagesPanel) CapturingInput() bool {
+	return p.showInput || p.showActions || p.showConfirm || p.sectionAction != ""
+}
+
 // refreshPackageList updates the package list with the latest data
 func (p *PackagesPanel) refreshPackageList() {
 	// Remember the currently selected index
@@ -770,16 +1216,44 @@ func (p *PackagesPanel) refreshPackageList() {
 		selectedPkg = selected.pkg.Name
 	}
 
-	// Clear the list
-	p.packageList.SetItems([]list.Item{})
+	// Add the packages of the filtered type, in order
+	names := make([]string, 0, len(p.packageManager.Packages))
+	for name := range p.packageManager.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []list.Item
+	for _, name := range names {
+		pkg := p.packageManager.Packages[name]
+		if p.filter == "" || pkg.In(p.filter) {
+			items = append(items, packageItem{pkg: pkg})
+		}
+	}
 
-	// Add packages to the list with a micro-delay to allow UI updates
-	for _, pkg := range p.packageManager.Packages {
-		p.packageList.InsertItem(len(p.packageList.Items()), packageItem{pkg})
+	// Followed by the overrides
+	if p.filter == "" || p.filter == npm.TypeOverride {
+		for _, override := range p.packageManager.Overrides {
+			items = append(items, packageItem{
+				pkg: npm.Package{
+					Name:        override.Name,
+					Version:     override.Range,
+					Range:       override.Range,
+					Type:        npm.TypeOverride,
+					Description: "in " + override.Field,
+				},
+				override: override,
+			})
+		}
+	}
+	p.packageList.SetItems(items)
+
+	p.packageList.Title = "Packages"
+	if p.filter != "" {
+		p.packageList.Title = fmt.Sprintf("Packages (%s)", typeLabel(p.filter))
 	}
 
 	// Try to restore selection
-	items := p.packageList.Items()
 	if len(items) > 0 {
 		// First try to find the same package by name
 		if selectedPkg != "" {
@@ -837,6 +1311,25 @@ func (p *PackagesPanel) View() string {
 			"[y]Yes [n]No")
 	}
 
+	// Show the section prompt
+	if p.sectionAction != "" {
+		var choices []string
+		for _, section := range sectionKeys {
+			if p.sectionAction == "move" && section.depType == npm.TypeOverride {
+				continue
+			}
+			choices = append(choices, fmt.Sprintf("[%s]%s", section.key, typeLabel(section.depType)))
+		}
+
+		prompt := "Add a package as:"
+		if p.sectionAction == "move" {
+			prompt = fmt.Sprintf("Move '%s' to:", p.sectionPackage)
+		}
+		return fmt.Sprintf("%s\n%s",
+			prompt,
+			lipgloss.NewStyle().Width(p.width).Render(strings.Join(choices, " ")+" [esc]Cancel"))
+	}
+
 	// Show action selection mode
 	if p.showActions {
 		return fmt.Sprintf("%s\n%s",
@@ -844,8 +1337,15 @@ func (p *PackagesPanel) View() string {
 			"[↵]Select [esc]Cancel")
 	}
 
-	// Show input mode
+	// Show input mode, with the suggestions in place of the list while installing
 	if p.showInput {
+		if p.completing() {
+			if dropdown := p.completer.View(p.width, availableHeight); dropdown != "" {
+				return fmt.Sprintf("%s\n%s",
+					p.input.View(),
+					dropdown)
+			}
+		}
 		return fmt.Sprintf("%s\n%s",
 			p.packageList.View(),
 			p.input.View())
@@ -854,10 +1354,13 @@ func (p *PackagesPanel) View() string {
 	// Get the selected package details for status line
 	var statusInfo string
 	if i, ok := p.packageList.SelectedItem().(packageItem); ok {
-		if i.pkg.Type == "devDependency" {
-			statusInfo = "[i]Install [d]Del [dev]"
+		if i.pkg.Type == npm.TypeOverride {
+			statusInfo = "[d]Remove override [f]Filter"
 		} else {
-			statusInfo = "[i]Install [d]Del"
+			statusInfo = "[i]Install [d]Del [m]Move [f]Filter"
+			if badges := typeBadges(i.pkg); badges != "" {
+				statusInfo += " " + badges
+			}
 		}
 	}
 
@@ -910,7 +1413,7 @@ func (p *PackagesPanel) Title() string {
 	return p.title
 }
 
-// LogsPanel displays command logs
+// LogsPanel displays command logs and the live output of the attached process
 type LogsPanel struct {
 	title         string
 	width         int
@@ -921,6 +1424,10 @@ type LogsPanel struct {
 	spinnerFrames []string
 	lastUpdate    time.Time
 	maxLogHistory int
+	supervisor    *process.Supervisor // Processes that get an output tab
+	tab           string              // Selected tab: logsTab, allTab or a process name
+	lastTab       string              // Last output tab, restored by "o"
+	interactive   bool                // Forward key presses to the process terminal
 }
 
 // NewLogsPanel creates a new logs panel
@@ -943,6 +1450,8 @@ func NewLogsPanel() *LogsPanel {
 		spinnerFrames: spinnerFrames,
 		lastUpdate:    time.Now(),
 		maxLogHistory: 500, // Increase log history for more terminal-like scrolling
+		tab:           logsTab,
+		lastTab:       allTab,
 	}
 }
 
@@ -961,11 +1470,123 @@ func (p *LogsPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 		p.lastUpdate = time.Now()
 	}
 
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		// Forward key presses to the process until ctrl+] detaches
+		if p.interactive {
+			proc := p.currentProcess()
+			if msg.String() == "ctrl+]" || proc == nil {
+				p.interactive = false
+			} else if err := proc.Write(keyToBytes(msg)); err != nil {
+				p.interactive = false
+			}
+			return p, nil
+		}
+
+		switch msg.String() {
+		case "[":
+			// Previous tab
+			p.switchTab(-1)
+			return p, nil
+		case "]":
+			// Next tab
+			p.switchTab(1)
+			return p, nil
+		case "o":
+			// Toggle between LazyNode's logs and the last output tab
+			if p.tab == logsTab {
+				p.selectTab(p.lastTab)
+			} else {
+				p.selectTab(logsTab)
+			}
+			return p, nil
+		case "i":
+			// Focus the process so prompts can be answered
+			if proc := p.currentProcess(); proc != nil && proc.Interactive() {
+				p.interactive = true
+			}
+			return p, nil
+		case "x":
+			// Close the tab of a finished process
+			if p.currentProcess() != nil && p.supervisor.Remove(p.tab) == nil {
+				p.selectTab(allTab)
+			}
+			return p, nil
+		}
+	}
+
 	p.viewport, cmd = p.viewport.Update(msg)
 
 	return p, cmd
 }
 
+// SetSupervisor gives every process of the supervisor its own output tab
+func (p *LogsPanel) SetSupervisor(supervisor *process.Supervisor) {
+	p.supervisor = supervisor
+}
+
+// AttachProcess switches to the output tab of proc
+func (p *LogsPanel) AttachProcess(proc *process.Process) {
+	p.selectTab(proc.Name)
+}
+
+// selectTab switches to the named tab, falling back to the logs if it is gone
+func (p *LogsPanel) selectTab(tab string) {
+	p.tab = logsTab
+	for _, t := range p.tabs() {
+		if t == tab {
+			p.tab = tab
+			break
+		}
+	}
+
+	if p.tab != logsTab {
+		p.lastTab = p.tab
+	}
+	p.interactive = false
+	p.viewport.GotoBottom()
+	p.resizeProcess()
+}
+
+// CapturingInput reports whether key presses are forwarded to the process
+func (p *LogsPanel) CapturingInput() bool {
+	return p.interactive
+}
+
+// resizeProcess fits every process terminal to the viewport
+func (p *LogsPanel) resizeProcess() {
+	if p.supervisor == nil {
+		return
+	}
+
+	for _, name := range p.supervisor.Names() {
+		if proc, ok := p.supervisor.Process(name); ok {
+			proc.Resize(p.viewport.Width, p.viewport.Height)
+		}
+	}
+}
+
+// outputContent renders the output of proc, stderr lines in red
+func (p *LogsPanel) outputContent(proc *process.Process) string {
+	stdoutStyle := lipgloss.NewStyle().Foreground(terminalBrightWhite)
+	stderrStyle := lipgloss.NewStyle().Foreground(terminalBrightRed)
+
+	lines := proc.Output.Lines()
+	rendered := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if proc.Interactive() {
+			// Terminal output carries its own ANSI styling
+			rendered = append(rendered, line.Text)
+		} else if line.Stream == process.Stderr {
+			rendered = append(rendered, stderrStyle.Render(line.Text))
+		} else {
+			rendered = append(rendered, stdoutStyle.Render(line.Text))
+		}
+	}
+
+	return strings.Join(rendered, "\n")
+}
+
 // AddLog adds a log message
 func (p *LogsPanel) AddLog(message string) {
 	timestamp := time.Now().Format("15:04:05")
@@ -1037,7 +1658,7 @@ func (p *LogsPanel) View() string {
 
 	// Make sure viewport size is properly set
 	viewportWidth := p.width - 2   // Account for borders
-	viewportHeight := p.height - 1 // Minimal height for logs
+	viewportHeight := p.height - 2 // Leave room for the tabs and the prompt
 
 	if viewportWidth < 5 {
 		viewportWidth = 5
@@ -1050,7 +1671,18 @@ func (p *LogsPanel) View() string {
 	p.viewport.Width = viewportWidth
 	p.viewport.Height = viewportHeight
 
+	// The selected process may have finished and been closed
+	if p.tab != logsTab && p.tab != allTab && p.currentProcess() == nil {
+		p.selectTab(logsTab)
+	}
+
 	// Create a terminal prompt footer for the logs panel
+	promptText := "$ "
+	if p.interactive {
+		promptText = fmt.Sprintf("⌨ %s (ctrl+] to detach) ", p.tab)
+	} else if len(p.tabs()) > 1 {
+		promptText = "$ [ ] switch tabs "
+	}
 	prompt := lipgloss.NewStyle().
 		Background(terminalBlack).
 		Foreground(terminalBrightGreen).
@@ -1059,18 +1691,32 @@ func (p *LogsPanel) View() string {
 			Background(terminalBlack).
 			Foreground(terminalBrightCyan).
 			Bold(true).
-			Render("$ ")
+			Render(promptText)
 
-	// Style the logs to look like terminal output
-	content := strings.Join(p.logs, "\n")
-	p.viewport.SetContent(content)
+	if p.tab != logsTab {
+		// Tail the process output, following new lines unless scrolled up
+		following := p.viewport.AtBottom()
+		if proc := p.currentProcess(); proc != nil {
+			p.viewport.SetContent(p.outputContent(proc))
+		} else {
+			p.viewport.SetContent(p.allContent())
+		}
+		if following {
+			p.viewport.GotoBottom()
+		}
+	} else {
+		// Style the logs to look like terminal output
+		content := strings.Join(p.logs, "\n")
+		p.viewport.SetContent(content)
 
-	// Auto-scroll to top (most recent logs)
-	p.viewport.GotoTop()
+		// Auto-scroll to top (most recent logs)
+		p.viewport.GotoTop()
+	}
 
-	// Combine viewport with prompt for a terminal-like appearance
+	// Combine tabs, viewport and prompt for a terminal-like appearance
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
+		p.tabBar(),
 		p.viewport.View(),
 		prompt,
 	)
@@ -1101,7 +1747,7 @@ func (p *LogsPanel) SetSize(width, height int) {
 
 	// Update viewport dimensions with constraints
 	viewportWidth := width - 2   // Account for borders
-	viewportHeight := height - 1 // Minimal space for logs
+	viewportHeight := height - 2 // Leave room for the tabs and the prompt
 
 	if viewportWidth < 5 {
 		viewportWidth = 5
@@ -1113,6 +1759,9 @@ func (p *LogsPanel) SetSize(width, height int) {
 
 	p.viewport.Width = viewportWidth
 	p.viewport.Height = viewportHeight
+
+	// Keep the process terminal the same size as the panel
+	p.resizeProcess()
 }
 
 // Title returns the panel title
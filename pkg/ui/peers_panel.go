@@ -0,0 +1,412 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/peers"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// peerKindColors colors peer problems by how serious they are
+var peerKindColors = map[peers.Kind]lipgloss.Color{
+	peers.Conflict:        terminalBrightRed,
+	peers.Missing:         terminalBrightRed,
+	peers.Unmet:           terminalBrightYellow,
+	peers.MissingOptional: terminalBrightBlack,
+}
+
+// peerKindStyle returns the style of a peer problem label
+func peerKindStyle(kind peers.Kind) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(peerKindColors[kind]).Bold(kind == peers.Conflict)
+}
+
+// PeersPanel lists the peer dependency problems of the project, either
+// found in the installed tree or reported by the last failed install
+type PeersPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	issues         []peers.Issue
+	installIssues  []peers.Issue // Parsed from the output of the last install
+	fromInstall    bool          // Showing installIssues instead of the tree check
+	cursor         int
+	offset         int
+	loading        bool
+	busy           string // Description of the running install, if any
+	statusMessage  string
+	statusTime     time.Time
+	error          string
+}
+
+// NewPeersPanel checks the peer dependencies of the project. When the last
+// install failed on peers its report is shown first.
+func NewPeersPanel(packageManager *npm.PackageManager) *PeersPanel {
+	p := &PeersPanel{
+		title:          "Peer Dependencies",
+		packageManager: packageManager,
+	}
+
+	if len(packageManager.LastInstallIssues) > 0 {
+		p.installIssues = append([]peers.Issue(nil), packageManager.LastInstallIssues...)
+		p.fromInstall = true
+		p.suggestInstallIssues()
+		return p
+	}
+
+	p.runCheck()
+	return p
+}
+
+// runCheck checks the installed tree in the background
+func (p *PeersPanel) runCheck() {
+	p.loading = true
+	p.error = ""
+
+	go func() {
+		issues, err := p.packageManager.CheckPeers(context.Background())
+		if err != nil {
+			p.error = fmt.Sprintf("Error checking peers: %v", err)
+		} else {
+			p.issues = append([]peers.Issue{}, issues...) // Not nil once checked
+		}
+		p.loading = false
+	}()
+}
+
+// suggestInstallIssues looks up fixes for the install report in the background
+func (p *PeersPanel) suggestInstallIssues() {
+	p.loading = true
+
+	go func() {
+		issues := append([]peers.Issue(nil), p.installIssues...)
+		p.packageManager.SuggestPeers(context.Background(), issues)
+		p.installIssues = issues
+		p.loading = false
+	}()
+}
+
+// shown returns the issues currently listed
+func (p *PeersPanel) shown() []peers.Issue {
+	if p.fromInstall {
+		return p.installIssues
+	}
+	return p.issues
+}
+
+// selected returns the issue under the cursor
+func (p *PeersPanel) selected() (peers.Issue, bool) {
+	issues := p.shown()
+	if p.cursor >= len(issues) {
+		return peers.Issue{}, false
+	}
+	return issues[p.cursor], true
+}
+
+// apply installs a suggested version in the background and checks again
+func (p *PeersPanel) apply(issue peers.Issue, suggestion peers.Suggestion) {
+	if p.busy != "" || p.loading {
+		return
+	}
+
+	// Keep the declared section and range style of direct dependencies
+	spec, depType := suggestion.Package+"@^"+suggestion.Version, npm.TypeDependency
+	if pkg, ok := p.packageManager.Packages[suggestion.Package]; ok {
+		rng, ok := npm.RangeFor(pkg.Range, suggestion.Version)
+		if !ok {
+			p.error = fmt.Sprintf("%s is declared as %q, change it by hand", suggestion.Package, pkg.Range)
+			return
+		}
+		spec = suggestion.Package + "@" + rng
+		depType = pkg.Type
+	} else if suggestion.Package == issue.Name {
+		// A missing peer goes next to the direct dependency needing it
+		for _, requirement := range issue.Requirements {
+			name, _ := peers.SplitID(requirement.Dependent)
+			if pkg, ok := p.packageManager.Packages[name]; ok && pkg.Type == npm.TypeDevDependency {
+				depType = npm.TypeDevDependency
+			}
+		}
+	} else {
+		p.error = fmt.Sprintf("%s is not a direct dependency, update the package depending on it", suggestion.Package)
+		return
+	}
+
+	p.busy = fmt.Sprintf("Installed %s (%s)", spec, typeLabel(depType))
+	go func() {
+		err := p.packageManager.AddToSection(spec, depType)
+		description := p.busy
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+			if len(p.packageManager.LastInstallIssues) > 0 {
+				// Show what the install complained about
+				p.installIssues = append([]peers.Issue(nil), p.packageManager.LastInstallIssues...)
+				p.fromInstall = true
+				p.cursor = 0
+				p.suggestInstallIssues()
+			}
+			return
+		}
+
+		p.statusMessage = fmt.Sprintf("✅ %s", description)
+		p.statusTime = time.Now()
+		p.fromInstall = false
+		p.runCheck()
+	}()
+}
+
+// Init initializes the panel
+func (p *PeersPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *PeersPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	count := len(p.shown())
+	p.error = ""
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = count - 1
+
+	case "tab":
+		// Switch between the install report and the tree check
+		if len(p.installIssues) > 0 {
+			p.fromInstall = !p.fromInstall
+			p.cursor = 0
+			if !p.fromInstall && p.issues == nil && !p.loading {
+				p.runCheck()
+			}
+		}
+
+	case "r":
+		// Check the tree again
+		if !p.loading && p.busy == "" {
+			p.fromInstall = false
+			p.cursor = 0
+			p.runCheck()
+		}
+
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		// Install a suggested version
+		if issue, ok := p.selected(); ok {
+			index := int(keyMsg.String()[0] - '1')
+			if index < len(issue.Suggestions) {
+				p.apply(issue, issue.Suggestions[index])
+			}
+		}
+	}
+
+	if p.cursor >= len(p.shown()) {
+		p.cursor = len(p.shown()) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of issue lines that fit
+func (p *PeersPanel) pageSize() int {
+	// Header, status line and the details below the list
+	size := p.height - 2 - len(p.detailLines())
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// issueLine renders one issue of the list
+func (p *PeersPanel) issueLine(issue peers.Issue) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	line := peerKindStyle(issue.Kind).Render(fmt.Sprintf("%-16s", issue.Kind)) +
+		" " + HighlightStyle.Render(issue.Name)
+	if issue.Installed != "" {
+		line += " " + issue.Installed
+	}
+
+	unsatisfied := issue.Unsatisfied()
+	wanted := make([]string, 0, len(unsatisfied))
+	for _, requirement := range unsatisfied {
+		name, _ := peers.SplitID(requirement.Dependent)
+		wanted = append(wanted, fmt.Sprintf("%s wants %s", name, requirement.Range))
+	}
+	line += " " + dimStyle.Render(strings.Join(wanted, ", "))
+
+	if len(issue.Suggestions) > 0 {
+		line += lipgloss.NewStyle().Foreground(terminalBrightCyan).Render(" fix available")
+	}
+	return line
+}
+
+// detailLines describes the selected issue: who asks for what, and the fixes
+func (p *PeersPanel) detailLines() []string {
+	issue, ok := p.selected()
+	if !ok {
+		return nil
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	okStyle := lipgloss.NewStyle().Foreground(terminalBrightGreen)
+
+	installed := ErrorStyle.Render("not installed")
+	if issue.Installed != "" {
+		installed = issue.Installed
+	}
+	lines := []string{
+		dimStyle.Render(strings.Repeat("─", p.width)),
+		fmt.Sprintf("%s %s, installed: %s", peerKindStyle(issue.Kind).Render(strings.ToUpper(issue.Kind.String())), issue.Name, installed),
+	}
+
+	// Show a few of the requirements, popular peers have many
+	const maxRequirements = 5
+	for i, requirement := range issue.Requirements {
+		if i == maxRequirements {
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("  and %d more dependents", len(issue.Requirements)-maxRequirements)))
+			break
+		}
+
+		mark := ErrorStyle.Render("✕")
+		if requirement.Satisfied {
+			mark = okStyle.Render("✓")
+		}
+		kind := "needs"
+		if requirement.Peer {
+			kind = "peer"
+		}
+		line := fmt.Sprintf("  %s %s %s %s", mark, requirement.Dependent, dimStyle.Render(kind), requirement.Range)
+		if requirement.Optional {
+			line += dimStyle.Render(" (optional)")
+		}
+		lines = append(lines, line)
+	}
+
+	for i, suggestion := range issue.Suggestions {
+		if i == 9 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("[%d] install %s %s",
+			i+1,
+			HighlightStyle.Render(suggestion.Package+"@"+suggestion.Version),
+			dimStyle.Render(suggestion.Reason)))
+	}
+	if len(issue.Suggestions) == 0 && issue.Kind == peers.Conflict {
+		lines = append(lines, dimStyle.Render("No version satisfies every dependent, update or replace one of them"))
+	}
+
+	return lines
+}
+
+// View renders the panel
+func (p *PeersPanel) View() string {
+	issues := p.shown()
+
+	header := "No peer dependency problems"
+	if p.fromInstall {
+		header = fmt.Sprintf("Reported by the last install: %d problems", len(issues))
+	} else if p.issues == nil {
+		if p.error != "" {
+			return ErrorStyle.Render(p.error)
+		}
+		header = "Checking peer dependencies..."
+	} else if len(issues) > 0 {
+		header = fmt.Sprintf("%d peer dependency problems in the installed tree", len(issues))
+	}
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for i := p.offset; i < len(issues) && i < p.offset+size; i++ {
+		line := p.issueLine(issues[i])
+		if i == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the details and status line stay at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	for _, line := range p.detailLines() {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the progress, the last result or the key hints
+func (p *PeersPanel) statusLine() string {
+	status := "[1-9]Install suggestion [r]Check again"
+	if len(p.installIssues) > 0 {
+		status += " [tab]Install report/tree"
+	}
+
+	switch {
+	case p.busy != "":
+		status = "Installing... " + status
+	case p.loading:
+		status = "Checking... " + status
+	case p.error != "":
+		status = ErrorStyle.Render(p.error)
+	case p.statusMessage != "" && time.Since(p.statusTime) < 5*time.Second:
+		status = HighlightStyle.Render(p.statusMessage)
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *PeersPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *PeersPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *PeersPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *PeersPanel) Title() string {
+	return p.title
+}
@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/VesperAkshay/lazynode/pkg/packagejson"
 	"github.com/VesperAkshay/lazynode/pkg/project"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,7 +17,7 @@ type ProjectPanel struct {
 	width     int
 	height    int
 	project   *project.Project
-	mode      string // "view", "edit"
+	mode      string // "view", "edit", "conflict"
 	editKey   string
 	editValue string
 	input     textinput.Model
@@ -45,11 +48,20 @@ func (p *ProjectPanel) Init() tea.Cmd {
 func (p *ProjectPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// Pick up what LazyNode wrote to package.json meanwhile, e.g. an install,
+	// so that it doesn't look like an edit made elsewhere
+	if p.mode == "view" && !p.loading {
+		if err := p.project.Refresh(); err != nil {
+			p.error = fmt.Sprintf("Error reloading package.json: %v", err)
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch p.mode {
 		case "view":
 			// Handle view mode keys
+			p.error = ""
 			switch msg.String() {
 			case "e":
 				// Edit name
@@ -115,11 +127,7 @@ func (p *ProjectPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 
 				// Save changes
 				go func() {
-					if err := p.project.SavePackageJSON(); err != nil {
-						p.error = fmt.Sprintf("Error saving package.json: %v", err)
-					}
-					p.mode = "view"
-					p.loading = false
+					p.saved(p.project.SavePackageJSON())
 				}()
 
 			case "esc":
@@ -130,15 +138,91 @@ func (p *ProjectPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
 
 			// Update the input
 			p.input, cmd = p.input.Update(msg)
+
+		case "conflict":
+			// package.json changed on disk, ask what to keep
+			if p.loading {
+				break
+			}
+			switch msg.String() {
+			case "r":
+				// Drop the edit and take the file as it is now
+				p.loading = true
+				go func() {
+					if err := p.project.LoadPackageJSON(); err != nil {
+						p.error = fmt.Sprintf("Error reloading package.json: %v", err)
+					}
+					p.mode = "view"
+					p.loading = false
+				}()
+
+			case "o":
+				// Write the edit over the other changes
+				p.loading = true
+				go func() {
+					p.saved(p.project.OverwritePackageJSON())
+				}()
+
+			case "m":
+				// Apply the edit on top of the other changes
+				p.loading = true
+				go func() {
+					conflicts, err := p.project.MergePackageJSON()
+					if len(conflicts) > 0 {
+						p.error = fmt.Sprintf("Both sides changed %s - reload or overwrite", strings.Join(conflicts, ", "))
+						p.loading = false
+						return
+					}
+					p.saved(err)
+				}()
+
+			case "esc":
+				// Keep the edit unsaved for now
+				p.mode = "view"
+			}
 		}
 	}
 
 	return p, cmd
 }
 
+// saved handles the result of writing package.json, asking what to do
+// when the file was changed elsewhere
+func (p *ProjectPanel) saved(err error) {
+	var conflict *packagejson.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		p.mode = "conflict"
+		p.error = ""
+	case err != nil:
+		p.error = fmt.Sprintf("Error saving package.json: %v", err)
+		p.mode = "view"
+	default:
+		p.mode = "view"
+	}
+	p.loading = false
+}
+
+// CapturingInput reports whether an input or the conflict prompt is active
+func (p *ProjectPanel) CapturingInput() bool {
+	return p.mode != "view"
+}
+
 // View renders the panel
 func (p *ProjectPanel) View() string {
 	// In a 4-panel grid, we need to be more economical with space
+	if p.mode == "conflict" {
+		status := "[r]Reload [o]Overwrite [m]Merge [esc]Later"
+		if p.loading {
+			status = "Saving..."
+		} else if p.error != "" {
+			status = ErrorStyle.Render(p.error) + "\n" + status
+		}
+		return fmt.Sprintf("%s\n%s",
+			ErrorStyle.Render("package.json changed on disk since it was loaded"),
+			status)
+	}
+
 	if p.mode == "edit" {
 		// Simple edit view
 		return fmt.Sprintf("%s:\n%s\n[↵]Save [esc]Cancel",
@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/VesperAkshay/lazynode/pkg/process"
+	"github.com/VesperAkshay/lazynode/pkg/recent"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openProjectMsg replaces the project with another one
+type openProjectMsg struct {
+	path string
+	// stopRunning stops the processes of the current project instead of
+	// keeping them running in the background
+	stopRunning bool
+}
+
+// projectOpenedMsg carries a project opened from the switcher, loaded but
+// not yet in place of the current one
+type projectOpenedMsg struct {
+	detected    projectDetectedMsg
+	stopRunning bool
+}
+
+// openProjectFailedMsg reports a project of the switcher that couldn't be
+// loaded. The current project stays open.
+type openProjectFailedMsg struct {
+	path  string
+	error string
+}
+
+// ProjectSwitcherPanel filters the recently opened projects as the user
+// types and opens the chosen one in place of the current project
+type ProjectSwitcherPanel struct {
+	title      string
+	width      int
+	height     int
+	input      textinput.Model
+	registry   *recent.Registry
+	matches    []recent.Project
+	current    string              // package.json of the current project
+	supervisor *process.Supervisor // Runs the processes of the current project
+	confirming string              // Project to open once the user chose what to do with the running processes
+	cursor     int
+	offset     int
+	error      string
+}
+
+// NewProjectSwitcherPanel lists the projects of the registry, pinned first
+func NewProjectSwitcherPanel(currentPackageJSONPath string, supervisor *process.Supervisor) *ProjectSwitcherPanel {
+	input := textinput.New()
+	input.Placeholder = "Project name or path"
+	input.Prompt = "> "
+	input.Focus()
+
+	p := &ProjectSwitcherPanel{
+		title:      "Switch Project",
+		input:      input,
+		current:    filepath.Clean(currentPackageJSONPath),
+		supervisor: supervisor,
+	}
+
+	registry, err := recent.Load()
+	if err != nil {
+		p.error = fmt.Sprintf("Error reading recent projects: %v", err)
+		registry = &recent.Registry{}
+	}
+	p.registry = registry
+	p.filter()
+	return p
+}
+
+// filter keeps the projects matching the query, the best matches first
+func (p *ProjectSwitcherPanel) filter() {
+	query := p.input.Value()
+
+	type match struct {
+		project recent.Project
+		score   int
+	}
+	var matches []match
+	for _, project := range p.registry.Existing() {
+		if score, ok := fuzzyScore(query, project.Name+" "+filepath.Dir(project.PackageJSONPath)); ok {
+			matches = append(matches, match{project, score})
+		}
+	}
+	// Keep the registry order, pinned and recent first, among equal scores
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	p.matches = p.matches[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.project)
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+// fuzzyScore reports whether the letters of query appear in text in order,
+// ignoring case, and scores the match: consecutive letters and letters
+// starting a word score higher, gaps lower
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(strings.ReplaceAll(query, " ", ""))
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+
+	score, last := 0, -1
+	i := 0
+	for _, q := range query {
+		for i < len(lower) && lower[i] != q {
+			i++
+		}
+		if i == len(lower) {
+			return 0, false
+		}
+
+		switch {
+		case i == last+1:
+			score += 3
+		case i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]):
+			score += 2
+		default:
+			score -= 1
+		}
+		last = i
+		i++
+	}
+	return score, true
+}
+
+// open switches to a project, asking first what to do with the processes
+// still running in the current one
+func (p *ProjectSwitcherPanel) open(project recent.Project) tea.Cmd {
+	if project.PackageJSONPath == p.current {
+		return closeOverlay
+	}
+	if p.supervisor != nil && p.supervisor.Running() > 0 {
+		p.confirming = project.PackageJSONPath
+		return nil
+	}
+	return openProject(project.PackageJSONPath, false)
+}
+
+// openProject returns a command opening a project
+func openProject(path string, stopRunning bool) tea.Cmd {
+	return func() tea.Msg {
+		return openProjectMsg{path: path, stopRunning: stopRunning}
+	}
+}
+
+// CapturingInput reports that keys go to the query, esc included
+func (p *ProjectSwitcherPanel) CapturingInput() bool {
+	return true
+}
+
+// Init initializes the panel
+func (p *ProjectSwitcherPanel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages
+func (p *ProjectSwitcherPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+
+	p.error = ""
+
+	if p.confirming != "" {
+		// Keep or stop the processes of the current project
+		path := p.confirming
+		switch keyMsg.String() {
+		case "k", "enter":
+			p.confirming = ""
+			return p, openProject(path, false)
+		case "s":
+			p.confirming = ""
+			return p, openProject(path, true)
+		case "esc":
+			p.confirming = ""
+		}
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		return p, closeOverlay
+	case "up", "ctrl+p":
+		p.cursor--
+	case "down", "ctrl+n":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+
+	case "enter":
+		if p.cursor < len(p.matches) {
+			return p, p.open(p.matches[p.cursor])
+		}
+
+	case "ctrl+t":
+		// Pin or unpin the selected project
+		if p.cursor < len(p.matches) {
+			selected := p.matches[p.cursor].PackageJSONPath
+			p.registry.TogglePin(selected)
+			if err := p.registry.Save(); err != nil {
+				p.error = fmt.Sprintf("Error saving recent projects: %v", err)
+			}
+			p.filter()
+			for i, project := range p.matches {
+				if project.PackageJSONPath == selected {
+					p.cursor = i
+				}
+			}
+		}
+
+	default:
+		// Narrow the list as the query changes
+		query := p.input.Value()
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		if p.input.Value() != query {
+			p.filter()
+		}
+		return p, cmd
+	}
+
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of projects that fit
+func (p *ProjectSwitcherPanel) pageSize() int {
+	// Query, header and status line
+	size := p.height - 3
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// location shows the directory of a project, from the home directory when
+// it's below it
+func (p *ProjectSwitcherPanel) location(packageJSONPath string) string {
+	dir := filepath.Dir(packageJSONPath)
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel)
+		}
+	}
+	return dir
+}
+
+// View renders the panel
+func (p *ProjectSwitcherPanel) View() string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	header := fmt.Sprintf("%d of %d recent projects", len(p.matches), len(p.registry.Projects))
+	if len(p.registry.Projects) == 0 {
+		header = "No recent projects yet: they are remembered as they are opened"
+	}
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{p.input.View(), dimStyle.Render(header)}
+	for row := p.offset; row < len(p.matches) && row < p.offset+size; row++ {
+		project := p.matches[row]
+
+		marker := " "
+		if project.PackageJSONPath == p.current {
+			marker = HighlightStyle.Render("●")
+		}
+		pin := " "
+		if project.Pinned {
+			pin = SelectedItemStyle.Render("★")
+		}
+		line := fmt.Sprintf("%s%s %-30s %-50s %s", marker, pin, project.Name, p.location(project.PackageJSONPath),
+			dimStyle.Render(project.OpenedAt.Format("Jan 02 15:04")))
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+2 {
+		lines = append(lines, "")
+	}
+
+	status := "[↵]Open [ctrl+t]Pin/unpin [esc]Close  ● current  ★ pinned"
+	switch {
+	case p.confirming != "":
+		status = HighlightStyle.Render(fmt.Sprintf("%d processes are running in this project: [k]Keep them running in the background [s]Stop them [esc]Cancel",
+			p.supervisor.Running()))
+	case p.error != "":
+		status = ErrorStyle.Render(p.error)
+	}
+	lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(status))
+	return strings.Join(lines, "\n")
+}
+
+// Width returns the panel width
+func (p *ProjectSwitcherPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *ProjectSwitcherPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *ProjectSwitcherPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.input.Width = width - 4
+}
+
+// Title returns the panel title
+func (p *ProjectSwitcherPanel) Title() string {
+	return p.title
+}
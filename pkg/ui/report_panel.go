@@ -0,0 +1,340 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DependencyReportPanel lists the dependencies the packages of a monorepo
+// declare with different ranges, and the packages the lockfile resolves at
+// several versions, with actions to align and dedupe them
+type DependencyReportPanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	workspace      *workspace.Workspace // Nil outside a monorepo
+	drifts         []workspace.Drift
+	duplicates     []lockfile.Duplicate
+	showDuplicates bool
+	cursor         int
+	offset         int
+	busy           string // Description of the running action, if any
+	statusMessage  string
+	statusTime     time.Time
+	error          string
+}
+
+// NewDependencyReportPanel analyzes the project, starting with the version
+// drift in a monorepo and the lockfile duplicates otherwise
+func NewDependencyReportPanel(packageManager *npm.PackageManager) *DependencyReportPanel {
+	p := &DependencyReportPanel{
+		title:          "Dependency Report",
+		packageManager: packageManager,
+	}
+	p.load()
+	p.showDuplicates = p.workspace == nil
+	return p
+}
+
+// load reads the workspaces and the lockfile again
+func (p *DependencyReportPanel) load() {
+	dir := filepath.Dir(p.packageManager.PackageJSONPath)
+	p.workspace, p.drifts = nil, nil
+	if w, err := workspace.Find(dir); err == nil {
+		p.workspace = w
+		p.drifts = w.Drift()
+	}
+
+	p.duplicates = nil
+	if graph, err := lockfile.Load(dir); err == nil {
+		p.duplicates = lockfile.Duplicates(graph)
+	}
+}
+
+// count returns the number of rows of the current view
+func (p *DependencyReportPanel) count() int {
+	if p.showDuplicates {
+		return len(p.duplicates)
+	}
+	return len(p.drifts)
+}
+
+// align rewrites every declaration of the selected dependency to one of
+// its ranges in the background
+func (p *DependencyReportPanel) align(drift workspace.Drift, rng string) {
+	if p.busy != "" || p.workspace == nil {
+		return
+	}
+
+	changed := len(drift.Usages) - drift.Count(rng)
+	p.busy = fmt.Sprintf("Aligning %s to %s", drift.Name, rng)
+	go func() {
+		err := p.packageManager.AlignRange(p.workspace, drift, rng)
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+		} else {
+			p.statusMessage = fmt.Sprintf("✅ Aligned %s to %s in %d declarations", drift.Name, rng, changed)
+			p.statusTime = time.Now()
+		}
+		p.load()
+	}()
+}
+
+// dedupe runs the client's dedupe in the background and reports how many
+// duplicated packages it removed
+func (p *DependencyReportPanel) dedupe() {
+	if p.busy != "" {
+		return
+	}
+
+	p.busy = "Deduping"
+	go func() {
+		before, after, err := p.packageManager.Dedupe()
+		p.busy = ""
+		if err != nil {
+			p.error = fmt.Sprintf("Error: %v", err)
+		} else {
+			p.statusMessage = fmt.Sprintf("✅ Deduped: %d → %d duplicated packages", before, after)
+			p.statusTime = time.Now()
+		}
+		p.load()
+	}()
+}
+
+// Init initializes the panel
+func (p *DependencyReportPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *DependencyReportPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	p.error = ""
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = p.count() - 1
+
+	case "tab":
+		// Switch between the version drift and the lockfile duplicates
+		p.showDuplicates = !p.showDuplicates
+		p.cursor, p.offset = 0, 0
+
+	case "r":
+		// Analyze again
+		if p.busy == "" {
+			p.load()
+		}
+
+	case "d":
+		// Dedupe the lockfile
+		p.dedupe()
+
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		// Align every package to one of the ranges
+		if !p.showDuplicates && p.cursor < len(p.drifts) {
+			drift := p.drifts[p.cursor]
+			index := int(keyMsg.String()[0] - '1')
+			if index < len(drift.Ranges) {
+				p.align(drift, drift.Ranges[index])
+			}
+		}
+	}
+
+	if p.cursor >= p.count() {
+		p.cursor = p.count() - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of rows that fit
+func (p *DependencyReportPanel) pageSize() int {
+	// Header, status line and the details below the list
+	size := p.height - 2 - len(p.detailLines())
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// driftLine renders a dependency with its ranges and how many packages use each
+func (p *DependencyReportPanel) driftLine(drift workspace.Drift) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	ranges := make([]string, len(drift.Ranges))
+	for i, rng := range drift.Ranges {
+		ranges[i] = rng + dimStyle.Render(fmt.Sprintf(" ×%d", drift.Count(rng)))
+	}
+	return HighlightStyle.Render(fmt.Sprintf("%-30s", drift.Name)) + " " + strings.Join(ranges, ", ")
+}
+
+// duplicateLine renders a package with the versions the lockfile holds
+func (p *DependencyReportPanel) duplicateLine(duplicate lockfile.Duplicate) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	return HighlightStyle.Render(fmt.Sprintf("%-30s", duplicate.Name)) + " " +
+		strings.Join(duplicate.Versions, ", ") + dimStyle.Render(fmt.Sprintf(" (%d versions)", len(duplicate.Versions)))
+}
+
+// detailLines describes the selected row: who declares which range, or who
+// requires which version
+func (p *DependencyReportPanel) detailLines() []string {
+	if p.cursor >= p.count() {
+		return nil
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	lines := []string{dimStyle.Render(strings.Repeat("─", p.width))}
+
+	if p.showDuplicates {
+		duplicate := p.duplicates[p.cursor]
+		for _, version := range duplicate.Versions {
+			dependents := duplicate.Dependents[version]
+			required := "required by " + strings.Join(dependents, ", ")
+			if len(dependents) == 0 {
+				required = "required by nothing"
+			}
+			lines = append(lines, fmt.Sprintf("  %-12s %s", version, dimStyle.Render(required)))
+		}
+		return lines
+	}
+
+	drift := p.drifts[p.cursor]
+	for _, usage := range drift.Usages {
+		name := usage.Package.Name
+		if usage.Package == p.workspace.Root {
+			name = workspaceRootName(p.workspace) + " (root)"
+		}
+		lines = append(lines, fmt.Sprintf("  %-30s %-12s %s", name, usage.Range, dimStyle.Render(usage.Section)))
+	}
+	for i, rng := range drift.Ranges {
+		if i == 9 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("[%d] align to %s %s", i+1, HighlightStyle.Render(rng),
+			dimStyle.Render(fmt.Sprintf("rewrites %d declarations", len(drift.Usages)-drift.Count(rng)))))
+	}
+	return lines
+}
+
+// View renders the panel
+func (p *DependencyReportPanel) View() string {
+	var header string
+	switch {
+	case p.showDuplicates && len(p.duplicates) == 0:
+		header = "The lockfile holds one version of every package"
+	case p.showDuplicates:
+		header = fmt.Sprintf("%d packages the lockfile holds at several versions", len(p.duplicates))
+	case p.workspace == nil:
+		header = "Not part of a monorepo: no version drift to compare"
+	case len(p.drifts) == 0:
+		header = fmt.Sprintf("Every package of %s declares the same ranges", workspaceRootName(p.workspace))
+	default:
+		header = fmt.Sprintf("%d dependencies declared with different ranges across %d packages",
+			len(p.drifts), len(p.workspace.Packages)+1)
+	}
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for row := p.offset; row < p.count() && row < p.offset+size; row++ {
+		var line string
+		if p.showDuplicates {
+			line = p.duplicateLine(p.duplicates[row])
+		} else {
+			line = p.driftLine(p.drifts[row])
+		}
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the details and status line stay at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	for _, line := range p.detailLines() {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the progress, the last result or the key hints
+func (p *DependencyReportPanel) statusLine() string {
+	status := "[tab]Drift/duplicates [d]Dedupe [r]Refresh"
+	if !p.showDuplicates {
+		status = "[1-9]Align to range " + status
+	}
+
+	switch {
+	case p.busy != "":
+		status = p.busy + "... " + status
+	case p.error != "":
+		status = ErrorStyle.Render(p.error)
+	case p.statusMessage != "" && time.Since(p.statusTime) < 5*time.Second:
+		status = HighlightStyle.Render(p.statusMessage)
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *DependencyReportPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *DependencyReportPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *DependencyReportPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *DependencyReportPanel) Title() string {
+	return p.title
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keySequences maps special keys to the bytes a terminal sends for them
+var keySequences = map[tea.KeyType]string{
+	tea.KeySpace:    " ",
+	tea.KeyUp:       "\x1b[A",
+	tea.KeyDown:     "\x1b[B",
+	tea.KeyRight:    "\x1b[C",
+	tea.KeyLeft:     "\x1b[D",
+	tea.KeyHome:     "\x1b[H",
+	tea.KeyEnd:      "\x1b[F",
+	tea.KeyPgUp:     "\x1b[5~",
+	tea.KeyPgDown:   "\x1b[6~",
+	tea.KeyDelete:   "\x1b[3~",
+	tea.KeyInsert:   "\x1b[2~",
+	tea.KeyShiftTab: "\x1b[Z",
+}
+
+// keyToBytes converts a key press into the input a process terminal expects
+func keyToBytes(msg tea.KeyMsg) []byte {
+	var data string
+
+	switch {
+	case msg.Type == tea.KeyRunes:
+		data = string(msg.Runes)
+	case msg.Type >= 0 && msg.Type < 32 || msg.Type == tea.KeyBackspace:
+		// Control characters, enter, tab, escape and backspace are sent as is
+		data = string(rune(msg.Type))
+	default:
+		data = keySequences[msg.Type]
+	}
+
+	// Alt prefixes the key with escape
+	if msg.Alt && data != "" {
+		data = "\x1b" + data
+	}
+
+	return []byte(data)
+}
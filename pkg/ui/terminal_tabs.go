@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/process"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Terminal tabs that are not a single process
+const (
+	logsTab = "logs" // LazyNode's own messages
+	allTab  = "all"  // Every process output merged, like concurrently
+)
+
+// maxAllLines limits how many lines the "all" view merges
+const maxAllLines = 2000
+
+// prefixColors are cycled through to tell processes apart in the "all" view
+var prefixColors = []lipgloss.Color{
+	terminalBrightCyan,
+	terminalBrightYellow,
+	terminalBrightBlue,
+	terminalBrightGreen,
+	lipgloss.Color("#d3869b"),
+	lipgloss.Color("#fe8019"),
+}
+
+// tabs returns the terminal tabs: the logs, the "all" view and one per process
+func (p *LogsPanel) tabs() []string {
+	tabs := []string{logsTab}
+	if p.supervisor == nil {
+		return tabs
+	}
+
+	names := p.supervisor.Names()
+	if len(names) > 0 {
+		tabs = append(tabs, allTab)
+	}
+	return append(tabs, names...)
+}
+
+// switchTab moves to the next (delta 1) or previous (delta -1) tab
+func (p *LogsPanel) switchTab(delta int) {
+	tabs := p.tabs()
+
+	current := 0
+	for i, tab := range tabs {
+		if tab == p.tab {
+			current = i
+			break
+		}
+	}
+
+	p.tab = tabs[(current+delta+len(tabs))%len(tabs)]
+	p.interactive = false
+	p.viewport.GotoBottom()
+	p.resizeProcess()
+}
+
+// currentProcess returns the process of the selected tab, if any
+func (p *LogsPanel) currentProcess() *process.Process {
+	if p.supervisor == nil || p.tab == logsTab || p.tab == allTab {
+		return nil
+	}
+
+	proc, _ := p.supervisor.Process(p.tab)
+	return proc
+}
+
+// tabBadge renders the state of a process for its tab
+func tabBadge(status process.Status) string {
+	switch status.State {
+	case process.StateRunning:
+		return lipgloss.NewStyle().Foreground(terminalBrightGreen).Render("● running")
+	case process.StateStopping:
+		return lipgloss.NewStyle().Foreground(terminalBrightYellow).Render("◌ stopping")
+	case process.StateRestarting:
+		return lipgloss.NewStyle().Foreground(terminalBrightYellow).Render("↻ restarting")
+	}
+
+	if status.ExitCode == 0 {
+		return lipgloss.NewStyle().Foreground(terminalBrightBlue).Render("✓ exited 0")
+	}
+	return lipgloss.NewStyle().Foreground(terminalBrightRed).Render(fmt.Sprintf("✗ exited %d", status.ExitCode))
+}
+
+// tabBar renders the tab headers, highlighting the selected tab
+func (p *LogsPanel) tabBar() string {
+	activeStyle := lipgloss.NewStyle().
+		Background(terminalBrightBlack).
+		Foreground(terminalBrightWhite).
+		Bold(true)
+	inactiveStyle := lipgloss.NewStyle().
+		Foreground(terminalWhite)
+
+	var headers []string
+	for _, tab := range p.tabs() {
+		label := tab
+		switch tab {
+		case logsTab:
+			label = "LazyNode"
+		case allTab:
+			label = "all"
+		}
+
+		style := inactiveStyle
+		if tab == p.tab {
+			style = activeStyle
+		}
+		header := style.Render(" " + label + " ")
+
+		// Show the state of each process next to its name
+		if tab != logsTab && tab != allTab {
+			if status, ok := p.supervisor.Status(tab); ok {
+				header += tabBadge(status)
+			}
+		}
+
+		headers = append(headers, header)
+	}
+
+	return lipgloss.NewStyle().
+		MaxWidth(p.viewport.Width).
+		Render(strings.Join(headers, " │ "))
+}
+
+// allContent merges the output of every process in the order it was written,
+// prefixing each line with the process name
+func (p *LogsPanel) allContent() string {
+	type namedLine struct {
+		prefix string
+		line   process.Line
+	}
+
+	names := p.supervisor.Names()
+
+	// Pad the prefixes so the output lines up
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	var lines []namedLine
+	for i, name := range names {
+		output, ok := p.supervisor.Output(name)
+		if !ok {
+			continue
+		}
+
+		prefix := lipgloss.NewStyle().
+			Foreground(prefixColors[i%len(prefixColors)]).
+			Render(fmt.Sprintf("[%-*s]", width, name))
+
+		for _, line := range output.Tail(maxAllLines) {
+			lines = append(lines, namedLine{prefix: prefix, line: line})
+		}
+	}
+
+	// Lines are numbered across all buffers, so sorting restores the write order
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].line.Seq < lines[j].line.Seq
+	})
+	if len(lines) > maxAllLines {
+		lines = lines[len(lines)-maxAllLines:]
+	}
+
+	stderrStyle := lipgloss.NewStyle().Foreground(terminalBrightRed)
+
+	rendered := make([]string, 0, len(lines))
+	for _, l := range lines {
+		text := l.line.Text
+		if l.line.Stream == process.Stderr {
+			text = stderrStyle.Render(text)
+		}
+		rendered = append(rendered, l.prefix+" "+text)
+	}
+
+	return strings.Join(rendered, "\n")
+}
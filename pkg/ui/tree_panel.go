@@ -0,0 +1,579 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/semver"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pathSeparator joins the dependency names of a tree path into a row key
+const pathSeparator = " > "
+
+// treeRow is one visible line of the dependency tree
+type treeRow struct {
+	key        string         // Dependency names from the root, joined by pathSeparator; see edgeKey
+	edge       *lockfile.Edge // nil for extraneous packages
+	node       *lockfile.Node // nil when the dependency is missing
+	depth      int
+	prefix     string // Branch characters in front of the row
+	expandable bool
+	expanded   bool
+	deduped    bool
+	missing    bool
+	extraneous bool
+	invalid    bool
+}
+
+// DependencyTreePanel shows the resolved dependency graph as a collapsible tree
+type DependencyTreePanel struct {
+	title          string
+	width          int
+	height         int
+	packageManager *npm.PackageManager
+	graph          *lockfile.Graph
+	root           *lockfile.Node
+	// canonical is the row key where each package is shown in full; other occurrences are deduped
+	canonical  map[*lockfile.Node]string
+	extraneous []*lockfile.Node
+	expanded   map[string]bool
+	rows       []treeRow
+	cursor     int
+	offset     int
+	input      textinput.Model
+	searching  bool
+	query      string
+	matches    []string // Row keys of every occurrence of the searched package
+	match      int
+	missing    int
+	invalid    int
+	error      string
+}
+
+// NewDependencyTreePanel creates a tree of the project's resolved dependencies
+func NewDependencyTreePanel(packageManager *npm.PackageManager) *DependencyTreePanel {
+	input := textinput.New()
+	input.Placeholder = "Package name"
+	input.Prompt = "/ "
+
+	p := &DependencyTreePanel{
+		title:          "Dependency Tree",
+		packageManager: packageManager,
+		expanded:       make(map[string]bool),
+		input:          input,
+	}
+
+	p.load()
+	return p
+}
+
+// load builds the tree from the lockfile graph of the project
+func (p *DependencyTreePanel) load() {
+	p.graph = p.packageManager.Graph
+	if p.graph == nil {
+		p.error = "No lockfile found - install dependencies to see the tree"
+		return
+	}
+
+	p.root = p.graph.Importer(filepath.Dir(p.packageManager.PackageJSONPath))
+	if p.root == nil {
+		p.root = p.graph.Root
+	}
+
+	// Show each package in full where it is first reached, like npm ls
+	p.canonical = map[*lockfile.Node]string{p.root: ""}
+	queue := []*lockfile.Node{p.root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		edges := sortedEdges(n)
+		for i, edge := range edges {
+			if edge.To == nil {
+				continue
+			}
+			if _, seen := p.canonical[edge.To]; !seen {
+				p.canonical[edge.To] = joinKey(p.canonical[n], edgeKey(edges, i))
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	// Packages nothing depends on, in the lockfile or in node_modules
+	p.extraneous = p.graph.Extraneous()
+	for name, version := range p.packageManager.ReadNodeModules() {
+		if len(p.graph.Lookup(name)) == 0 && p.root.Dependency(name) == nil {
+			p.extraneous = append(p.extraneous, &lockfile.Node{Name: name, Version: version})
+		}
+	}
+	sort.Slice(p.extraneous, func(i, j int) bool {
+		return p.extraneous[i].ID() < p.extraneous[j].ID()
+	})
+
+	// Count the problems in the whole tree, not only the expanded part
+	p.walk(func(key string, edge *lockfile.Edge) {
+		if edge.To == nil && edge.Required() {
+			p.missing++
+		} else if edge.To != nil && !satisfies(edge.To.Version, edge.Range) {
+			p.invalid++
+		}
+	})
+
+	p.buildRows()
+}
+
+// walk calls fn for every occurrence of every dependency in the tree,
+// descending only where each package is shown in full
+func (p *DependencyTreePanel) walk(fn func(key string, edge *lockfile.Edge)) {
+	var visit func(n *lockfile.Node, key string)
+	visit = func(n *lockfile.Node, key string) {
+		edges := sortedEdges(n)
+		for i, edge := range edges {
+			rowKey := joinKey(key, edgeKey(edges, i))
+			fn(rowKey, edge)
+			if edge.To != nil && p.canonical[edge.To] == rowKey {
+				visit(edge.To, rowKey)
+			}
+		}
+	}
+	visit(p.root, "")
+}
+
+// sortedEdges returns the dependencies of a node sorted by name, then by
+// the version they resolve to
+func sortedEdges(n *lockfile.Node) []*lockfile.Edge {
+	edges := append([]*lockfile.Edge(nil), n.Dependencies...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].Name != edges[j].Name {
+			return edges[i].Name < edges[j].Name
+		}
+		return edges[i].To != nil && (edges[j].To == nil || semver.Compare(edges[i].To.Version, edges[j].To.Version) < 0)
+	})
+	return edges
+}
+
+// edgeKey returns the row key part of the i-th sorted edge: its name, with
+// the version when a package shared by several install paths resolves the
+// name to more than one version
+func edgeKey(edges []*lockfile.Edge, i int) string {
+	edge := edges[i]
+	shared := (i > 0 && edges[i-1].Name == edge.Name) || (i+1 < len(edges) && edges[i+1].Name == edge.Name)
+	if shared && edge.To != nil {
+		return edge.Name + "@" + edge.To.Version
+	}
+	return edge.Name
+}
+
+// joinKey appends a dependency name to a row key
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + pathSeparator + name
+}
+
+// buildRows flattens the expanded part of the tree into rows
+func (p *DependencyTreePanel) buildRows() {
+	p.rows = p.rows[:0]
+	p.addRows(p.root, "", 0, "")
+
+	for _, n := range p.extraneous {
+		p.rows = append(p.rows, treeRow{
+			key:        n.ID(),
+			node:       n,
+			extraneous: true,
+		})
+	}
+
+	if p.cursor >= len(p.rows) {
+		p.cursor = len(p.rows) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// addRows adds a row for each dependency of n, recursing into expanded ones
+func (p *DependencyTreePanel) addRows(n *lockfile.Node, key string, depth int, prefix string) {
+	edges := sortedEdges(n)
+
+	// Optional dependencies that were not installed are not worth a row
+	visible := edges[:0:0]
+	var keys []string
+	for i, edge := range edges {
+		if edge.To != nil || edge.Required() {
+			visible = append(visible, edge)
+			keys = append(keys, joinKey(key, edgeKey(edges, i)))
+		}
+	}
+
+	for i, edge := range visible {
+		last := i == len(visible)-1
+		row := treeRow{
+			key:   keys[i],
+			edge:  edge,
+			node:  edge.To,
+			depth: depth,
+		}
+
+		branch, childPrefix := "├─ ", prefix+"│  "
+		if last {
+			branch, childPrefix = "└─ ", prefix+"   "
+		}
+		row.prefix = prefix + branch
+
+		if edge.To == nil {
+			row.missing = true
+		} else {
+			row.deduped = p.canonical[edge.To] != row.key
+			row.invalid = !satisfies(edge.To.Version, edge.Range)
+			row.expandable = !row.deduped && len(edge.To.Dependencies) > 0
+			row.expanded = row.expandable && p.expanded[row.key]
+		}
+
+		p.rows = append(p.rows, row)
+
+		if row.expanded {
+			p.addRows(edge.To, row.key, depth+1, childPrefix)
+		}
+	}
+}
+
+// satisfies reports whether version is allowed by rng. Ranges that aren't
+// semver, such as tags, URLs and workspace: or file: protocols, are accepted.
+func satisfies(version, rng string) bool {
+	// An alias like "npm:string-width@^4.2.0" is checked against its range
+	if strings.HasPrefix(rng, "npm:") {
+		if i := strings.LastIndex(rng, "@"); i > len("npm:") {
+			rng = rng[i+1:]
+		}
+	}
+
+	if !semver.ValidRange(rng) {
+		return true
+	}
+	return semver.Satisfies(version, rng)
+}
+
+// Init initializes the panel
+func (p *DependencyTreePanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *DependencyTreePanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	// Typing a search
+	if p.searching {
+		switch keyMsg.String() {
+		case "enter":
+			p.searching = false
+			p.input.Blur()
+			p.search(p.input.Value())
+			return p, nil
+		case "esc":
+			p.searching = false
+			p.input.Blur()
+			return p, nil
+		}
+
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+
+	if len(p.rows) == 0 {
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(p.rows)-1 {
+			p.cursor++
+		}
+	case "pgup":
+		p.cursor -= p.pageSize()
+		if p.cursor < 0 {
+			p.cursor = 0
+		}
+	case "pgdown":
+		p.cursor += p.pageSize()
+		if p.cursor >= len(p.rows) {
+			p.cursor = len(p.rows) - 1
+		}
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = len(p.rows) - 1
+
+	case "enter", " ":
+		// Toggle the selected node
+		row := p.rows[p.cursor]
+		if row.expandable {
+			p.expanded[row.key] = !row.expanded
+			p.buildRows()
+		} else if row.deduped {
+			// Jump to where the package is shown in full
+			p.jumpTo(p.canonical[row.node])
+		}
+
+	case "right", "l":
+		row := p.rows[p.cursor]
+		if row.expandable && !row.expanded {
+			p.expanded[row.key] = true
+			p.buildRows()
+		}
+
+	case "left", "h":
+		// Collapse the node, or move to its parent
+		row := p.rows[p.cursor]
+		if row.expanded {
+			p.expanded[row.key] = false
+			p.buildRows()
+		} else if index := strings.LastIndex(row.key, pathSeparator); index >= 0 {
+			p.jumpTo(row.key[:index])
+		}
+
+	case "w":
+		// Explain why the selected package is installed
+		// Missing packages have no node, extraneous ones no edge
+		row := p.rows[p.cursor]
+		if row.edge != nil {
+			return p, openOverlay(NewWhyPanel(p.graph, row.edge.Name))
+		}
+		return p, openOverlay(NewWhyPanel(p.graph, row.node.Name))
+
+	case "/":
+		// Search for a package
+		p.searching = true
+		p.input.SetValue("")
+		p.input.Focus()
+		return p, textinput.Blink
+
+	case "n":
+		// Next occurrence
+		if len(p.matches) > 0 {
+			p.match = (p.match + 1) % len(p.matches)
+			p.jumpTo(p.matches[p.match])
+		}
+
+	case "N":
+		// Previous occurrence
+		if len(p.matches) > 0 {
+			p.match = (p.match - 1 + len(p.matches)) % len(p.matches)
+			p.jumpTo(p.matches[p.match])
+		}
+	}
+
+	return p, nil
+}
+
+// search finds every occurrence of packages whose name contains query and jumps to the first
+func (p *DependencyTreePanel) search(query string) {
+	p.query = strings.ToLower(strings.TrimSpace(query))
+	p.matches = nil
+	p.match = 0
+	if p.query == "" || p.root == nil {
+		return
+	}
+
+	p.walk(func(key string, edge *lockfile.Edge) {
+		if strings.Contains(strings.ToLower(edge.Name), p.query) {
+			p.matches = append(p.matches, key)
+		}
+	})
+
+	for _, n := range p.extraneous {
+		if strings.Contains(strings.ToLower(n.Name), p.query) {
+			p.matches = append(p.matches, n.ID())
+		}
+	}
+
+	if len(p.matches) > 0 {
+		p.jumpTo(p.matches[0])
+	}
+}
+
+// jumpTo expands the ancestors of the row with the given key and selects it
+func (p *DependencyTreePanel) jumpTo(key string) {
+	parts := strings.Split(key, pathSeparator)
+	for i := 1; i < len(parts); i++ {
+		p.expanded[strings.Join(parts[:i], pathSeparator)] = true
+	}
+	p.buildRows()
+
+	for i, row := range p.rows {
+		if row.key == key {
+			p.cursor = i
+			return
+		}
+	}
+}
+
+// CapturingInput reports whether the search input is active
+func (p *DependencyTreePanel) CapturingInput() bool {
+	return p.searching
+}
+
+// pageSize returns the number of rows that fit in the panel
+func (p *DependencyTreePanel) pageSize() int {
+	size := p.height - 3 // Header, status line and search
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// rowView renders a single row
+func (p *DependencyTreePanel) rowView(row treeRow) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	warnStyle := lipgloss.NewStyle().Foreground(terminalBrightYellow)
+
+	// Expansion marker
+	marker := "  "
+	if row.expandable && row.expanded {
+		marker = "▾ "
+	} else if row.expandable {
+		marker = "▸ "
+	}
+
+	var label string
+	switch {
+	case row.missing && row.edge.Type == lockfile.Peer:
+		label = ErrorStyle.Render(fmt.Sprintf("UNMET PEER DEPENDENCY %s@%s", row.edge.Name, row.edge.Range))
+	case row.missing:
+		label = ErrorStyle.Render(fmt.Sprintf("UNMET DEPENDENCY %s@%s", row.edge.Name, row.edge.Range))
+	default:
+		name := row.node.Name
+		if row.edge != nil {
+			name = row.edge.Name // Aliased dependencies keep their local name
+		}
+		if p.query != "" && strings.Contains(strings.ToLower(name), p.query) {
+			name = HighlightStyle.Render(name)
+		}
+		label = fmt.Sprintf("%s@%s", name, row.node.Version)
+	}
+
+	// Markers in the style of npm ls
+	var tags []string
+	if row.edge != nil && row.edge.Type != lockfile.Prod {
+		tags = append(tags, dimStyle.Render(strings.TrimSuffix(string(row.edge.Type), "Dependencies")))
+	}
+	if row.deduped {
+		tags = append(tags, dimStyle.Render("deduped"))
+	}
+	if row.invalid {
+		tags = append(tags, ErrorStyle.Render(fmt.Sprintf("invalid: %q required", row.edge.Range)))
+	}
+	if row.extraneous {
+		tags = append(tags, warnStyle.Render("extraneous"))
+	}
+
+	line := dimStyle.Render(row.prefix) + marker + label
+	if len(tags) > 0 {
+		line += " " + strings.Join(tags, " ")
+	}
+	return line
+}
+
+// View renders the panel
+func (p *DependencyTreePanel) View() string {
+	if p.error != "" {
+		return ErrorStyle.Render(p.error)
+	}
+
+	header := HighlightStyle.Render(fmt.Sprintf("%s@%s", p.root.Name, p.root.Version)) +
+		lipgloss.NewStyle().Foreground(terminalBrightBlack).Render(" "+p.graph.String())
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for i := p.offset; i < len(p.rows) && i < p.offset+size; i++ {
+		line := p.rowView(p.rows[i])
+		if i == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, p.statusLine())
+	return strings.Join(lines, "\n")
+}
+
+// statusLine shows the search input, the search position or the key hints
+func (p *DependencyTreePanel) statusLine() string {
+	if p.searching {
+		return p.input.View()
+	}
+
+	status := fmt.Sprintf("[↵]Expand [←]Collapse [/]Search [w]Why  %d packages", len(p.graph.Nodes))
+	if p.query != "" {
+		if len(p.matches) == 0 {
+			status = fmt.Sprintf("No match for %q  %s", p.query, status)
+		} else {
+			status = fmt.Sprintf("[n/N] %d/%d for %q  %s", p.match+1, len(p.matches), p.query, status)
+		}
+	}
+	if p.missing > 0 {
+		status += ErrorStyle.Render(fmt.Sprintf(" · %d missing", p.missing))
+	}
+	if p.invalid > 0 {
+		status += ErrorStyle.Render(fmt.Sprintf(" · %d invalid", p.invalid))
+	}
+	if len(p.extraneous) > 0 {
+		status += lipgloss.NewStyle().Foreground(terminalBrightYellow).Render(fmt.Sprintf(" · %d extraneous", len(p.extraneous)))
+	}
+
+	return lipgloss.NewStyle().MaxWidth(p.width).Render(status)
+}
+
+// Width returns the panel width
+func (p *DependencyTreePanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *DependencyTreePanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *DependencyTreePanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.input.Width = width - 4
+}
+
+// Title returns the panel title
+func (p *DependencyTreePanel) Title() string {
+	return p.title
+}
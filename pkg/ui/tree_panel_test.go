@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// treeLockfile has a dependency missing from the lockfile and a package
+// nothing depends on
+const treeLockfile = `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "app",
+      "dependencies": {"absent": "^1.0.0", "present": "^1.0.0"}
+    },
+    "node_modules/present": {"version": "1.0.0"},
+    "node_modules/stray": {"version": "2.0.0"}
+  }
+}`
+
+func TestTreeWhy(t *testing.T) {
+	graph, err := lockfile.Parse(lockfile.KindNpm, []byte(treeLockfile), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm := &npm.PackageManager{PackageJSONPath: filepath.Join(t.TempDir(), "package.json"), Graph: graph}
+
+	tests := []struct {
+		name string
+		row  func(row treeRow) bool
+	}{
+		{"absent", func(row treeRow) bool { return row.missing }},
+		{"present", func(row treeRow) bool { return row.edge != nil && !row.missing }},
+		{"stray", func(row treeRow) bool { return row.extraneous }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewDependencyTreePanel(pm)
+			p.cursor = -1
+			for i, row := range p.rows {
+				if tc.row(row) {
+					p.cursor = i
+				}
+			}
+			if p.cursor < 0 {
+				t.Fatalf("no row for %s in %+v", tc.name, p.rows)
+			}
+
+			_, cmd := p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+			if cmd == nil {
+				t.Fatal("w opened nothing")
+			}
+			msg, ok := cmd().(openOverlayMsg)
+			if !ok {
+				t.Fatalf("w sent %T, want openOverlayMsg", cmd())
+			}
+			if why, ok := msg.panel.(*WhyPanel); !ok || why.name != tc.name {
+				t.Errorf("w opened %+v, want why %s", msg.panel, tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/lockfile"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxWhyPaths limits the paths listed for a package used all over the graph
+const maxWhyPaths = 200
+
+// WhyPanel lists every path from the project to a package, like npm explain
+type WhyPanel struct {
+	title  string
+	width  int
+	height int
+	name   string
+	paths  []lockfile.Path
+	lines  []string
+	offset int
+	error  string
+}
+
+// NewWhyPanel explains why the named package is installed
+func NewWhyPanel(graph *lockfile.Graph, name string) *WhyPanel {
+	p := &WhyPanel{
+		title: fmt.Sprintf("Why is %s installed?", name),
+		name:  name,
+	}
+
+	if graph == nil {
+		p.error = "No lockfile found - install dependencies to explain packages"
+		return p
+	}
+
+	p.paths = graph.Why(name, maxWhyPaths)
+	if len(p.paths) == 0 {
+		p.error = fmt.Sprintf("%s is not reachable from the project", name)
+		return p
+	}
+
+	p.lines = p.render()
+	return p
+}
+
+// render formats the paths grouped by the version they lead to
+func (p *WhyPanel) render() []string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	rangeStyle := lipgloss.NewStyle().Foreground(terminalBrightYellow)
+	directStyle := lipgloss.NewStyle().Foreground(terminalBrightCyan).Bold(true)
+
+	// Group the paths by target version, keeping their order
+	var versions []string
+	byVersion := make(map[string][]lockfile.Path)
+	for _, path := range p.paths {
+		version := path.Target().Version
+		if _, ok := byVersion[version]; !ok {
+			versions = append(versions, version)
+		}
+		byVersion[version] = append(byVersion[version], path)
+	}
+
+	var lines []string
+	for _, version := range versions {
+		paths := byVersion[version]
+		lines = append(lines, HighlightStyle.Render(fmt.Sprintf("%s@%s", p.name, version))+
+			dimStyle.Render(fmt.Sprintf(" (%d paths)", len(paths))))
+
+		for _, path := range paths {
+			// project › dep "range" 1.2.3 › ...
+			hops := []string{dimStyle.Render(path[0].From.Name)}
+			for i, step := range path {
+				name := step.Edge.Name
+				if i == 0 {
+					// The direct dependency is the one to bump
+					name = directStyle.Render(name)
+				}
+
+				hop := fmt.Sprintf("%s %s %s", name, rangeStyle.Render(fmt.Sprintf("%q", step.Edge.Range)), step.Edge.To.Version)
+				if step.Edge.Type != lockfile.Prod {
+					hop += dimStyle.Render(" " + strings.TrimSuffix(string(step.Edge.Type), "Dependencies"))
+				}
+				hops = append(hops, hop)
+			}
+
+			lines = append(lines, "  "+strings.Join(hops, dimStyle.Render(" › ")))
+		}
+		lines = append(lines, "")
+	}
+
+	if len(p.paths) >= maxWhyPaths {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("Showing the first %d paths", maxWhyPaths)))
+	}
+
+	return lines
+}
+
+// Init initializes the panel
+func (p *WhyPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *WhyPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	maxOffset := len(p.lines) - p.pageSize()
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.offset--
+	case "down", "j":
+		p.offset++
+	case "pgup":
+		p.offset -= p.pageSize()
+	case "pgdown":
+		p.offset += p.pageSize()
+	case "g", "home":
+		p.offset = 0
+	case "G", "end":
+		p.offset = maxOffset
+	}
+
+	if p.offset > maxOffset {
+		p.offset = maxOffset
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of lines that fit above the status line
+func (p *WhyPanel) pageSize() int {
+	size := p.height - 1
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// View renders the panel
+func (p *WhyPanel) View() string {
+	if p.error != "" {
+		return ErrorStyle.Render(p.error)
+	}
+
+	size := p.pageSize()
+	lines := make([]string, 0, size+1)
+	for i := p.offset; i < len(p.lines) && i < p.offset+size; i++ {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(p.lines[i]))
+	}
+	for len(lines) < size {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, fmt.Sprintf("[↑/↓]Scroll [Esc]Close  %d paths, direct dependencies highlighted", len(p.paths)))
+	return strings.Join(lines, "\n")
+}
+
+// Width returns the panel width
+func (p *WhyPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *WhyPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *WhyPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *WhyPanel) Title() string {
+	return p.title
+}
@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VesperAkshay/lazynode/pkg/scripts"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runStateStyles color the packages of a run across workspaces
+var runStateStyles = map[scripts.RunState]lipgloss.Style{
+	scripts.RunPending:   lipgloss.NewStyle().Foreground(terminalBrightBlack),
+	scripts.RunRunning:   lipgloss.NewStyle().Foreground(terminalBrightYellow),
+	scripts.RunSucceeded: lipgloss.NewStyle().Foreground(terminalBrightGreen),
+	scripts.RunFailed:    lipgloss.NewStyle().Foreground(terminalBrightRed).Bold(true),
+	scripts.RunSkipped:   lipgloss.NewStyle().Foreground(terminalBrightBlack),
+	scripts.RunCancelled: lipgloss.NewStyle().Foreground(terminalBrightBlack),
+}
+
+// runStateIcons mark the packages of a run across workspaces
+var runStateIcons = map[scripts.RunState]string{
+	scripts.RunPending:   "·",
+	scripts.RunRunning:   "▶",
+	scripts.RunSucceeded: "✓",
+	scripts.RunFailed:    "✗",
+	scripts.RunSkipped:   "-",
+	scripts.RunCancelled: "⊘",
+}
+
+// WorkspaceRunPanel runs a script in every package of a monorepo, in
+// dependency order, and shows how each package is doing
+type WorkspaceRunPanel struct {
+	title           string
+	width           int
+	height          int
+	workspace       *workspace.Workspace
+	scriptRunner    *scripts.ScriptRunner
+	scripts         []string       // Scripts of the packages, the most common first
+	counts          map[string]int // Number of packages having each script
+	cursor          int
+	parallel        int
+	continueOnError bool
+	run             *scripts.WorkspaceRun // Nil while choosing the script
+	offset          int
+	error           string
+}
+
+// NewWorkspaceRunPanel shows the last run across workspaces, or the scripts
+// that can be run
+func NewWorkspaceRunPanel(w *workspace.Workspace, scriptRunner *scripts.ScriptRunner) *WorkspaceRunPanel {
+	p := &WorkspaceRunPanel{
+		title:        "Run Across Workspaces",
+		workspace:    w,
+		scriptRunner: scriptRunner,
+		counts:       make(map[string]int),
+		parallel:     runtime.NumCPU() / 2,
+		run:          scriptRunner.LastWorkspaceRun,
+	}
+	if p.parallel < 1 {
+		p.parallel = 1
+	}
+
+	for _, pkg := range w.Packages {
+		for script := range pkg.Scripts {
+			if p.counts[script] == 0 {
+				p.scripts = append(p.scripts, script)
+			}
+			p.counts[script]++
+		}
+	}
+	sort.Slice(p.scripts, func(i, j int) bool {
+		if p.counts[p.scripts[i]] != p.counts[p.scripts[j]] {
+			return p.counts[p.scripts[i]] > p.counts[p.scripts[j]]
+		}
+		return p.scripts[i] < p.scripts[j]
+	})
+
+	if p.run != nil {
+		p.parallel = p.run.Parallel
+		p.continueOnError = p.run.ContinueOnError
+	}
+	return p
+}
+
+// start runs the script across the workspaces
+func (p *WorkspaceRunPanel) start(script string) {
+	run, err := p.scriptRunner.RunAcrossWorkspaces(p.workspace, script, p.parallel, p.continueOnError)
+	if err != nil {
+		p.error = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	p.run = run
+	p.scriptRunner.LastWorkspaceRun = run
+	p.offset = 0
+}
+
+// Init initializes the panel
+func (p *WorkspaceRunPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *WorkspaceRunPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	p.error = ""
+
+	if p.run != nil {
+		switch keyMsg.String() {
+		case "up", "k":
+			p.offset--
+		case "down", "j":
+			p.offset++
+		case "s":
+			// Stop the run
+			p.run.Stop()
+		case "r":
+			// Run the same script again
+			if p.run.Finished() {
+				p.start(p.run.Script)
+			}
+		case "n":
+			// Pick another script
+			if p.run.Finished() {
+				p.run = nil
+			}
+		}
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "+", "=":
+		p.parallel++
+	case "-":
+		if p.parallel > 1 {
+			p.parallel--
+		}
+	case "c":
+		p.continueOnError = !p.continueOnError
+	case "enter":
+		if len(p.scripts) > 0 {
+			p.start(p.scripts[p.cursor])
+		}
+	}
+
+	if p.cursor >= len(p.scripts) {
+		p.cursor = len(p.scripts) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of rows that fit
+func (p *WorkspaceRunPanel) pageSize() int {
+	// Header, settings and status line
+	size := p.height - 3
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// failureMode describes what happens when a package fails
+func (p *WorkspaceRunPanel) failureMode() string {
+	if p.continueOnError {
+		return "continue"
+	}
+	return "stop"
+}
+
+// View renders the panel
+func (p *WorkspaceRunPanel) View() string {
+	if p.run != nil {
+		return p.matrixView()
+	}
+
+	if len(p.scripts) == 0 {
+		return "No package of the monorepo has scripts"
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{"Run a script in every package that has it, dependencies first"}
+	for row := p.offset; row < len(p.scripts) && row < p.offset+size; row++ {
+		script := p.scripts[row]
+		line := fmt.Sprintf("%-24s %s", script, dimStyle.Render(fmt.Sprintf("%d of %d packages", p.counts[script], len(p.workspace.Packages))))
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the settings and status line stay at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, fmt.Sprintf("Parallel: %s  On failure: %s",
+		HighlightStyle.Render(fmt.Sprint(p.parallel)), HighlightStyle.Render(p.failureMode())))
+
+	status := "[↵]Run [+/-]Parallel [c]Continue or stop on failure"
+	if p.error != "" {
+		status = ErrorStyle.Render(p.error)
+	}
+	lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(status))
+	return strings.Join(lines, "\n")
+}
+
+// matrixView shows the state, duration and outcome of every package
+func (p *WorkspaceRunPanel) matrixView() string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+	packages := p.run.Packages()
+	finished := p.run.Finished()
+
+	// Count the packages in each state
+	counts := make(map[scripts.RunState]int)
+	for _, packageRun := range packages {
+		counts[packageRun.State]++
+	}
+	var summary []string
+	for _, state := range []scripts.RunState{scripts.RunSucceeded, scripts.RunFailed, scripts.RunRunning, scripts.RunPending, scripts.RunSkipped, scripts.RunCancelled} {
+		if counts[state] > 0 {
+			summary = append(summary, runStateStyles[state].Render(fmt.Sprintf("%d %s", counts[state], state)))
+		}
+	}
+	header := fmt.Sprintf("%s: %s - %s", p.run.Script, strings.Join(summary, ", "), p.run.Duration().Round(100*time.Millisecond))
+	if finished {
+		header += " (finished)"
+	}
+
+	// Keep the offset within the rows
+	size := p.pageSize()
+	if p.offset > len(packages)-size {
+		p.offset = len(packages) - size
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+
+	lines := []string{header}
+	for row := p.offset; row < len(packages) && row < p.offset+size; row++ {
+		packageRun := packages[row]
+		style := runStateStyles[packageRun.State]
+
+		duration := ""
+		if !packageRun.StartedAt.IsZero() {
+			duration = packageRun.Duration().Round(100 * time.Millisecond).String()
+		}
+
+		detail := packageRun.Reason
+		if packageRun.State == scripts.RunPending {
+			detail = p.waitingFor(packageRun, packages)
+		}
+
+		line := fmt.Sprintf("  %s %-30s %s %8s  %s",
+			style.Render(runStateIcons[packageRun.State]),
+			packageRun.Package.Name,
+			style.Render(fmt.Sprintf("%-9s", packageRun.State)),
+			duration,
+			dimStyle.Render(detail))
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the settings and status line stay at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, dimStyle.Render(fmt.Sprintf("Parallel: %d  On failure: %s  Output is in the Terminal panel", p.run.Parallel, p.failureMode())))
+
+	status := "[s]Stop"
+	if finished {
+		status = "[r]Run again [n]Another script"
+	}
+	if p.error != "" {
+		status = ErrorStyle.Render(p.error)
+	}
+	lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(status))
+	return strings.Join(lines, "\n")
+}
+
+// waitingFor names the dependencies a pending package waits for
+func (p *WorkspaceRunPanel) waitingFor(packageRun scripts.PackageRun, packages []scripts.PackageRun) string {
+	var waiting []string
+	for _, name := range packageRun.Package.LocalDependencies {
+		for _, dependency := range packages {
+			if dependency.Package.Name == name && !dependency.Done() {
+				waiting = append(waiting, name)
+			}
+		}
+	}
+	if len(waiting) == 0 {
+		return "waiting for a free slot"
+	}
+	return "waiting for " + strings.Join(waiting, ", ")
+}
+
+// Width returns the panel width
+func (p *WorkspaceRunPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *WorkspaceRunPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *WorkspaceRunPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *WorkspaceRunPanel) Title() string {
+	return p.title
+}
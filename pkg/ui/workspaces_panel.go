@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/VesperAkshay/lazynode/pkg/npm"
+	"github.com/VesperAkshay/lazynode/pkg/scripts"
+	"github.com/VesperAkshay/lazynode/pkg/workspace"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// switchProjectMsg makes another package.json the active project
+type switchProjectMsg struct {
+	path string
+}
+
+// workspaceRootName returns the name of the root package of a monorepo,
+// or its directory when it has none
+func workspaceRootName(w *workspace.Workspace) string {
+	if w.Root.Name != "" {
+		return w.Root.Name
+	}
+	return filepath.Base(w.RootDir)
+}
+
+// WorkspacesPanel lists the root and the packages of a monorepo with their
+// version and the packages they depend on, and switches the active project
+type WorkspacesPanel struct {
+	title          string
+	width          int
+	height         int
+	workspace      *workspace.Workspace
+	scriptRunner   *scripts.ScriptRunner
+	packageManager *npm.PackageManager
+	active         string               // package.json of the active project
+	packages       []*workspace.Package // The root first, then the packages
+	cursor         int
+	offset         int
+}
+
+// NewWorkspacesPanel lists the packages of a monorepo, with the cursor on
+// the active project
+func NewWorkspacesPanel(w *workspace.Workspace, scriptRunner *scripts.ScriptRunner, packageManager *npm.PackageManager, activePackageJSONPath string) *WorkspacesPanel {
+	p := &WorkspacesPanel{
+		title:          "Workspaces",
+		workspace:      w,
+		scriptRunner:   scriptRunner,
+		packageManager: packageManager,
+		active:         activePackageJSONPath,
+		packages:       append([]*workspace.Package{w.Root}, w.Packages...),
+	}
+
+	for i, pkg := range p.packages {
+		if filepath.Clean(pkg.PackageJSONPath) == filepath.Clean(activePackageJSONPath) {
+			p.cursor = i
+		}
+	}
+	return p
+}
+
+// Init initializes the panel
+func (p *WorkspacesPanel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (p *WorkspacesPanel) Update(msg tea.Msg) (Panel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.pageSize()
+	case "pgdown":
+		p.cursor += p.pageSize()
+	case "g", "home":
+		p.cursor = 0
+	case "G", "end":
+		p.cursor = len(p.packages) - 1
+
+	case "enter":
+		// Make the selected package the active project
+		pkg := p.packages[p.cursor]
+		if filepath.Clean(pkg.PackageJSONPath) == filepath.Clean(p.active) {
+			return p, closeOverlay
+		}
+		return p, func() tea.Msg {
+			return switchProjectMsg{path: pkg.PackageJSONPath}
+		}
+
+	case "x":
+		// Run a script in every package
+		return p, openOverlay(NewWorkspaceRunPanel(p.workspace, p.scriptRunner))
+
+	case "v":
+		// Compare the dependency ranges of the packages
+		return p, openOverlay(NewDependencyReportPanel(p.packageManager))
+	}
+
+	if p.cursor >= len(p.packages) {
+		p.cursor = len(p.packages) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+
+	return p, nil
+}
+
+// pageSize returns the number of packages that fit
+func (p *WorkspacesPanel) pageSize() int {
+	// Header and status line
+	size := p.height - 2
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// packageLine renders a package with its version, directory and the local
+// packages it depends on
+func (p *WorkspacesPanel) packageLine(pkg *workspace.Package) string {
+	dimStyle := lipgloss.NewStyle().Foreground(terminalBrightBlack)
+
+	name := pkg.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	dir := "(root)"
+	if pkg != p.workspace.Root {
+		if rel, err := filepath.Rel(p.workspace.RootDir, pkg.Dir); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+
+	line := fmt.Sprintf("%-30s %-10s %s", name, pkg.Version, dimStyle.Render(dir))
+	if pkg.Private && pkg != p.workspace.Root {
+		line += dimStyle.Render(" private")
+	}
+	if len(pkg.LocalDependencies) > 0 {
+		line += HighlightStyle.Render("  → " + strings.Join(pkg.LocalDependencies, ", "))
+	}
+	if pkg != p.workspace.Root {
+		if dependents := p.workspace.Dependents(pkg.Name); len(dependents) > 0 {
+			line += dimStyle.Render("  ← " + strings.Join(dependents, ", "))
+		}
+	}
+	return line
+}
+
+// View renders the panel
+func (p *WorkspacesPanel) View() string {
+	header := fmt.Sprintf("%s: %d packages from %s",
+		workspaceRootName(p.workspace), len(p.workspace.Packages), strings.Join(p.workspace.Sources, ", "))
+
+	// Keep the cursor in view
+	size := p.pageSize()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+size {
+		p.offset = p.cursor - size + 1
+	}
+
+	lines := []string{header}
+	for row := p.offset; row < len(p.packages) && row < p.offset+size; row++ {
+		pkg := p.packages[row]
+
+		marker := " "
+		if filepath.Clean(pkg.PackageJSONPath) == filepath.Clean(p.active) {
+			marker = HighlightStyle.Render("●")
+		}
+		line := marker + " " + p.packageLine(pkg)
+		if row == p.cursor {
+			line = SelectedItemStyle.Render("›") + line
+		} else {
+			line = " " + line
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(line))
+	}
+
+	// Pad so the status line stays at the bottom
+	for len(lines) < size+1 {
+		lines = append(lines, "")
+	}
+
+	status := "[↵]Switch to package [x]Run a script in every package [v]Version drift  → depends on  ← used by"
+	lines = append(lines, lipgloss.NewStyle().MaxWidth(p.width).Render(status))
+	return strings.Join(lines, "\n")
+}
+
+// Width returns the panel width
+func (p *WorkspacesPanel) Width() int {
+	return p.width
+}
+
+// Height returns the panel height
+func (p *WorkspacesPanel) Height() int {
+	return p.height
+}
+
+// SetSize sets the panel size
+func (p *WorkspacesPanel) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Title returns the panel title
+func (p *WorkspacesPanel) Title() string {
+	return p.title
+}
@@ -0,0 +1,127 @@
+package workspace
+
+import (
+	"sort"
+	"strings"
+)
+
+// driftSections are the dependency sections compared across packages. Peer
+// ranges are left out: they are meant to be wide and to differ.
+var driftSections = []string{"dependencies", "devDependencies", "optionalDependencies"}
+
+// localProtocols are range prefixes pointing inside the monorepo rather
+// than at the registry
+var localProtocols = []string{"workspace:", "catalog:", "link:", "file:", "portal:"}
+
+// Usage is the declaration of a dependency by one package
+type Usage struct {
+	Package *Package
+	Section string // e.g. "devDependencies"
+	Range   string
+}
+
+// Drift is a dependency declared with different ranges across the packages
+// of a monorepo
+type Drift struct {
+	Name   string
+	Ranges []string // Distinct ranges, the most used first
+	Usages []Usage  // Sorted by package name, then package.json path
+}
+
+// Count returns how many packages declare the dependency with a range
+func (d Drift) Count(rng string) int {
+	count := 0
+	for _, usage := range d.Usages {
+		if usage.Range == rng {
+			count++
+		}
+	}
+	return count
+}
+
+// Paths returns the package.json files declaring the dependency
+func (d Drift) Paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, usage := range d.Usages {
+		if !seen[usage.Package.PackageJSONPath] {
+			seen[usage.Package.PackageJSONPath] = true
+			paths = append(paths, usage.Package.PackageJSONPath)
+		}
+	}
+	return paths
+}
+
+// Drift lists the registry dependencies the root and the packages declare
+// with more than one range, by name. The packages of the monorepo and
+// ranges such as workspace:* are left out.
+func (w *Workspace) Drift() []Drift {
+	usages := make(map[string][]Usage)
+	for _, pkg := range append([]*Package{w.Root}, w.Packages...) {
+		for _, section := range driftSections {
+			for name, rng := range pkg.Sections[section] {
+				if w.Package(name) != nil || isLocalRange(rng) {
+					continue
+				}
+				usages[name] = append(usages[name], Usage{Package: pkg, Section: section, Range: rng})
+			}
+		}
+	}
+
+	var drifts []Drift
+	for name, declared := range usages {
+		drift := Drift{Name: name, Usages: declared}
+		for _, usage := range declared {
+			if !contains(drift.Ranges, usage.Range) {
+				drift.Ranges = append(drift.Ranges, usage.Range)
+			}
+		}
+		if len(drift.Ranges) < 2 {
+			continue
+		}
+
+		sort.Slice(drift.Ranges, func(i, j int) bool {
+			if ci, cj := drift.Count(drift.Ranges[i]), drift.Count(drift.Ranges[j]); ci != cj {
+				return ci > cj
+			}
+			return drift.Ranges[i] < drift.Ranges[j]
+		})
+		sort.Slice(drift.Usages, func(i, j int) bool {
+			a, b := drift.Usages[i], drift.Usages[j]
+			if a.Package.Name != b.Package.Name {
+				return a.Package.Name < b.Package.Name
+			}
+			// Packages without a name are told apart by their path
+			if a.Package.PackageJSONPath != b.Package.PackageJSONPath {
+				return a.Package.PackageJSONPath < b.Package.PackageJSONPath
+			}
+			return a.Section < b.Section
+		})
+		drifts = append(drifts, drift)
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Name < drifts[j].Name
+	})
+	return drifts
+}
+
+// isLocalRange reports whether a range points inside the monorepo
+func isLocalRange(rng string) bool {
+	for _, protocol := range localProtocols {
+		if strings.HasPrefix(rng, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether a list holds a string
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
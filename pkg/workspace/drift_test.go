@@ -0,0 +1,85 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDrift(t *testing.T) {
+	w, err := Load(filepath.Join("testdata", "drift"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each usage reads "package.json section range", by path from the root
+	describe := func(drift Drift) []string {
+		var usages []string
+		for _, usage := range drift.Usages {
+			rel, err := filepath.Rel(w.RootDir, usage.Package.PackageJSONPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			usages = append(usages, fmt.Sprintf("%s %s %s", filepath.ToSlash(rel), usage.Section, usage.Range))
+		}
+		return usages
+	}
+
+	// lodash agrees everywhere; zod and vendored have a single registry
+	// range next to local protocols; ui is a package of the monorepo
+	drifts := w.Drift()
+	var names []string
+	for _, drift := range drifts {
+		names = append(names, drift.Name)
+	}
+	if want := []string{"react", "typescript"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Drift() = %q, want %q", names, want)
+	}
+
+	tests := []struct {
+		drift  Drift
+		ranges []string
+		usages []string
+	}{
+		{
+			drift:  drifts[0],
+			ranges: []string{"^18.2.0", "^17.0.2", "^18.0.0"},
+			// The unnamed examples come first, apart by path; peers are left out
+			usages: []string{
+				"examples/a/package.json dependencies ^17.0.2",
+				"examples/b/package.json dependencies ^18.2.0",
+				"packages/app/package.json dependencies ^18.0.0",
+				"packages/ui/package.json dependencies ^18.2.0",
+			},
+		},
+		{
+			drift:  drifts[1],
+			ranges: []string{"^5.3.0", "^5.0.0"},
+			usages: []string{
+				"examples/a/package.json devDependencies ^5.0.0",
+				"package.json devDependencies ^5.3.0",
+				"packages/ui/package.json devDependencies ^5.3.0",
+				"packages/utils/package.json devDependencies ^5.3.0",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.drift.Name, func(t *testing.T) {
+			if !reflect.DeepEqual(tc.drift.Ranges, tc.ranges) {
+				t.Errorf("ranges = %q, want %q", tc.drift.Ranges, tc.ranges)
+			}
+			if got := describe(tc.drift); !reflect.DeepEqual(got, tc.usages) {
+				t.Errorf("usages = %q, want %q", got, tc.usages)
+			}
+		})
+	}
+
+	if got := drifts[0].Count("^18.2.0"); got != 2 {
+		t.Errorf("Count(^18.2.0) = %d, want 2", got)
+	}
+	if got := len(drifts[1].Paths()); got != 4 {
+		t.Errorf("Paths() lists %d files, want 4", got)
+	}
+}
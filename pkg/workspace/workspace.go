@@ -0,0 +1,427 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound is returned when a directory is not part of a monorepo
+var ErrNotFound = errors.New("no workspace root found")
+
+// Package is a package of a monorepo, or its root
+type Package struct {
+	Name            string
+	Version         string
+	Private         bool
+	Dir             string
+	PackageJSONPath string
+	// Dependencies are the ranges of every dependency section merged
+	Dependencies map[string]string
+	// Sections are the ranges of each dependency section, e.g. "devDependencies"
+	Sections map[string]map[string]string
+	// LocalDependencies are the other packages of the monorepo it depends on, sorted
+	LocalDependencies []string
+	Scripts           map[string]string
+}
+
+// Selector names the package for workspace flags: its name, or its path
+// from the root when it has none
+func (p *Package) Selector(rootDir string) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	rel, err := filepath.Rel(rootDir, p.Dir)
+	if err != nil {
+		return p.Dir
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// Workspace is a monorepo: a root package.json and the packages its
+// workspace configuration lists
+type Workspace struct {
+	RootDir  string
+	Root     *Package
+	Packages []*Package // Sorted by name
+	// Sources are the files the package globs came from, e.g. "pnpm-workspace.yaml"
+	Sources []string
+}
+
+// Package returns the package with the given name, or nil
+func (w *Workspace) Package(name string) *Package {
+	for _, pkg := range w.Packages {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// PackageAt returns the package in dir, the root included, or nil
+func (w *Workspace) PackageAt(dir string) *Package {
+	if filepath.Clean(dir) == filepath.Clean(w.RootDir) {
+		return w.Root
+	}
+	for _, pkg := range w.Packages {
+		if filepath.Clean(pkg.Dir) == filepath.Clean(dir) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// Dependents returns the packages depending on the named one, sorted
+func (w *Workspace) Dependents(name string) []string {
+	var dependents []string
+	for _, pkg := range w.Packages {
+		for _, dependency := range pkg.LocalDependencies {
+			if dependency == name {
+				dependents = append(dependents, pkg.Name)
+			}
+		}
+	}
+	return dependents
+}
+
+// Sorted returns the packages with every package after the ones it depends
+// on, and by name otherwise. A dependency cycle is an error.
+func (w *Workspace) Sorted() ([]*Package, error) {
+	var sorted []*Package
+	state := make(map[*Package]int) // 1 while visiting, 2 once sorted
+
+	var visit func(pkg *Package, path []string) error
+	visit = func(pkg *Package, path []string) error {
+		switch state[pkg] {
+		case 1:
+			// Show the cycle alone, without the path leading to it
+			for i, name := range path {
+				if name == pkg.Name {
+					path = path[i:]
+					break
+				}
+			}
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, pkg.Name), " → "))
+		case 2:
+			return nil
+		}
+
+		state[pkg] = 1
+		for _, name := range pkg.LocalDependencies {
+			if dependency := w.Package(name); dependency != nil {
+				if err := visit(dependency, append(path, pkg.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[pkg] = 2
+		sorted = append(sorted, pkg)
+		return nil
+	}
+
+	for _, pkg := range w.Packages {
+		if err := visit(pkg, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Find looks for the monorepo dir belongs to, walking up from dir to the
+// first directory whose workspace configuration lists it
+func Find(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for current := dir; ; {
+		// A broken configuration above the project is not ours to report
+		if w, err := Load(current); err == nil && w.PackageAt(dir) != nil {
+			return w, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return nil, ErrNotFound
+		}
+		current = parent
+	}
+}
+
+// Load reads the monorepo rooted in dir. The packages are listed by the
+// workspaces field of package.json, pnpm-workspace.yaml, lerna.json and the
+// workspace layout of nx.json. ErrNotFound is returned when dir declares no
+// workspaces.
+func Load(dir string) (*Workspace, error) {
+	root, err := readPackage(filepath.Join(dir, "package.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	patterns, sources, err := patterns(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, ErrNotFound
+	}
+
+	w := &Workspace{RootDir: dir, Root: root, Sources: sources}
+	for _, packageDir := range expand(dir, patterns) {
+		pkg, err := readPackage(filepath.Join(packageDir, "package.json"))
+		if err != nil {
+			continue // Not a package, or a broken one
+		}
+		w.Packages = append(w.Packages, pkg)
+	}
+	sort.Slice(w.Packages, func(i, j int) bool {
+		return w.Packages[i].Name < w.Packages[j].Name
+	})
+
+	// Link the packages depending on each other
+	names := make(map[string]bool)
+	for _, pkg := range w.Packages {
+		names[pkg.Name] = true
+	}
+	for _, pkg := range append([]*Package{w.Root}, w.Packages...) {
+		for name := range pkg.Dependencies {
+			if names[name] && name != pkg.Name {
+				pkg.LocalDependencies = append(pkg.LocalDependencies, name)
+			}
+		}
+		sort.Strings(pkg.LocalDependencies)
+	}
+
+	return w, nil
+}
+
+// manifest is the part of a package.json read for workspaces
+type manifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Private              bool              `json:"private"`
+	Scripts              map[string]string `json:"scripts"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	// Workspaces is a list of globs, or an object with a packages list (yarn)
+	Workspaces json.RawMessage `json:"workspaces"`
+}
+
+// readPackage reads a package.json
+func readPackage(packageJSONPath string) (*Package, error) {
+	data, err := os.ReadFile(packageJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		Name:            m.Name,
+		Version:         m.Version,
+		Private:         m.Private,
+		Dir:             filepath.Dir(packageJSONPath),
+		PackageJSONPath: packageJSONPath,
+		Dependencies:    make(map[string]string),
+		Sections: map[string]map[string]string{
+			"dependencies":         m.Dependencies,
+			"devDependencies":      m.DevDependencies,
+			"optionalDependencies": m.OptionalDependencies,
+			"peerDependencies":     m.PeerDependencies,
+		},
+		Scripts: m.Scripts,
+	}
+	for _, section := range []map[string]string{m.PeerDependencies, m.OptionalDependencies, m.DevDependencies, m.Dependencies} {
+		for name, version := range section {
+			pkg.Dependencies[name] = version
+		}
+	}
+	return pkg, nil
+}
+
+// patterns collects the package globs of every workspace configuration in
+// dir, along with the files they came from
+func patterns(dir string) ([]string, []string, error) {
+	var patterns, sources []string
+
+	// package.json: "workspaces": [...] or {"packages": [...]}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, nil, err
+		}
+		var list []string
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(m.Workspaces, &list) == nil && list != nil {
+			patterns = append(patterns, list...)
+			sources = append(sources, "package.json")
+		} else if json.Unmarshal(m.Workspaces, &object) == nil && object.Packages != nil {
+			patterns = append(patterns, object.Packages...)
+			sources = append(sources, "package.json")
+		}
+	}
+
+	// pnpm-workspace.yaml: packages: [...]
+	if data, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		var config struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, nil, err
+		}
+		patterns = append(patterns, config.Packages...)
+		sources = append(sources, "pnpm-workspace.yaml")
+	}
+
+	// lerna.json: "packages": [...], defaulting to packages/*
+	if data, err := os.ReadFile(filepath.Join(dir, "lerna.json")); err == nil {
+		var config struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, nil, err
+		}
+		if config.Packages == nil {
+			config.Packages = []string{"packages/*"}
+		}
+		patterns = append(patterns, config.Packages...)
+		sources = append(sources, "lerna.json")
+	}
+
+	// nx.json: projects live in the apps and libs directories of the layout
+	if data, err := os.ReadFile(filepath.Join(dir, "nx.json")); err == nil {
+		var config struct {
+			WorkspaceLayout struct {
+				AppsDir string `json:"appsDir"`
+				LibsDir string `json:"libsDir"`
+			} `json:"workspaceLayout"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, nil, err
+		}
+		appsDir, libsDir := config.WorkspaceLayout.AppsDir, config.WorkspaceLayout.LibsDir
+		if appsDir == "" {
+			appsDir = "apps"
+		}
+		if libsDir == "" {
+			libsDir = "libs"
+		}
+		patterns = append(patterns, appsDir+"/*", libsDir+"/*", "packages/*")
+		sources = append(sources, "nx.json")
+	}
+
+	return patterns, sources, nil
+}
+
+// expand returns the directories below root holding a package.json that
+// match the globs. Globs starting with ! exclude directories, and ** matches
+// any number of directories. node_modules and hidden directories are skipped.
+func expand(root string, patterns []string) []string {
+	var include, exclude []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(path.Clean(filepath.ToSlash(pattern)), "/")
+		pattern = strings.TrimPrefix(pattern, "./")
+		if strings.HasPrefix(pattern, "!") {
+			exclude = append(exclude, strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"))
+		} else if pattern != "" && pattern != "." {
+			include = append(include, pattern)
+		}
+	}
+	if len(include) == 0 {
+		return nil
+	}
+
+	// Only walk as deep as the globs reach
+	depth := 0
+	for _, pattern := range include {
+		if strings.Contains(pattern, "**") {
+			depth = -1
+			break
+		}
+		if d := strings.Count(pattern, "/") + 1; d > depth {
+			depth = d
+		}
+	}
+
+	var dirs []string
+	filepath.WalkDir(root, func(current string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if current == root {
+			return nil
+		}
+		if name := entry.Name(); name == "node_modules" || strings.HasPrefix(name, ".") {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchAny(include, rel) && !matchAny(exclude, rel) {
+			if _, err := os.Stat(filepath.Join(current, "package.json")); err == nil {
+				dirs = append(dirs, current)
+			}
+		}
+
+		if depth >= 0 && strings.Count(rel, "/")+1 >= depth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return dirs
+}
+
+// matchAny reports whether a relative path matches one of the globs
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if match(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// match matches path segments against glob segments, where ** stands for
+// zero or more segments
+func match(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if match(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
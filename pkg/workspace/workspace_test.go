@@ -0,0 +1,199 @@
+package workspace
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	w := &Workspace{
+		RootDir: "/repo",
+		Packages: []*Package{
+			{Name: "app", Dir: "/repo/apps/app", LocalDependencies: []string{"ui", "utils"}},
+			{Name: "ui", Dir: "/repo/packages/ui", LocalDependencies: []string{"utils"}},
+			{Name: "utils", Dir: "/repo/packages/utils"},
+		},
+	}
+
+	sorted, err := w.Sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pkg := range sorted {
+		names = append(names, pkg.Name)
+	}
+	if got, want := strings.Join(names, " "), "utils ui app"; got != want {
+		t.Errorf("Sorted() = %s, want %s", got, want)
+	}
+}
+
+func TestSortedKeepsUnnamedPackages(t *testing.T) {
+	w := &Workspace{
+		RootDir: "/repo",
+		Packages: []*Package{
+			{Dir: "/repo/examples/a"},
+			{Dir: "/repo/examples/b"},
+			{Name: "lib", Dir: "/repo/packages/lib"},
+		},
+	}
+
+	sorted, err := w.Sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sorted) != 3 {
+		var selectors []string
+		for _, pkg := range sorted {
+			selectors = append(selectors, pkg.Selector(w.RootDir))
+		}
+		t.Fatalf("Sorted() = %v, want all 3 packages", selectors)
+	}
+}
+
+func TestSortedCycle(t *testing.T) {
+	w := &Workspace{
+		Packages: []*Package{
+			{Name: "a", LocalDependencies: []string{"b"}},
+			{Name: "b", LocalDependencies: []string{"c"}},
+			{Name: "c", LocalDependencies: []string{"b"}},
+		},
+	}
+
+	_, err := w.Sorted()
+	if err == nil || !strings.Contains(err.Error(), "b → c → b") {
+		t.Fatalf("Sorted() error = %v, want the cycle b → c → b", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		dir     string
+		sources []string
+		// packages lists each package as "name dir", sorted by name
+		packages []string
+	}{
+		{
+			dir:      "npm",
+			sources:  []string{"package.json"},
+			packages: []string{"a packages/a", "b packages/b"},
+		},
+		{
+			// yarn's object form, with its other settings
+			dir:      "yarn",
+			sources:  []string{"package.json"},
+			packages: []string{"web apps/web"},
+		},
+		{
+			// ** reaches nested packages, ! excludes, node_modules and
+			// hidden directories are skipped
+			dir:      "pnpm",
+			sources:  []string{"pnpm-workspace.yaml"},
+			packages: []string{"cli packages/tools/cli", "core packages/core"},
+		},
+		{
+			// Without a packages field lerna uses packages/*
+			dir:      "lerna",
+			sources:  []string{"lerna.json"},
+			packages: []string{"x packages/x"},
+		},
+		{
+			dir:      "nx",
+			sources:  []string{"nx.json"},
+			packages: []string{"site projects/site", "ui shared/ui"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dir, func(t *testing.T) {
+			w, err := Load(filepath.Join("testdata", tc.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(w.Sources, tc.sources) {
+				t.Errorf("Sources = %q, want %q", w.Sources, tc.sources)
+			}
+
+			var packages []string
+			for _, pkg := range w.Packages {
+				rel, err := filepath.Rel(w.RootDir, pkg.Dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				packages = append(packages, pkg.Name+" "+filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(packages, tc.packages) {
+				t.Errorf("packages = %q, want %q", packages, tc.packages)
+			}
+		})
+	}
+}
+
+func TestLoadLinksLocalDependencies(t *testing.T) {
+	w, err := Load(filepath.Join("testdata", "npm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lodash comes from the registry
+	if got := w.Package("a").LocalDependencies; !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("a depends on %q, want b", got)
+	}
+	if got := w.Root.LocalDependencies; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("the root depends on %q, want a", got)
+	}
+	if got := w.Dependents("b"); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Dependents(b) = %q, want a", got)
+	}
+}
+
+func TestLoadWithoutWorkspaces(t *testing.T) {
+	for _, dir := range []string{"plain", "missing"} {
+		if _, err := Load(filepath.Join("testdata", dir)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Load(%s) error = %v, want ErrNotFound", dir, err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		dir     string
+		rootDir string
+		pkg     string // "" for the root itself
+	}{
+		{dir: "pnpm/packages/tools/cli", rootDir: "pnpm", pkg: "cli"},
+		{dir: "pnpm/packages/core", rootDir: "pnpm", pkg: "core"},
+		{dir: "npm/packages/b", rootDir: "npm", pkg: "b"},
+		{dir: "nx/shared/ui", rootDir: "nx", pkg: "ui"},
+		{dir: "yarn", rootDir: "yarn"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dir, func(t *testing.T) {
+			w, err := Find(filepath.Join("testdata", filepath.FromSlash(tc.dir)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := filepath.Abs(filepath.Join("testdata", tc.rootDir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.RootDir != want {
+				t.Errorf("RootDir = %s, want %s", w.RootDir, want)
+			}
+
+			pkg := w.PackageAt(filepath.Join(want, filepath.FromSlash(strings.TrimPrefix(tc.dir, tc.rootDir))))
+			switch {
+			case pkg == nil:
+				t.Errorf("%s is not a package of the workspace", tc.dir)
+			case tc.pkg == "" && pkg != w.Root:
+				t.Errorf("PackageAt(%s) = %s, want the root", tc.dir, pkg.Name)
+			case tc.pkg != "" && pkg.Name != tc.pkg:
+				t.Errorf("PackageAt(%s) = %s, want %s", tc.dir, pkg.Name, tc.pkg)
+			}
+		})
+	}
+}